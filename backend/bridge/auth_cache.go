@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// cachingVerifier memoizes another verifier's results so a valid token costs
+// one round trip to Supabase per ttl instead of one per request.
+//
+//   - Entries are keyed by SHA-256 of the token; raw tokens are never stored.
+//   - A positive entry never outlives the token's exp (or maxTTL, whichever is first).
+//   - Final rejections (invalid/expired) are cached for negTTL; transient
+//     failures (unreachable, not configured) are not cached.
+//   - Concurrent verifications of the same token share one upstream call.
+//
+// Trade-off: a token revoked upstream stays accepted here for up to maxTTL.
+type cachingVerifier struct {
+	next       TokenVerifier
+	maxTTL     time.Duration
+	negTTL     time.Duration
+	maxEntries int
+
+	mu       sync.Mutex
+	entries  map[[32]byte]*verifyCacheEntry
+	inflight map[[32]byte]*verifyCall
+
+	hits, negHits, misses, shared atomic.Int64
+}
+
+type verifyCacheEntry struct {
+	claims    *JWTClaims
+	jerr      *JWTError
+	expiresAt time.Time
+}
+
+type verifyCall struct {
+	done   chan struct{}
+	claims *JWTClaims
+	jerr   *JWTError
+}
+
+// verifyCacheStats is a point-in-time snapshot of cache counters.
+type verifyCacheStats struct {
+	Hits    int64
+	NegHits int64
+	Misses  int64
+	Shared  int64
+	Entries int
+	HitRate float64
+}
+
+func newCachingVerifier(next TokenVerifier, maxTTL, negTTL time.Duration, maxEntries int) *cachingVerifier {
+	return &cachingVerifier{
+		next:       next,
+		maxTTL:     maxTTL,
+		negTTL:     negTTL,
+		maxEntries: maxEntries,
+		entries:    make(map[[32]byte]*verifyCacheEntry),
+		inflight:   make(map[[32]byte]*verifyCall),
+	}
+}
+
+func (c *cachingVerifier) Name() string { return c.next.Name() + "+cache" }
+
+func (c *cachingVerifier) Verify(ctx context.Context, token string) (*JWTClaims, *JWTError) {
+	key := sha256.Sum256([]byte(token))
+	now := time.Now()
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		if now.Before(e.expiresAt) {
+			c.mu.Unlock()
+			if e.jerr != nil {
+				c.count(&c.negHits, "negative_hit")
+			} else {
+				c.count(&c.hits, "hit")
+			}
+			return copyVerifyResult(e.claims, e.jerr)
+		}
+		delete(c.entries, key)
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		c.count(&c.shared, "shared")
+		select {
+		case <-call.done:
+			return copyVerifyResult(call.claims, call.jerr)
+		case <-ctx.Done():
+			return nil, jwtUnauthorized(jwtErrUnreachable, "Verification cancelled")
+		}
+	}
+	call := &verifyCall{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	c.count(&c.misses, "miss")
+	// Detach from the first caller's cancellation: other callers share this result.
+	call.claims, call.jerr = c.next.Verify(context.WithoutCancel(ctx), token)
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if ttl := c.ttlFor(call.claims, call.jerr, time.Now()); ttl > 0 {
+		if len(c.entries) >= c.maxEntries {
+			c.sweepLocked(time.Now())
+		}
+		if len(c.entries) < c.maxEntries {
+			c.entries[key] = &verifyCacheEntry{claims: call.claims, jerr: call.jerr, expiresAt: time.Now().Add(ttl)}
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+
+	return copyVerifyResult(call.claims, call.jerr)
+}
+
+// count records one lookup outcome in n and in the lookups metric.
+func (c *cachingVerifier) count(n *atomic.Int64, result string) {
+	n.Add(1)
+	bridgeMetrics.authCacheLookups.Inc(result)
+}
+
+// ttlFor returns how long a result may be cached (0 = do not cache).
+func (c *cachingVerifier) ttlFor(claims *JWTClaims, jerr *JWTError, now time.Time) time.Duration {
+	if jerr != nil {
+		switch jerr.Code {
+		case jwtErrUnreachable, jwtErrNotConfigured, jwtErrUnsupported:
+			return 0
+		}
+		if jerr.Error == "server_error" {
+			return 0
+		}
+		return c.negTTL
+	}
+	if claims == nil || claims.ExpiresAt == 0 {
+		// Without exp we cannot bound the entry; always re-verify.
+		return 0
+	}
+	ttl := time.Unix(claims.ExpiresAt, 0).Sub(now)
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl
+}
+
+// sweepLocked drops expired entries. Caller holds c.mu.
+func (c *cachingVerifier) sweepLocked(now time.Time) {
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
+
+// Stats returns the current counters.
+func (c *cachingVerifier) Stats() verifyCacheStats {
+	c.mu.Lock()
+	n := len(c.entries)
+	c.mu.Unlock()
+
+	s := verifyCacheStats{
+		Hits:    c.hits.Load(),
+		NegHits: c.negHits.Load(),
+		Misses:  c.misses.Load(),
+		Shared:  c.shared.Load(),
+		Entries: n,
+	}
+	if total := s.Hits + s.NegHits + s.Misses + s.Shared; total > 0 {
+		s.HitRate = float64(s.Hits+s.NegHits+s.Shared) / float64(total)
+	}
+	return s
+}
+
+// startCleanupRoutine sweeps expired entries and logs the hit rate until ctx
+// is done.
+func (c *cachingVerifier) startCleanupRoutine(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(5 * time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			c.mu.Lock()
+			c.sweepLocked(time.Now())
+			c.mu.Unlock()
+
+			s := c.Stats()
+			logging.Debug(ctx, "auth cache swept",
+				logging.Public("entries", s.Entries), logging.Public("hits", s.Hits),
+				logging.Public("negative_hits", s.NegHits), logging.Public("shared", s.Shared),
+				logging.Public("misses", s.Misses), logging.Public("hit_rate", s.HitRate))
+		}
+	}()
+}
+
+// copyVerifyResult returns private copies; authMiddleware mutates JWTError.
+func copyVerifyResult(claims *JWTClaims, jerr *JWTError) (*JWTClaims, *JWTError) {
+	if jerr != nil {
+		e := *jerr
+		return nil, &e
+	}
+	if claims == nil {
+		return nil, nil
+	}
+	cl := *claims
+	return &cl, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// devIssuerName is the iss claim of locally minted development tokens.
+const devIssuerName = "privxx-dev-issuer"
+
+// devIssuer mints real ES256 tokens for development and air-gapped tests.
+// The key is generated at startup (or fixed by AUTH_DEV_ISSUER_KEY) and
+// never leaves the process. Enabled with AUTH_DEV_ISSUER=true, which is refused
+// unless ENVIRONMENT=development.
+type devIssuer struct {
+	key    *ecdsa.PrivateKey
+	kid    string
+	maxTTL time.Duration
+}
+
+// devTokens is non-nil when the dev issuer is enabled (see loadTokenVerifier).
+var devTokens *devIssuer
+
+// newDevIssuer uses the P-256 scalar seed (32 bytes) if given, else a fresh key.
+func newDevIssuer(seed []byte) (*devIssuer, error) {
+	var key *ecdsa.PrivateKey
+	if seed == nil {
+		k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		key = k
+	} else {
+		curve := elliptic.P256()
+		d := new(big.Int).SetBytes(seed)
+		if len(seed) != 32 || d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+			return nil, errors.New("AUTH_DEV_ISSUER_KEY must be a valid 32-byte P-256 scalar (hex)")
+		}
+		key = &ecdsa.PrivateKey{D: d, PublicKey: ecdsa.PublicKey{Curve: curve}}
+		key.X, key.Y = curve.ScalarBaseMult(seed)
+	}
+	sum := sha256.Sum256(elliptic.Marshal(key.Curve, key.X, key.Y))
+	return &devIssuer{key: key, kid: fmt.Sprintf("dev-%x", sum[:8]), maxTTL: 24 * time.Hour}, nil
+}
+
+// loadDevIssuer returns the dev issuer if AUTH_DEV_ISSUER=true. With
+// AUTH_DEV_ISSUER_KEY set, minted tokens stay valid across restarts.
+func loadDevIssuer() (*devIssuer, error) {
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("AUTH_DEV_ISSUER")), "true") {
+		return nil, nil
+	}
+	if os.Getenv("ENVIRONMENT") != "development" {
+		return nil, errors.New("AUTH_DEV_ISSUER requires ENVIRONMENT=development")
+	}
+	var seed []byte
+	if v := strings.TrimSpace(os.Getenv("AUTH_DEV_ISSUER_KEY")); v != "" {
+		b, err := hex.DecodeString(v)
+		if err != nil {
+			return nil, errors.New("AUTH_DEV_ISSUER_KEY must be hex")
+		}
+		seed = b
+	}
+	return newDevIssuer(seed)
+}
+
+// Mint signs a token for sub with the default audience.
+func (d *devIssuer) Mint(sub, email string, ttl time.Duration) (string, time.Time, error) {
+	if strings.TrimSpace(sub) == "" {
+		return "", time.Time{}, errors.New("sub required")
+	}
+	if ttl <= 0 || ttl > d.maxTTL {
+		ttl = time.Hour
+	}
+	now := time.Now()
+	exp := now.Add(ttl)
+	claims := jwt.MapClaims{
+		"iss": devIssuerName,
+		"sub": sub,
+		"aud": defaultJWTAudience,
+		"iat": now.Unix(),
+		"exp": exp.Unix(),
+	}
+	if email != "" {
+		claims["email"] = email
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	tok.Header["kid"] = d.kid
+	signed, err := tok.SignedString(d.key)
+	return signed, exp, err
+}
+
+// verifier checks tokens minted by this issuer, offline.
+func (d *devIssuer) verifier() *jwtVerifier {
+	return &jwtVerifier{
+		name: "dev",
+		algs: []string{"ES256"},
+		key: func(_ context.Context, t *jwt.Token) (any, error) {
+			if kid, _ := t.Header["kid"].(string); kid != d.kid {
+				return nil, errUnknownKid
+			}
+			return &d.key.PublicKey, nil
+		},
+		issuer:    devIssuerName,
+		audiences: []string{defaultJWTAudience},
+	}
+}
+
+// registerDevIssuerRoutes exposes POST /dev/token. Only called when the dev
+// issuer is enabled; the route is absent from production route tables.
+func (s *server) registerDevIssuerRoutes(d *devIssuer) {
+	type tokenReq struct {
+		Sub        string `json:"sub"`
+		Email      string `json:"email,omitempty"`
+		TTLSeconds int64  `json:"ttlSeconds,omitempty"` // default 1h, capped at 24h
+	}
+	type tokenResp struct {
+		AccessToken   string `json:"access_token"`
+		TokenType     string `json:"token_type"`
+		ExpiresAtUnix int64  `json:"expiresAtUnix"`
+	}
+	s.handle("/dev/token", func(w http.ResponseWriter, r *http.Request) {
+		noStore(w)
+		if r.Method != http.MethodPost {
+			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
+			return
+		}
+		var req tokenReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		token, exp, err := d.Mint(req.Sub, req.Email, time.Duration(req.TTLSeconds)*time.Second)
+		if err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": err.Error()})
+			return
+		}
+		writeJSONP1(w, http.StatusOK, tokenResp{AccessToken: token, TokenType: "bearer", ExpiresAtUnix: exp.Unix()})
+	})
+}
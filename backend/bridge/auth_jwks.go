@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwksCache is the JWKS cache used by every verifier that checks asymmetric
+// signatures (one per key set URL). Keys are refreshed after ttl, and at most
+// once per minRefresh when a token names an unknown kid (key rotation).
+// EC P-256 and RSA (>= 2048 bit) keys are kept; others are ignored.
+type jwksCache struct {
+	url        string
+	apiKey     string // optional; some gateways want apikey on the public JWKS
+	ttl        time.Duration
+	minRefresh time.Duration
+	client     *http.Client
+
+	mu          sync.Mutex
+	byKid       map[string]crypto.PublicKey
+	fetched     time.Time
+	lastAttempt time.Time
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+var errUnknownKid = errors.New("kid not found in jwks")
+
+func newJWKSCache(url, apiKey string) *jwksCache {
+	return &jwksCache{
+		url:        url,
+		apiKey:     apiKey,
+		ttl:        10 * time.Minute,
+		minRefresh: 30 * time.Second,
+		client:     &http.Client{Timeout: 8 * time.Second},
+		byKid:      map[string]crypto.PublicKey{},
+	}
+}
+
+// key returns the public key for kid, fetching the JWKS when stale or
+// when kid is unknown (rate-limited by minRefresh).
+func (c *jwksCache) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	stale := now.Sub(c.fetched) >= c.ttl
+	pub, ok := c.byKid[kid]
+	if ok && !stale {
+		return pub, nil
+	}
+	if !stale && now.Sub(c.lastAttempt) < c.minRefresh {
+		return nil, errUnknownKid
+	}
+
+	c.lastAttempt = now
+	keys, err := c.fetch(ctx)
+	if err != nil {
+		// Serve a stale key rather than failing closed on a transient outage.
+		if ok {
+			return pub, nil
+		}
+		return nil, err
+	}
+	c.byKid = keys
+	c.fetched = now
+
+	if pub, ok = c.byKid[kid]; !ok {
+		return nil, errUnknownKid
+	}
+	return pub, nil
+}
+
+func (c *jwksCache) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("apikey", c.apiKey)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("jwks fetch failed: %s", resp.Status)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kid == "" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		if pub := k.publicKey(); pub != nil {
+			keys[k.Kid] = pub
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes a supported JWK, or returns nil.
+func (k jwk) publicKey() crypto.PublicKey {
+	switch {
+	case k.Kty == "EC" && k.Crv == "P-256" && (k.Alg == "" || k.Alg == "ES256"):
+		x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+		y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+		if err1 != nil || err2 != nil {
+			return nil
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil
+		}
+		return pub
+	case k.Kty == "RSA" && (k.Alg == "" || k.Alg == "RS256"):
+		n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+		e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+		if err1 != nil || err2 != nil || len(e) == 0 || len(e) > 4 {
+			return nil
+		}
+		pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if pub.N.BitLen() < 2048 || pub.E < 3 {
+			return nil
+		}
+		return pub
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// oidcIssuerConfig is one entry of AUTH_OIDC_ISSUERS.
+//
+//	[{"issuer":"https://login.example.com","audiences":["privxx"]}]
+//
+// The issuer list is the issuer allow-list; each issuer accepts only its own
+// audiences, so a token minted for another client of the same IdP is refused.
+type oidcIssuerConfig struct {
+	Issuer    string   `json:"issuer"`
+	Audiences []string `json:"audiences"`
+}
+
+// oidcProvider resolves an issuer's JWKS through OpenID Connect discovery
+// (issuer + /.well-known/openid-configuration). Discovery runs on first use
+// and is retried at most every minRetry until it succeeds; the JWKS cache then
+// handles key rotation on its own.
+type oidcProvider struct {
+	issuer   string
+	client   *http.Client
+	minRetry time.Duration
+
+	mu          sync.Mutex
+	jwks        *jwksCache
+	lastAttempt time.Time
+}
+
+type oidcDiscoveryDoc struct {
+	Issuer  string `json:"issuer"`
+	JWKSURI string `json:"jwks_uri"`
+}
+
+func newOIDCProvider(issuer string) *oidcProvider {
+	return &oidcProvider{
+		issuer:   issuer,
+		client:   &http.Client{Timeout: 8 * time.Second},
+		minRetry: 30 * time.Second,
+	}
+}
+
+func (p *oidcProvider) keys(ctx context.Context) (*jwksCache, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.jwks != nil {
+		return p.jwks, nil
+	}
+	if time.Since(p.lastAttempt) < p.minRetry {
+		return nil, errors.New("oidc discovery pending retry")
+	}
+	p.lastAttempt = time.Now()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc discovery failed: %s", resp.Status)
+	}
+	var doc oidcDiscoveryDoc
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, err
+	}
+	// OpenID Connect Discovery 1.0 §4.3: issuer must match exactly.
+	if doc.Issuer != p.issuer {
+		return nil, fmt.Errorf("oidc discovery issuer mismatch: %q", doc.Issuer)
+	}
+	if err := checkIssuerURL(doc.JWKSURI); err != nil {
+		return nil, fmt.Errorf("oidc jwks_uri: %w", err)
+	}
+	p.jwks = newJWKSCache(doc.JWKSURI, "")
+	return p.jwks, nil
+}
+
+// newOIDCVerifier verifies ES256/RS256 tokens from one discovered issuer.
+func newOIDCVerifier(cfg oidcIssuerConfig) *jwtVerifier {
+	p := newOIDCProvider(cfg.Issuer)
+	return &jwtVerifier{
+		name: "oidc:" + cfg.Issuer,
+		algs: []string{"ES256", "RS256"},
+		key: func(ctx context.Context, t *jwt.Token) (any, error) {
+			cache, err := p.keys(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", errKeyUnavailable, err)
+			}
+			return jwksKeyFunc(cache)(ctx, t)
+		},
+		issuer:    cfg.Issuer,
+		audiences: cfg.Audiences,
+	}
+}
+
+// parseOIDCIssuers decodes and validates AUTH_OIDC_ISSUERS.
+func parseOIDCIssuers(raw string) ([]oidcIssuerConfig, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	var cfgs []oidcIssuerConfig
+	if err := json.Unmarshal([]byte(raw), &cfgs); err != nil {
+		return nil, fmt.Errorf("AUTH_OIDC_ISSUERS: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, c := range cfgs {
+		if err := checkIssuerURL(c.Issuer); err != nil {
+			return nil, fmt.Errorf("AUTH_OIDC_ISSUERS: issuer %q: %w", c.Issuer, err)
+		}
+		if len(c.Audiences) == 0 {
+			return nil, fmt.Errorf("AUTH_OIDC_ISSUERS: issuer %q needs at least one audience", c.Issuer)
+		}
+		if seen[c.Issuer] {
+			return nil, fmt.Errorf("AUTH_OIDC_ISSUERS: duplicate issuer %q", c.Issuer)
+		}
+		seen[c.Issuer] = true
+	}
+	return cfgs, nil
+}
+
+// checkIssuerURL requires https, except for loopback hosts (local IdPs in tests).
+func checkIssuerURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.New("not an absolute URL")
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if h := u.Hostname(); h == "localhost" || h == "127.0.0.1" || h == "::1" {
+			return nil
+		}
+	}
+	return errors.New("must use https")
+}
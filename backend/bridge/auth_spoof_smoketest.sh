@@ -0,0 +1,35 @@
+#!/usr/bin/env bash
+# Proves a client cannot choose its identity with X-User-Id: the bridge strips
+# the header and handlers only trust the Principal set by the token verifier.
+# Run against a bridge started with:
+#   ENVIRONMENT=development AUTH_DEV_ISSUER=true BRIDGE_MODE=phase1 BRIDGE_CONTACTS_KEY=<64 hex> go run .
+# and a core (or privxx-backend-stub) at its BACKEND_URL, which /unlock calls.
+set -euo pipefail
+
+: "${BASE:?set BASE like http://127.0.0.1:8090}"
+
+mint() { # sub
+  curl -sS -X POST "$BASE/dev/token" -d "{\"sub\":\"$1\"}" | jq -er '.access_token'
+}
+ALICE=$(mint spoof-alice)
+BOB=$(mint spoof-bob)
+
+echo "== no token + X-User-Id -> 401 =="
+curl -sS -o /dev/null -w '%{http_code}' -X POST "$BASE/unlock" -H "X-User-Id: spoof-alice" \
+  | grep -qx 401
+
+echo "== alice unlocks =="
+curl -sS -X POST "$BASE/unlock" -H "Authorization: Bearer $ALICE" -d '{"password":"spoof"}' | jq -e '.success == true'
+
+echo "== bob claiming X-User-Id: spoof-alice is still bob (locked) =="
+curl -sS "$BASE/unlock/status" -H "Authorization: Bearer $BOB" -H "X-User-Id: spoof-alice" \
+  | jq -e '.unlocked == false'
+
+echo "== alice creates an invite; bob spoofing alice cannot list it =="
+curl -sS -X POST "$BASE/invites/create" -H "Authorization: Bearer $ALICE" \
+  -H "Content-Type: application/json" -d '{}' | jq -e '.invite.inviteId | length > 0'
+curl -sS -X POST "$BASE/invites/list" -H "Authorization: Bearer $BOB" -H "X-User-Id: spoof-alice" \
+  -H "Content-Type: application/json" -d '{}' | jq -e '.invites | length == 0'
+
+echo
+echo "spoofing checks OK"
@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// TokenVerifier checks a bearer token and returns its claims.
+// Every implementation reports failures as a *JWTError with one of the
+// jwtErr* codes so callers handle all strategies the same way.
+type TokenVerifier interface {
+	Name() string
+	Verify(ctx context.Context, token string) (*JWTClaims, *JWTError)
+}
+
+// Uniform JWTError codes.
+const (
+	jwtErrMissingToken  = "missing_token"
+	jwtErrInvalidToken  = "invalid_token"
+	jwtErrExpiredToken  = "expired_token"
+	jwtErrUnsupported   = "unsupported_token" // this verifier does not handle the token's alg
+	jwtErrUnreachable   = "verifier_unreachable"
+	jwtErrNotConfigured = "verifier_not_configured"
+)
+
+const (
+	jwtClockSkewLeeway = 30 * time.Second
+	defaultJWTAudience = "authenticated"
+)
+
+func jwtUnauthorized(code, msg string) *JWTError {
+	return &JWTError{Error: "unauthorized", Code: code, Message: msg}
+}
+
+// ============================
+// Chain
+// ============================
+
+// chainVerifier tries verifiers in order. A verifier that does not handle the
+// token (unsupported alg) or cannot reach its backend passes to the next one;
+// any other failure is final so a bad signature never falls through.
+type chainVerifier struct {
+	verifiers []TokenVerifier
+}
+
+func (c *chainVerifier) Name() string {
+	names := make([]string, 0, len(c.verifiers))
+	for _, v := range c.verifiers {
+		names = append(names, v.Name())
+	}
+	return "chain(" + strings.Join(names, ",") + ")"
+}
+
+func (c *chainVerifier) Verify(ctx context.Context, token string) (*JWTClaims, *JWTError) {
+	if strings.TrimSpace(token) == "" {
+		return nil, jwtUnauthorized(jwtErrMissingToken, "Authorization header required")
+	}
+	last := jwtUnauthorized(jwtErrUnsupported, "No verifier accepts this token")
+	for _, v := range c.verifiers {
+		claims, jerr := v.Verify(ctx, token)
+		if jerr == nil {
+			return claims, nil
+		}
+		switch jerr.Code {
+		case jwtErrUnsupported, jwtErrUnreachable, jwtErrNotConfigured:
+			last = jerr
+			continue
+		}
+		return nil, jerr
+	}
+	return nil, last
+}
+
+// ============================
+// Signed JWTs (ES256 via JWKS, HS256 via shared secret)
+// ============================
+
+// jwtVerifier validates a signed JWT locally with golang-jwt.
+// Tokens whose alg or iss this verifier does not own are reported as
+// unsupported so the chain can offer them to the next verifier.
+type jwtVerifier struct {
+	name      string
+	algs      []string
+	key       func(ctx context.Context, t *jwt.Token) (any, error)
+	issuer    string   // required when non-empty
+	audiences []string // one must match when non-empty
+}
+
+// errKeyUnavailable marks key lookups that failed for reasons other than the token.
+var errKeyUnavailable = errors.New("signing keys unavailable")
+
+func newES256Verifier(cache *jwksCache, issuer, audience string) *jwtVerifier {
+	return &jwtVerifier{name: "es256", algs: []string{"ES256"}, key: jwksKeyFunc(cache), issuer: issuer, audiences: []string{audience}}
+}
+
+func newHS256Verifier(secret []byte, issuer, audience string) *jwtVerifier {
+	return &jwtVerifier{
+		name:      "hs256",
+		algs:      []string{"HS256"},
+		key:       func(context.Context, *jwt.Token) (any, error) { return secret, nil },
+		issuer:    issuer,
+		audiences: []string{audience},
+	}
+}
+
+// jwksKeyFunc resolves the token's kid against cache.
+func jwksKeyFunc(cache *jwksCache) func(ctx context.Context, t *jwt.Token) (any, error) {
+	return func(ctx context.Context, t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		if kid == "" {
+			return nil, errUnknownKid
+		}
+		pub, err := cache.key(ctx, kid)
+		if err != nil && !errors.Is(err, errUnknownKid) {
+			return nil, fmt.Errorf("%w: %v", errKeyUnavailable, err)
+		}
+		return pub, err
+	}
+}
+
+func (v *jwtVerifier) Name() string { return v.name }
+
+func (v *jwtVerifier) Verify(ctx context.Context, token string) (*JWTClaims, *JWTError) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, jwtUnauthorized(jwtErrMissingToken, "Authorization header required")
+	}
+
+	// Peek at alg/iss so tokens for other strategies pass down the chain.
+	alg, iss := jwtPeek(token)
+	if !containsString(v.algs, alg) {
+		return nil, jwtUnauthorized(jwtErrUnsupported, "Unsupported token algorithm")
+	}
+	if v.issuer != "" && iss != v.issuer {
+		return nil, jwtUnauthorized(jwtErrUnsupported, "Token issuer not handled by "+v.name)
+	}
+
+	var keyErr error
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.algs),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(jwtClockSkewLeeway),
+	}
+	if v.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.issuer))
+	}
+	if len(v.audiences) > 0 {
+		opts = append(opts, jwt.WithAudience(v.audiences...))
+	}
+
+	tok, err := jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		k, err := v.key(ctx, t)
+		if errors.Is(err, errKeyUnavailable) {
+			keyErr = err
+		}
+		return k, err
+	}, opts...)
+
+	switch {
+	case keyErr != nil:
+		return nil, jwtUnauthorized(jwtErrUnreachable, "Unable to fetch signing keys")
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, jwtUnauthorized(jwtErrExpiredToken, "Token is expired")
+	case err != nil || tok == nil || !tok.Valid:
+		return nil, jwtUnauthorized(jwtErrInvalidToken, "Token is invalid or expired")
+	}
+
+	mc, _ := tok.Claims.(jwt.MapClaims)
+	return claimsFromMap(mc, v.name)
+}
+
+// jwtPeek returns the unverified "alg" header and "iss" claim ("" if unparseable).
+func jwtPeek(token string) (alg, iss string) {
+	tok, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	if err != nil || tok == nil {
+		return "", ""
+	}
+	alg, _ = tok.Header["alg"].(string)
+	if mc, ok := tok.Claims.(jwt.MapClaims); ok {
+		iss, _ = mc.GetIssuer()
+	}
+	return alg, iss
+}
+
+func containsString(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// claimsFromMap builds the uniform JWTClaims from verified claims.
+func claimsFromMap(mc jwt.MapClaims, method string) (*JWTClaims, *JWTError) {
+	sub, _ := mc.GetSubject()
+	if strings.TrimSpace(sub) == "" {
+		return nil, jwtUnauthorized(jwtErrInvalidToken, "Token missing sub")
+	}
+	out := &JWTClaims{Sub: sub, Method: method}
+	out.Email, _ = mc["email"].(string)
+	out.Iss, _ = mc.GetIssuer()
+	if aud, err := mc.GetAudience(); err == nil && len(aud) > 0 {
+		out.Aud = aud[0]
+	}
+	if exp, err := mc.GetExpirationTime(); err == nil && exp != nil {
+		out.ExpiresAt = exp.Unix()
+	}
+	return out, nil
+}
+
+// ============================
+// Introspection (Supabase /auth/v1/user)
+// ============================
+
+// endpointVerifier asks Supabase whether the token is valid. It accepts any
+// alg, so it belongs last in a chain.
+type endpointVerifier struct{}
+
+func (endpointVerifier) Name() string { return "endpoint" }
+
+func (endpointVerifier) Verify(ctx context.Context, token string) (*JWTClaims, *JWTError) {
+	if supabaseURL == "" || supabaseAnonKey == "" {
+		return nil, &JWTError{Error: "server_error", Code: jwtErrNotConfigured, Message: "Supabase endpoint not configured"}
+	}
+	claims, jerr := validateJWTViaEndpoint(ctx, token)
+	if jerr != nil {
+		if jerr.Error == "server_error" {
+			jerr.Code = jwtErrUnreachable
+		}
+		return nil, jerr
+	}
+	claims.Method = "endpoint"
+	// The endpoint vouched for the token; exp/iss are read only for bookkeeping.
+	if tok, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{}); err == nil {
+		if mc, ok := tok.Claims.(jwt.MapClaims); ok {
+			if exp, err := mc.GetExpirationTime(); err == nil && exp != nil {
+				claims.ExpiresAt = exp.Unix()
+			}
+			claims.Iss, _ = mc.GetIssuer()
+		}
+	}
+	return claims, nil
+}
+
+// ============================
+// Config loader
+// ============================
+
+// tokenVerifier is the process-wide verifier used by authMiddleware.
+var tokenVerifier TokenVerifier = &chainVerifier{verifiers: []TokenVerifier{endpointVerifier{}}}
+
+// endpointCache fronts the endpoint verifier; nil when caching is disabled.
+var endpointCache *cachingVerifier
+
+// loadTokenVerifier builds the verifier chain from the environment:
+//   - AUTH_VERIFIERS       ordered list of dev, es256, hs256, oidc, endpoint
+//     (default: every verifier whose settings are present, in that order)
+//   - SUPABASE_URL         issuer base and JWKS location
+//   - SUPABASE_JWT_AUD     required audience (default "authenticated")
+//   - SUPABASE_JWT_SECRET  HS256 shared secret
+//   - AUTH_OIDC_ISSUERS    JSON issuer/audience allow-list (see auth_oidc.go)
+//   - AUTH_DEV_ISSUER      "true" enables the local dev issuer (development only)
+//   - AUTH_CACHE_TTL_SECONDS      endpoint result cache ceiling (default 300, 0 disables)
+//   - AUTH_CACHE_NEG_TTL_SECONDS  cache for rejected tokens (default 30)
+//
+// allowEndpoint is false in API-only mode, where Supabase introspection is off.
+func loadTokenVerifier(allowEndpoint bool) (TokenVerifier, error) {
+	base := strings.TrimRight(strings.TrimSpace(os.Getenv("SUPABASE_URL")), "/")
+	issuer := ""
+	if base != "" {
+		issuer = base + "/auth/v1"
+	}
+	aud := strings.TrimSpace(os.Getenv("SUPABASE_JWT_AUD"))
+	if aud == "" {
+		aud = defaultJWTAudience
+	}
+	secret := strings.TrimSpace(os.Getenv("SUPABASE_JWT_SECRET"))
+	oidcIssuers, err := parseOIDCIssuers(os.Getenv("AUTH_OIDC_ISSUERS"))
+	if err != nil {
+		return nil, err
+	}
+	if devTokens, err = loadDevIssuer(); err != nil {
+		return nil, err
+	}
+	endpointReady := allowEndpoint && supabaseURL != "" && supabaseAnonKey != ""
+
+	names := splitList(os.Getenv("AUTH_VERIFIERS"))
+	if len(names) == 0 {
+		if devTokens != nil {
+			names = append(names, "dev")
+		}
+		if issuer != "" {
+			names = append(names, "es256")
+		}
+		if secret != "" {
+			names = append(names, "hs256")
+		}
+		if len(oidcIssuers) > 0 {
+			names = append(names, "oidc")
+		}
+		if endpointReady {
+			names = append(names, "endpoint")
+		}
+	}
+
+	var cache *jwksCache
+	chain := &chainVerifier{}
+	for _, n := range names {
+		switch n {
+		case "dev":
+			if devTokens == nil {
+				return nil, fmt.Errorf("dev verifier requires AUTH_DEV_ISSUER=true")
+			}
+			chain.verifiers = append(chain.verifiers, devTokens.verifier())
+		case "es256":
+			if issuer == "" {
+				return nil, fmt.Errorf("es256 verifier requires SUPABASE_URL")
+			}
+			if cache == nil {
+				cache = newJWKSCache(issuer+"/.well-known/jwks.json", strings.TrimSpace(os.Getenv("SUPABASE_ANON_KEY")))
+			}
+			chain.verifiers = append(chain.verifiers, newES256Verifier(cache, issuer, aud))
+		case "hs256":
+			if secret == "" {
+				return nil, fmt.Errorf("hs256 verifier requires SUPABASE_JWT_SECRET")
+			}
+			chain.verifiers = append(chain.verifiers, newHS256Verifier([]byte(secret), issuer, aud))
+		case "oidc":
+			if len(oidcIssuers) == 0 {
+				return nil, fmt.Errorf("oidc verifier requires AUTH_OIDC_ISSUERS")
+			}
+			for _, c := range oidcIssuers {
+				chain.verifiers = append(chain.verifiers, newOIDCVerifier(c))
+			}
+		case "endpoint":
+			if !endpointReady {
+				return nil, fmt.Errorf("endpoint verifier requires SUPABASE_URL and SUPABASE_ANON_KEY (and is unavailable in api-only mode)")
+			}
+			var ev TokenVerifier = endpointVerifier{}
+			ttl, err := envSeconds("AUTH_CACHE_TTL_SECONDS", 300*time.Second)
+			if err != nil {
+				return nil, err
+			}
+			negTTL, err := envSeconds("AUTH_CACHE_NEG_TTL_SECONDS", 30*time.Second)
+			if err != nil {
+				return nil, err
+			}
+			if ttl > 0 {
+				endpointCache = newCachingVerifier(ev, ttl, negTTL, 10000)
+				ev = endpointCache
+			}
+			chain.verifiers = append(chain.verifiers, ev)
+		default:
+			return nil, fmt.Errorf("unknown verifier %q in AUTH_VERIFIERS", n)
+		}
+	}
+	if len(chain.verifiers) == 0 {
+		return nil, errors.New("no token verifiers configured (set SUPABASE_URL, AUTH_OIDC_ISSUERS or AUTH_DEV_ISSUER)")
+	}
+	return chain, nil
+}
+
+// envSeconds reads a non-negative integer number of seconds.
+func envSeconds(name string, def time.Duration) (time.Duration, error) {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+// splitList parses a comma/space separated, lower-cased list.
+func splitList(v string) []string {
+	var out []string
+	for _, f := range strings.FieldsFunc(strings.ToLower(v), func(r rune) bool { return r == ',' || r == ' ' }) {
+		out = append(out, f)
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+#!/usr/bin/env bash
+# Contract check: the bridge's typed backend client (internal/backend/rpc.go)
+# against core's contract server (internal/httpapi via privxx-backend-stub).
+# Builds both unless STUB_BIN / CONTRACT_BIN point at binaries.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+PORT="${PORT:-18891}"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" 2>/dev/null && wait "${PID:-}" 2>/dev/null; rm -rf "$WORK"' EXIT
+
+STUB="${STUB_BIN:-}"
+if [ -z "$STUB" ]; then
+  STUB="$WORK/stub"
+  (cd ../core && go build -o "$STUB" ./cmd/privxx-backend-stub)
+fi
+CHECK="${CONTRACT_BIN:-}"
+if [ -z "$CHECK" ]; then
+  CHECK="$WORK/contract"
+  go build -o "$CHECK" ./cmd/backend-contract
+fi
+
+"$STUB" -addr "127.0.0.1:$PORT" >"$WORK/stub.log" 2>&1 &
+PID=$!
+for _ in $(seq 50); do curl -sf "http://127.0.0.1:$PORT/health" >/dev/null && break; sleep 0.1; done
+
+"$CHECK" -url "http://127.0.0.1:$PORT"
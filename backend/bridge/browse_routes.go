@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/backend"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// backendClient is the bridge's single backend (core) client. main replaces it
+// with the configured one (backend.LoadConfig) and starts its health prober.
+var backendClient = backend.NewFromConfig(backend.DefaultConfig())
+
+// writeBackendUnavailable answers with the structured backend_unavailable error.
+func writeBackendUnavailable(w http.ResponseWriter, err error) {
+	var ue *backend.UnavailableError
+	reason := "transport_error"
+	retry := 5
+	if errors.As(err, &ue) {
+		reason = ue.Reason
+		if ue.RetryAfter > 0 {
+			retry = int(math.Ceil(ue.RetryAfter.Seconds()))
+		}
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	writeJSONP1(w, http.StatusServiceUnavailable, map[string]any{
+		"error":      "backend_unavailable",
+		"reason":     reason,
+		"retryAfter": retry,
+	})
+}
+
+// proxyJSONPost forwards a browse body as-is; core has no typed browse
+// contract yet, so these are the only untyped backend calls.
+func proxyJSONPost(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	resp, err := backendClient.PostJSON(r.Context(), path, body)
+	if err != nil {
+		if !errors.Is(err, backend.ErrUnavailable) {
+			logging.Error(r.Context(), "browse proxy failed", logging.Public("path", logging.Label(path)), logging.Err(err))
+		}
+		writeBackendUnavailable(w, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// ============================
+// Browsing B1: Preview forwarder
+// ============================
+
+func handleBrowsePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "invalid_request_body", http.StatusBadRequest)
+		return
+	}
+
+	proxyJSONPost(w, r, "/browse/preview", body)
+}
+
+// ============================
+// Browsing B2: Sanitized fetch forwarder
+// ============================
+
+func handleBrowseFetch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "invalid_request_body", http.StatusBadRequest)
+		return
+	}
+
+	proxyJSONPost(w, r, "/browse/fetch", body)
+}
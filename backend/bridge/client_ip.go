@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// trustedProxies are the peers whose forwarding headers we believe.
+// Loaded from TRUSTED_PROXY_CIDRS; defaults to loopback because the bridge
+// binds to localhost behind its reverse proxy.
+var trustedProxies = mustParseCIDRs("127.0.0.0/8,::1/128")
+
+// loadTrustedProxies reads TRUSTED_PROXY_CIDRS (comma separated CIDRs or bare
+// IPs). The value "none" disables header trust entirely.
+func loadTrustedProxies() error {
+	v, ok := os.LookupEnv("TRUSTED_PROXY_CIDRS")
+	if !ok {
+		return nil
+	}
+	if strings.EqualFold(strings.TrimSpace(v), "none") {
+		trustedProxies = nil
+		return nil
+	}
+	p, err := parseCIDRs(v)
+	if err != nil {
+		return fmt.Errorf("TRUSTED_PROXY_CIDRS: %w", err)
+	}
+	trustedProxies = p
+	return nil
+}
+
+func parseCIDRs(v string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !strings.Contains(f, "/") {
+			a, err := netip.ParseAddr(f)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(f)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+func mustParseCIDRs(v string) []netip.Prefix {
+	p, err := parseCIDRs(v)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func isTrustedProxy(a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// getClientIP returns the address rate limiting should key on.
+//
+// Forwarding headers are consulted only when the direct peer is a trusted
+// proxy. The hop list (RFC 7239 Forwarded, else X-Forwarded-For) is walked
+// right to left, skipping trusted proxies; the first untrusted hop is the
+// client. Hops a client could have forged (anything left of that) are ignored.
+// X-Real-IP is used only when a trusted peer sent no hop list.
+func getClientIP(r *http.Request) string {
+	peer, ok := parseHostIP(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !isTrustedProxy(peer) {
+		return peer.String()
+	}
+
+	hops, present := forwardedHops(r.Header)
+	if !present {
+		if xri, ok := parseHostIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ok {
+			return xri.String()
+		}
+		return peer.String()
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		a, ok := parseHostIP(hops[i])
+		if !ok {
+			// Unknown/obfuscated hop: the last trusted proxy is the best we know.
+			break
+		}
+		client = a
+		if !isTrustedProxy(a) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedHops returns the hop list from Forwarded (preferred) or
+// X-Forwarded-For, joining repeated header lines in order.
+func forwardedHops(h http.Header) ([]string, bool) {
+	if lines := h.Values("Forwarded"); len(lines) > 0 {
+		var hops []string
+		for _, line := range lines {
+			for _, elem := range strings.Split(line, ",") {
+				hops = append(hops, forwardedFor(elem))
+			}
+		}
+		return hops, true
+	}
+	if lines := h.Values("X-Forwarded-For"); len(lines) > 0 {
+		var hops []string
+		for _, line := range lines {
+			for _, f := range strings.Split(line, ",") {
+				hops = append(hops, strings.TrimSpace(f))
+			}
+		}
+		return hops, true
+	}
+	return nil, false
+}
+
+// forwardedFor extracts the for= node from one RFC 7239 forwarded-element,
+// e.g. `for="[2001:db8::1]:4711";proto=https` -> `[2001:db8::1]:4711`.
+func forwardedFor(elem string) string {
+	for _, pair := range strings.Split(elem, ";") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && strings.EqualFold(strings.TrimSpace(k), "for") {
+			return strings.Trim(strings.TrimSpace(v), `"`)
+		}
+	}
+	return ""
+}
+
+// parseHostIP accepts "ip", "ip:port", "[ipv6]" and "[ipv6]:port".
+// A bare IPv6 address is never split on its last colon.
+func parseHostIP(s string) (netip.Addr, bool) {
+	if s == "" {
+		return netip.Addr{}, false
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if a, err := netip.ParseAddr(s); err == nil {
+		return a.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
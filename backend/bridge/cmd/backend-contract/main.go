@@ -0,0 +1,177 @@
+// Command backend-contract runs the bridge's typed backend client against a
+// live core (normally privxx-backend-stub) and checks every contract endpoint:
+// request-ID round trip, v checks and typed errors. Run it through
+// backend_contract_check.sh.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/backend"
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+)
+
+var failed int
+
+func as(userID string) contracts.Intent { return contracts.Intent{UserID: userID} }
+
+func check(name string, ok bool, detail ...any) {
+	if ok {
+		fmt.Printf("ok   %s\n", name)
+		return
+	}
+	failed++
+	fmt.Printf("FAIL %s %v\n", name, detail)
+}
+
+func main() {
+	url := flag.String("url", backend.DefaultURL, "backend core base URL")
+	flag.Parse()
+
+	c := backend.New(*url, 5*time.Second)
+	ctx := context.Background()
+	const alice, bob, carol = "contract-user-alice", "contract-user-bob", "contract-user-carol"
+
+	// Identity: Unlock / IdentityStatus / Lock
+	_, err := c.Connect(ctx, contracts.ConnectIntent{Intent: as(alice), Domain: "example.com"})
+	check("Connect while locked is SESSION_LOCKED", errors.Is(err, backend.ErrSessionLocked), err)
+	var be *backend.Error
+	check("error carries status and op", errors.As(err, &be) && be.Status == http.StatusForbidden && be.Op == "Connect", err)
+
+	_, err = c.Unlock(ctx, contracts.UnlockIntent{Intent: as(alice)})
+	check("Unlock without password is INVALID_PASSWORD", errors.Is(err, backend.ErrInvalidPassword), err)
+
+	ur, err := c.Unlock(ctx, contracts.UnlockIntent{Intent: as(alice), Password: "correct horse"})
+	check("Unlock", err == nil && ur.TTLSeconds > 0 && ur.ExpiresAt != "", err)
+
+	st, err := c.IdentityStatus(ctx, contracts.IdentityStatusIntent{Intent: as(alice)})
+	check("IdentityStatus unlocked", err == nil && st.Unlocked, err)
+
+	// Connect / Disconnect / CoreStatus
+	rctx := backend.WithRequestID(ctx, "req-contract-connect")
+	cr, err := c.Connect(rctx, contracts.ConnectIntent{Intent: as(alice), Domain: "example.com"})
+	check("Connect", err == nil && cr.Ok && cr.Status == "connected", err)
+	check("Connect echoes request ID", err == nil && cr.RequestID == "req-contract-connect", cr)
+
+	_, err = c.Connect(ctx, contracts.ConnectIntent{})
+	check("Connect without user is INVALID_INPUT", errors.Is(err, backend.ErrInvalidInput), err)
+
+	cs, err := c.CoreStatus(ctx, contracts.StatusIntent{Intent: as(alice)})
+	check("CoreStatus", err == nil && cs.BackendReady && cs.Unlocked, err)
+
+	dr, err := c.Disconnect(ctx, contracts.DisconnectIntent{Intent: as(alice)})
+	check("Disconnect", err == nil && dr.Status == "disconnected", err)
+
+	// Conversations
+	_, err = c.CreateConversation(ctx, contracts.CreateConversationIntent{Intent: as(alice), Participants: []string{bob, carol}})
+	check("direct CreateConversation with two others is INVALID_INPUT", errors.Is(err, backend.ErrInvalidInput), err)
+
+	ccr, err := c.CreateConversation(ctx, contracts.CreateConversationIntent{Intent: as(alice), Participants: []string{bob}})
+	check("CreateConversation", err == nil && ccr.Conversation != nil && ccr.Conversation.Kind == contracts.KindDirect &&
+		ccr.Conversation.State == contracts.StateActive && len(ccr.Conversation.Participants) == 2, err)
+	conv := ""
+	if err == nil && ccr.Conversation != nil {
+		conv = ccr.Conversation.ConversationID
+	}
+
+	or, err := c.OpenConversation(ctx, contracts.OpenConversationIntent{Intent: as(bob), ConversationID: conv})
+	check("OpenConversation", err == nil && or.ConversationID == conv && or.Conversation != nil, err)
+
+	or, err = c.OpenConversation(ctx, contracts.OpenConversationIntent{Intent: as(bob), ParticipantHint: alice})
+	check("OpenConversation by participant reuses direct conversation", err == nil && or.ConversationID == conv, err)
+
+	_, err = c.OpenConversation(ctx, contracts.OpenConversationIntent{Intent: as(carol), ConversationID: conv})
+	check("OpenConversation by non-member is NOT_FOUND", errors.Is(err, backend.ErrNotFound), err)
+
+	gr, err := c.CreateConversation(ctx, contracts.CreateConversationIntent{Intent: as(alice), Kind: contracts.KindGroup, Participants: []string{bob, carol}})
+	check("CreateConversation group", err == nil && gr.Conversation != nil && gr.Conversation.Kind == contracts.KindGroup &&
+		gr.Conversation.Participants[0].Role == contracts.RoleAdmin, err)
+
+	lcr, err := c.ListConversations(ctx, contracts.ListConversationsIntent{Intent: as(carol)})
+	check("ListConversations shows only the caller's", err == nil && len(lcr.Conversations) == 1 && gr != nil && lcr.Conversations[0].ConversationID == gr.Conversation.ConversationID, err)
+
+	// Messaging
+	_, err = c.SendMessage(ctx, contracts.SendMessageIntent{Intent: as(alice), ConversationID: "conv_missing", Recipients: []string{bob}, Payload: "eA=="})
+	check("SendMessage to unknown conversation is NOT_FOUND", errors.Is(err, backend.ErrNotFound), err)
+
+	sr, err := c.SendMessage(ctx, contracts.SendMessageIntent{Intent: as(alice), ConversationID: conv, Recipients: []string{bob}, Payload: "Y2lwaGVydGV4dA=="})
+	check("SendMessage", err == nil && sr.Accepted && sr.MessageID != "", err)
+	check("generated request ID returned", err == nil && sr.RequestID != "", sr)
+
+	_, err = c.SendMessage(ctx, contracts.SendMessageIntent{Intent: as(alice), ConversationID: conv})
+	check("SendMessage without payload is INVALID_INPUT", errors.Is(err, backend.ErrInvalidInput), err)
+
+	_, err = c.SendMessage(ctx, contracts.SendMessageIntent{Intent: as(carol), ConversationID: conv, Payload: "eA=="})
+	check("SendMessage by non-member is NOT_FOUND", errors.Is(err, backend.ErrNotFound), err)
+
+	tr, err := c.Thread(ctx, contracts.ThreadIntent{Intent: as(alice), ConversationID: conv})
+	check("Thread", err == nil && len(tr.Messages) > 0 && sr != nil && tr.Messages[len(tr.Messages)-1].ID == sr.MessageID, err)
+
+	_, err = c.Thread(ctx, contracts.ThreadIntent{Intent: as(carol), ConversationID: conv})
+	check("Thread by non-member is NOT_FOUND", errors.Is(err, backend.ErrNotFound), err)
+
+	ir, err := c.Inbox(ctx, contracts.InboxIntent{Intent: as(bob), Limit: 10})
+	check("Inbox holds received message", err == nil && len(ir.Messages) == 1 && sr != nil && ir.Messages[0].ID == sr.MessageID && !ir.Messages[0].Consumed, err)
+
+	ir, err = c.Inbox(ctx, contracts.InboxIntent{Intent: as(alice), Limit: 10})
+	check("Inbox excludes sent messages", err == nil && len(ir.Messages) == 0, err)
+
+	if sr != nil {
+		_, err = c.Ack(ctx, contracts.AckIntent{Intent: as(alice), MessageIDs: []string{sr.MessageID}})
+		check("Ack by sender is NOT_FOUND", errors.Is(err, backend.ErrNotFound), err)
+
+		ar, err := c.Ack(ctx, contracts.AckIntent{Intent: as(bob), MessageIDs: []string{sr.MessageID}})
+		check("Ack", err == nil && ar.Acked == 1, err)
+		ar, err = c.Ack(ctx, contracts.AckIntent{Intent: as(bob), MessageIDs: []string{sr.MessageID}})
+		check("Ack repeat counts zero", err == nil && ar.Acked == 0, err)
+	}
+
+	ir, err = c.Inbox(ctx, contracts.InboxIntent{Intent: as(bob), UnconsumedOnly: true})
+	check("Inbox unconsumedOnly drops acked", err == nil && len(ir.Messages) == 0, err)
+
+	tr, err = c.Thread(ctx, contracts.ThreadIntent{Intent: as(alice), ConversationID: conv})
+	check("sender sees consumed", err == nil && len(tr.Messages) == 1 && tr.Messages[0].Consumed, err)
+
+	lr, err := c.Lock(ctx, contracts.LockIntent{Intent: as(alice)})
+	check("Lock", err == nil && lr.Ok, err)
+
+	st, err = c.IdentityStatus(ctx, contracts.IdentityStatusIntent{Intent: as(alice)})
+	check("IdentityStatus locked", err == nil && !st.Unlocked, err)
+
+	// A backend answering with another contract version is rejected.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("listen:", err)
+		os.Exit(1)
+	}
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"v": 2, "ok": true})
+	}))
+	_, err = backend.New("http://"+ln.Addr().String(), time.Second).Connect(ctx, contracts.ConnectIntent{Intent: as(alice)})
+	check("v=2 result is ErrVersionMismatch", errors.Is(err, backend.ErrVersionMismatch), err)
+	ln.Close()
+
+	// An unreachable backend is ErrUnavailable.
+	dead, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("listen:", err)
+		os.Exit(1)
+	}
+	dead.Close()
+	_, err = backend.New("http://"+dead.Addr().String(), time.Second).Lock(ctx, contracts.LockIntent{Intent: as(alice)})
+	check("unreachable backend is ErrUnavailable", errors.Is(err, backend.ErrUnavailable), err)
+
+	if failed > 0 {
+		fmt.Printf("%d contract check(s) failed\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("backend contract OK")
+}
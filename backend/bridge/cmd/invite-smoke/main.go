@@ -0,0 +1,202 @@
+// Command invite-smoke runs two bridge stacks (contacts, conversations,
+// messages, invites) joined by an in-memory transport and checks that an
+// accepted invite yields one conversation both sides can message over. Run it
+// through invite_smoketest.sh.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/contacts"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/invites"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/messages"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
+)
+
+var failed int
+
+func check(name string, ok bool, detail ...any) {
+	if ok {
+		fmt.Printf("ok   %s\n", name)
+		return
+	}
+	failed++
+	fmt.Printf("FAIL %s %v\n", name, detail)
+}
+
+// pipe is one end of an in-memory transport. Send queues for the other end;
+// deliver hands the queue to this end's handler, so each hop is explicit.
+type pipe struct {
+	peer    *pipe
+	queue   [][]byte
+	handler transport.ReceiveHandler
+	ref     []byte
+}
+
+func newPipes() (*pipe, *pipe) {
+	a, b := &pipe{ref: randomRef()}, &pipe{ref: randomRef()}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func randomRef() []byte {
+	ref := make([]byte, 32)
+	_, _ = rand.Read(ref)
+	return ref
+}
+
+func (p *pipe) Send(_ context.Context, envelope []byte) error {
+	p.peer.queue = append(p.peer.queue, append([]byte(nil), envelope...))
+	return nil
+}
+
+func (p *pipe) SetReceiveHandler(h transport.ReceiveHandler) error {
+	p.handler = h
+	return nil
+}
+
+func (p *pipe) Start(context.Context) error { return nil }
+func (p *pipe) Stop() error                 { return nil }
+func (p *pipe) ReceptionRef() []byte        { return append([]byte(nil), p.ref...) }
+
+// deliver runs every queued envelope through the handler and returns the
+// number delivered and the first handler error.
+func (p *pipe) deliver(ctx context.Context) (int, error) {
+	q := p.queue
+	p.queue = nil
+	var first error
+	for _, env := range q {
+		if err := p.handler(ctx, env); err != nil && first == nil {
+			first = err
+		}
+	}
+	return len(q), first
+}
+
+// node is one bridge's Phase-1 stack, wired as in phase1_deps.go.
+type node struct {
+	tx      *pipe
+	conv    *conversations.Repo
+	msgs    *messages.Store
+	orch    *messages.Orchestrator
+	invites *invites.Service
+	store   *invites.Store
+}
+
+func newNode(dir string, tx *pipe) (*node, error) {
+	kv, err := store.NewFileKV(filepath.Join(dir, "conversations"))
+	if err != nil {
+		return nil, err
+	}
+	convRepo := conversations.NewRepo(kv)
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	contactRepo, err := contacts.NewRepo(filepath.Join(dir, "contacts"), key)
+	if err != nil {
+		return nil, err
+	}
+	msgStore, err := messages.NewStore(filepath.Join(dir, "messages"))
+	if err != nil {
+		return nil, err
+	}
+	orch, err := messages.NewOrchestrator(convRepo, msgStore, tx, 4096)
+	if err != nil {
+		return nil, err
+	}
+	if err := orch.SetIdentities(contactRepo.LocalIdentity, contactRepo.SubjectForIdentity); err != nil {
+		return nil, err
+	}
+	inviteStore, err := invites.NewStore(filepath.Join(dir, "invites"))
+	if err != nil {
+		return nil, err
+	}
+	svc, err := invites.NewService(inviteStore, convRepo, contactRepo.LocalIdentity, tx.ReceptionRef(), tx)
+	if err != nil {
+		return nil, err
+	}
+	orch.HandleEnvelopeType(invites.HandshakeEnvelopeType, svc.OnHandshake)
+	orch.HandleEnvelopeType(invites.HandshakeAckEnvelopeType, svc.OnHandshakeAck)
+	if err := tx.SetReceiveHandler(orch.OnReceiveEnvelope); err != nil {
+		return nil, err
+	}
+	return &node{tx: tx, conv: convRepo, msgs: msgStore, orch: orch, invites: svc, store: inviteStore}, nil
+}
+
+func main() {
+	work, err := os.MkdirTemp("", "invite-smoke")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(work)
+
+	ctx := context.Background()
+	pa, pb := newPipes()
+	inviter, err := newNode(filepath.Join(work, "inviter"), pa)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	acceptor, err := newNode(filepath.Join(work, "acceptor"), pb)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer inviter.msgs.Close()
+	defer acceptor.msgs.Close()
+	const alice, bob = "smoke-alice", "smoke-bob"
+
+	// Invite: create, accept, handshake, ack
+	inv, token, err := inviter.invites.Create(alice, 0)
+	check("inviter creates invite", err == nil && token != "", err)
+	if err != nil {
+		os.Exit(1)
+	}
+	_, err = acceptor.invites.Accept(ctx, bob, token)
+	check("acceptor accepts token", err == nil, err)
+
+	n, err := inviter.tx.deliver(ctx)
+	check("inviter takes handshake", n == 1 && err == nil, n, err)
+	got, err := inviter.store.Lookup(inv.InviteID)
+	check("invite accepted with a conversation", err == nil && got.State(time.Now()) == invites.StateAccepted && got.ConversationID != "", err)
+	if err != nil || got.ConversationID == "" {
+		os.Exit(1)
+	}
+	convID := got.ConversationID
+
+	n, err = acceptor.tx.deliver(ctx)
+	check("acceptor takes ack", n == 1 && err == nil, n, err)
+	accs := acceptor.invites.Acceptances(bob)
+	check("acceptance names the inviter's conversation",
+		len(accs) == 1 && accs[0].State == invites.AcceptanceAccepted && accs[0].ConversationID == convID, accs)
+	conv, err := acceptor.conv.GetConversation(convID)
+	check("acceptor record is bob's", err == nil && conv.OwnerSubject == bob, err)
+
+	// Messages both ways over the shared conversation ID
+	_, err = acceptor.orch.SendText(ctx, bob, convID, []byte("hello alice"))
+	check("acceptor sends", err == nil, err)
+	n, err = inviter.tx.deliver(ctx)
+	check("inviter receives", n == 1 && err == nil, n, err)
+	thread, err := inviter.msgs.FetchThread(alice, convID, 10, false)
+	check("message in inviter's thread", err == nil && len(thread) == 1, len(thread), err)
+
+	_, err = inviter.orch.SendText(ctx, alice, convID, []byte("hello bob"))
+	check("inviter replies", err == nil, err)
+	n, err = acceptor.tx.deliver(ctx)
+	check("acceptor receives", n == 1 && err == nil, n, err)
+	thread, err = acceptor.msgs.FetchThread(bob, convID, 10, false)
+	check("reply in acceptor's thread", err == nil && len(thread) == 2, len(thread), err)
+
+	if failed > 0 {
+		fmt.Printf("%d check(s) failed\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("invite smoke passed")
+}
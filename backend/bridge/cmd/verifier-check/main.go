@@ -0,0 +1,362 @@
+// Command verifier-check runs the bridge binary against a local IdP (an
+// httptest JWKS and /auth/v1/user endpoint) and checks the token verifier
+// chain with locally generated ES256 and HS256 keys: fall-through codes,
+// issuer/audience rejection, the alg allow-list, expiry and unknown-kid
+// refresh. Run it through verifier_chain_check.sh.
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	audience  = "authenticated"
+	hsSecret  = "verifier-check-hs256-secret"
+	anonKey   = "verifier-check-anon"
+	opaqueTok = "opaque-introspected-token"
+)
+
+var failed int
+
+func check(name string, ok bool, detail ...any) {
+	if ok {
+		fmt.Printf("ok   %s\n", name)
+		return
+	}
+	failed++
+	fmt.Printf("FAIL %s %v\n", name, detail)
+}
+
+// idp is a local Supabase-shaped issuer: JWKS under /auth/v1 and token
+// introspection at /auth/v1/user. It counts requests to both.
+type idp struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*ecdsa.PrivateKey // published kid -> key
+	down     bool                         // JWKS answers 503
+	accepted map[string]bool              // tokens /auth/v1/user vouches for
+
+	jwksFetches atomic.Int64
+	userCalls   atomic.Int64
+}
+
+func newIDP() *idp {
+	p := &idp{keys: map[string]*ecdsa.PrivateKey{}, accepted: map[string]bool{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/v1/.well-known/jwks.json", p.serveJWKS)
+	mux.HandleFunc("/auth/v1/user", p.serveUser)
+	p.srv = httptest.NewServer(mux)
+	return p
+}
+
+func (p *idp) issuer() string { return p.srv.URL + "/auth/v1" }
+
+func (p *idp) publish(kid string, k *ecdsa.PrivateKey) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[kid] = k
+}
+
+func (p *idp) setDown(down bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.down = down
+}
+
+func (p *idp) accept(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accepted[token] = true
+}
+
+func (p *idp) serveJWKS(w http.ResponseWriter, r *http.Request) {
+	p.jwksFetches.Add(1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.down {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	var keys []map[string]string
+	for kid, k := range p.keys {
+		keys = append(keys, map[string]string{
+			"kty": "EC", "crv": "P-256", "kid": kid, "use": "sig", "alg": "ES256",
+			"x": base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, 32))),
+			"y": base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, 32))),
+		})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+}
+
+func (p *idp) serveUser(w http.ResponseWriter, r *http.Request) {
+	p.userCalls.Add(1)
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	p.mu.Lock()
+	ok := p.accepted[token] && r.Header.Get("apikey") == anonKey
+	p.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"msg":"invalid"}`, http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"id": "endpoint-user", "aud": audience})
+}
+
+// claims returns standard claims for iss, valid for ttl (negative: expired).
+func claims(iss, aud string, ttl time.Duration) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{"sub": "user-1", "iss": iss, "aud": aud, "iat": now.Unix(), "exp": now.Add(ttl).Unix()}
+}
+
+func es256(kid string, k *ecdsa.PrivateKey, c jwt.MapClaims) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, c)
+	if kid != "" {
+		t.Header["kid"] = kid
+	}
+	s, err := t.SignedString(k)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func hs256(secret string, c jwt.MapClaims) string {
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString([]byte(secret))
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// unsigned builds a token with an arbitrary alg header and no valid signature.
+func unsigned(alg string, c jwt.MapClaims) string {
+	h, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": "k1"})
+	b, _ := json.Marshal(c)
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc(h) + "." + enc(b) + "." + enc([]byte("sig"))
+}
+
+func newKey() *ecdsa.PrivateKey {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// bridge is a running bridge process (legacy mode) using one verifier chain.
+type bridge struct {
+	url string
+	cmd *exec.Cmd
+	ip  int
+}
+
+func startBridge(bin string, p *idp, verifiers string) (*bridge, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	cmd := exec.Command(bin)
+	cmd.Env = append(os.Environ(),
+		"BRIDGE_MODE=legacy",
+		fmt.Sprintf("PORT=%d", port),
+		"METRICS_ADDR=",
+		"SUPABASE_URL="+p.srv.URL,
+		"SUPABASE_ANON_KEY="+anonKey,
+		"SUPABASE_JWT_SECRET="+hsSecret,
+		"SUPABASE_JWT_AUD="+audience,
+		"AUTH_VERIFIERS="+verifiers,
+		"AUTH_CACHE_TTL_SECONDS=0",
+		`RATE_LIMIT_POLICIES={"default":{"rps":1000,"burst":1000},"policies":{}}`,
+	)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	b := &bridge{url: fmt.Sprintf("http://127.0.0.1:%d", port), cmd: cmd}
+	for i := 0; i < 50; i++ {
+		if resp, err := http.Get(b.url + "/health"); err == nil {
+			resp.Body.Close()
+			return b, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	b.stop()
+	return nil, fmt.Errorf("bridge on port %d did not start", port)
+}
+
+func (b *bridge) stop() {
+	_ = b.cmd.Process.Kill()
+	_ = b.cmd.Wait()
+}
+
+// auth calls a protected route with token and returns the status and JWTError
+// code. Each call comes from a fresh client IP so failed-auth lockout does not
+// interfere.
+func (b *bridge) auth(token string) (int, string) {
+	b.ip++
+	req, _ := http.NewRequest(http.MethodGet, b.url+"/unlock/status", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("X-Real-IP", fmt.Sprintf("10.9.%d.%d", b.ip/250, b.ip%250+1))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Code string `json:"code"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Code
+}
+
+func main() {
+	bin := flag.String("bridge", "", "bridge binary")
+	skipRefresh := flag.Bool("skip-refresh", false, "skip the unknown-kid refresh check (waits out the 30s JWKS refresh limit)")
+	flag.Parse()
+	if *bin == "" {
+		fmt.Println("-bridge is required")
+		os.Exit(2)
+	}
+
+	k1, k2, rogue := newKey(), newKey(), newKey()
+
+	// ---- Full chain: es256, hs256, endpoint ----
+	p := newIDP()
+	defer p.srv.Close()
+	p.publish("k1", k1)
+	b, err := startBridge(*bin, p, "es256,hs256,endpoint")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer b.stop()
+	iss := p.issuer()
+
+	status, code := b.auth("")
+	check("no token is missing_token", status == 401 && code == "missing_token", status, code)
+
+	// JWKS outage: es256 is unreachable, so the token falls through to introspection.
+	p.setDown(true)
+	good := es256("k1", k1, claims(iss, audience, time.Hour))
+	p.accept(good)
+	calls := p.userCalls.Load()
+	status, code = b.auth(good)
+	check("unreachable JWKS falls through to endpoint", status == 200 && p.userCalls.Load() == calls+1, status, code)
+	p.setDown(false)
+
+	calls = p.userCalls.Load()
+	status, code = b.auth(es256("k1", k1, claims(iss, audience, time.Hour)))
+	check("ES256 accepted locally", status == 200 && p.userCalls.Load() == calls, status, code)
+
+	status, code = b.auth(hs256(hsSecret, claims(iss, audience, time.Hour)))
+	check("HS256 falls past es256 and is accepted", status == 200 && p.userCalls.Load() == calls, status, code)
+
+	p.accept(opaqueTok)
+	status, code = b.auth(opaqueTok)
+	check("opaque token falls through to endpoint", status == 200 && p.userCalls.Load() == calls+1, status, code)
+
+	calls = p.userCalls.Load()
+	for _, tc := range []struct {
+		name, token, code string
+	}{
+		{"ES256 wrong audience is invalid_token", es256("k1", k1, claims(iss, "other-aud", time.Hour)), "invalid_token"},
+		{"HS256 wrong audience is invalid_token", hs256(hsSecret, claims(iss, "other-aud", time.Hour)), "invalid_token"},
+		{"ES256 bad signature is invalid_token", es256("k1", rogue, claims(iss, audience, time.Hour)), "invalid_token"},
+		{"HS256 wrong secret is invalid_token", hs256("not-the-secret", claims(iss, audience, time.Hour)), "invalid_token"},
+		{"ES256 without kid is invalid_token", es256("", k1, claims(iss, audience, time.Hour)), "invalid_token"},
+		{"ES256 expired is expired_token", es256("k1", k1, claims(iss, audience, -5*time.Minute)), "expired_token"},
+		{"HS256 expired is expired_token", hs256(hsSecret, claims(iss, audience, -5*time.Minute)), "expired_token"},
+	} {
+		status, code = b.auth(tc.token)
+		check(tc.name, status == 401 && code == tc.code, status, code)
+	}
+	check("final rejections never reach the endpoint", p.userCalls.Load() == calls, p.userCalls.Load()-calls)
+
+	// Tokens for another issuer or alg are not local verifiers' to judge:
+	// they pass down to the endpoint, which refuses them.
+	for _, tc := range []struct{ name, token string }{
+		{"ES256 foreign issuer", es256("k1", k1, claims("https://other.example/auth/v1", audience, time.Hour))},
+		{"HS256 foreign issuer", hs256(hsSecret, claims("https://other.example/auth/v1", audience, time.Hour))},
+		{"alg none", unsigned("none", claims(iss, audience, time.Hour))},
+		{"alg RS256", unsigned("RS256", claims(iss, audience, time.Hour))},
+		{"alg ES384", unsigned("ES384", claims(iss, audience, time.Hour))},
+	} {
+		calls = p.userCalls.Load()
+		status, code = b.auth(tc.token)
+		check(tc.name+" falls through and is rejected", status == 401 && code == "invalid_token" && p.userCalls.Load() == calls+1, status, code)
+	}
+
+	// Unknown kid: refreshes the JWKS at most once per 30s, then finds the rotated key.
+	p.publish("k2", k2)
+	rotated := es256("k2", k2, claims(iss, audience, time.Hour))
+	fetches := p.jwksFetches.Load()
+	status, code = b.auth(rotated)
+	check("unknown kid within the refresh limit is invalid_token without a fetch",
+		status == 401 && code == "invalid_token" && p.jwksFetches.Load() == fetches, status, code, p.jwksFetches.Load()-fetches)
+	if *skipRefresh {
+		fmt.Println("skip unknown kid refresh")
+	} else {
+		time.Sleep(31 * time.Second)
+		fetches = p.jwksFetches.Load()
+		status, code = b.auth(rotated)
+		check("unknown kid after the refresh limit refetches and is accepted",
+			status == 200 && p.jwksFetches.Load() == fetches+1, status, code, p.jwksFetches.Load()-fetches)
+	}
+
+	// ---- es256 alone: fall-through codes surface when nothing follows ----
+	q := newIDP()
+	defer q.srv.Close()
+	q.publish("k1", k1)
+	q.setDown(true)
+	e, err := startBridge(*bin, q, "es256")
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	defer e.stop()
+	qiss := q.issuer()
+
+	status, code = e.auth(es256("k1", k1, claims(qiss, audience, time.Hour)))
+	check("unreachable JWKS alone is verifier_unreachable", status == 401 && code == "verifier_unreachable", status, code)
+	q.setDown(false)
+
+	for _, tc := range []struct{ name, token string }{
+		{"HS256 to es256 alone is unsupported_token", hs256(hsSecret, claims(qiss, audience, time.Hour))},
+		{"alg none to es256 alone is unsupported_token", unsigned("none", claims(qiss, audience, time.Hour))},
+		{"alg RS256 to es256 alone is unsupported_token", unsigned("RS256", claims(qiss, audience, time.Hour))},
+		{"foreign issuer to es256 alone is unsupported_token", es256("k1", k1, claims("https://other.example/auth/v1", audience, time.Hour))},
+	} {
+		status, code = e.auth(tc.token)
+		check(tc.name, status == 401 && code == "unsupported_token", status, code)
+	}
+	status, code = e.auth(es256("k1", k1, claims(qiss, audience, time.Hour)))
+	check("es256 alone accepts once JWKS is back", status == 200, status, code)
+
+	if failed > 0 {
+		fmt.Printf("%d verifier check(s) failed\n", failed)
+		os.Exit(1)
+	}
+	fmt.Println("verifier chain OK")
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/contacts"
+)
+
+/*
+Contact book routes:
+- POST /contacts/add
+- POST /contacts/list
+- POST /contacts/update         (alias and/or observed identity key)
+- POST /contacts/verify         (record safety number comparison result)
+- POST /contacts/safety-number  (60-digit number + QR payload)
+- POST /contacts/delete
+Identity keys and fingerprints are never returned to clients.
+*/
+func (s *server) registerContactRoutes(contactRepo *contacts.Repo) {
+	type contactView struct {
+		ContactID       string `json:"contactId"`
+		Alias           string `json:"alias"`
+		Verification    string `json:"verification"`
+		IdentityChanged bool   `json:"identityChanged"`
+		CreatedAtUnix   int64  `json:"createdAtUnix"`
+		UpdatedAtUnix   int64  `json:"updatedAtUnix"`
+	}
+	view := func(c *contacts.Contact) contactView {
+		return contactView{
+			ContactID:       c.ContactID,
+			Alias:           c.Alias,
+			Verification:    c.Verification,
+			IdentityChanged: c.IdentityChanged(),
+			CreatedAtUnix:   c.CreatedAtUnix,
+			UpdatedAtUnix:   c.UpdatedAtUnix,
+		}
+	}
+	type contactResp struct {
+		Contact    contactView `json:"contact"`
+		Warning    string      `json:"warning,omitempty"`
+		ServerTime string      `json:"serverTime"`
+	}
+	single := func(w http.ResponseWriter, c *contacts.Contact) {
+		resp := contactResp{Contact: view(c), ServerTime: time.Now().UTC().Format(time.RFC3339)}
+		if c.IdentityChanged() {
+			resp.Warning = "identity_key_changed"
+		}
+		writeJSONP1(w, http.StatusOK, resp)
+	}
+
+	// ---- POST /contacts/add ----
+	type addReq struct {
+		Alias                string `json:"alias"`
+		IdentityPublicKeyB64 string `json:"identityPublicKeyB64"`
+		PeerRefB64           string `json:"peerRefEncryptedB64,omitempty"`
+	}
+	s.handle("/contacts/add", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req addReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		idKey, err := base64.StdEncoding.DecodeString(req.IdentityPublicKeyB64)
+		if err != nil || len(idKey) == 0 {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_identityPublicKeyB64"})
+			return
+		}
+		var peerRef []byte
+		if strings.TrimSpace(req.PeerRefB64) != "" {
+			if peerRef, err = base64.StdEncoding.DecodeString(req.PeerRefB64); err != nil {
+				writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_peerRefEncryptedB64"})
+				return
+			}
+		}
+		c, err := contactRepo.Add(ownerSubject, req.Alias, idKey, peerRef)
+		if err != nil {
+			writeContactError(w, "contact_add_failed", err)
+			return
+		}
+		single(w, c)
+	}))
+
+	// ---- POST /contacts/list ----
+	type listResp struct {
+		Contacts   []contactView `json:"contacts"`
+		ServerTime string        `json:"serverTime"`
+	}
+	s.handle("/contacts/list", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		resp := listResp{Contacts: []contactView{}, ServerTime: time.Now().UTC().Format(time.RFC3339)}
+		for _, c := range contactRepo.List(ownerSubject) {
+			resp.Contacts = append(resp.Contacts, view(c))
+		}
+		writeJSONP1(w, http.StatusOK, resp)
+	}))
+
+	// ---- POST /contacts/update ----
+	type updateReq struct {
+		ContactID            string `json:"contactId"`
+		Alias                string `json:"alias,omitempty"`
+		IdentityPublicKeyB64 string `json:"identityPublicKeyB64,omitempty"`
+	}
+	s.handle("/contacts/update", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req updateReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.ContactID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "contactId_required"})
+			return
+		}
+
+		c, err := contactRepo.Get(ownerSubject, req.ContactID)
+		if err == nil && strings.TrimSpace(req.Alias) != "" {
+			c, err = contactRepo.Rename(ownerSubject, req.ContactID, req.Alias)
+		}
+		if err == nil && req.IdentityPublicKeyB64 != "" {
+			idKey, derr := base64.StdEncoding.DecodeString(req.IdentityPublicKeyB64)
+			if derr != nil || len(idKey) == 0 {
+				writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_identityPublicKeyB64"})
+				return
+			}
+			c, _, err = contactRepo.UpdateIdentityKey(ownerSubject, req.ContactID, idKey)
+		}
+		if err != nil {
+			writeContactError(w, "contact_update_failed", err)
+			return
+		}
+		single(w, c)
+	}))
+
+	// ---- POST /contacts/verify ----
+	type verifyReq struct {
+		ContactID string `json:"contactId"`
+		Verified  bool   `json:"verified"`
+		// Optional: payload scanned from the peer's QR code. When present it must match.
+		ScannedQRPayload string `json:"scannedQrPayload,omitempty"`
+	}
+	s.handle("/contacts/verify", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req verifyReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.ContactID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "contactId_required"})
+			return
+		}
+		if req.ScannedQRPayload != "" {
+			c, err := contactRepo.Get(ownerSubject, req.ContactID)
+			if err != nil {
+				writeContactError(w, "contact_verify_failed", err)
+				return
+			}
+			localPub, err := contactRepo.LocalIdentityPublicKey(ownerSubject)
+			if err != nil {
+				writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "identity_unavailable"})
+				return
+			}
+			if !contacts.MatchQRPayload(req.ScannedQRPayload, localPub, c.IdentityPublicKey) {
+				writeJSONP1(w, http.StatusConflict, map[string]any{"error": "safety_number_mismatch"})
+				return
+			}
+		}
+		c, err := contactRepo.SetVerified(ownerSubject, req.ContactID, req.Verified)
+		if err != nil {
+			writeContactError(w, "contact_verify_failed", err)
+			return
+		}
+		single(w, c)
+	}))
+
+	// ---- POST /contacts/safety-number ----
+	type safetyReq struct {
+		ContactID string `json:"contactId"`
+	}
+	type safetyResp struct {
+		ContactID    string `json:"contactId"`
+		SafetyNumber string `json:"safetyNumber"`
+		QRPayload    string `json:"qrPayload"`
+		Verification string `json:"verification"`
+		Warning      string `json:"warning,omitempty"`
+		ServerTime   string `json:"serverTime"`
+	}
+	s.handle("/contacts/safety-number", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req safetyReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		c, err := contactRepo.Get(ownerSubject, req.ContactID)
+		if err != nil {
+			writeContactError(w, "safety_number_failed", err)
+			return
+		}
+		localPub, err := contactRepo.LocalIdentityPublicKey(ownerSubject)
+		if err != nil {
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "identity_unavailable"})
+			return
+		}
+		sn, err := contacts.SafetyNumber(localPub, c.IdentityPublicKey)
+		if err != nil {
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "safety_number_failed"})
+			return
+		}
+		qr, _ := contacts.QRPayload(localPub, c.IdentityPublicKey)
+		resp := safetyResp{
+			ContactID:    c.ContactID,
+			SafetyNumber: sn,
+			QRPayload:    qr,
+			Verification: c.Verification,
+			ServerTime:   time.Now().UTC().Format(time.RFC3339),
+		}
+		if c.IdentityChanged() {
+			resp.Warning = "identity_key_changed"
+		}
+		writeJSONP1(w, http.StatusOK, resp)
+	}))
+
+	// ---- POST /contacts/delete ----
+	type deleteReq struct {
+		ContactID string `json:"contactId"`
+	}
+	s.handle("/contacts/delete", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req deleteReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if err := contactRepo.Delete(ownerSubject, req.ContactID); err != nil {
+			writeContactError(w, "contact_delete_failed", err)
+			return
+		}
+		writeJSONP1(w, http.StatusOK, map[string]any{"status": "ok", "serverTime": time.Now().UTC().Format(time.RFC3339)})
+	}))
+}
+
+// contactPreamble applies the shared no-store/method/subject checks.
+func contactPreamble(w http.ResponseWriter, r *http.Request) (string, bool) {
+	noStore(w)
+	if r.Method != http.MethodPost {
+		writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
+		return "", false
+	}
+	ownerSubject, ok := mustAuthSubject(r)
+	if !ok {
+		writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+		return "", false
+	}
+	return ownerSubject, true
+}
+
+// writeContactError maps contacts errors to HTTP status codes.
+func writeContactError(w http.ResponseWriter, code string, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, contacts.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, contacts.ErrDuplicate):
+		status = http.StatusConflict
+	}
+	writeJSONP1(w, status, map[string]any{"error": code, "detail": err.Error()})
+}
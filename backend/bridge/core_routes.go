@@ -0,0 +1,262 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/backend"
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// Identity and connect routes call core through the typed client; core owns
+// the unlock state and the connect handshake. identityManager keeps a mirror
+// of core's unlock (capped at UNLOCK_TTL_MINUTES) so unlockRequiredMiddleware
+// can gate routes without a core round trip; core checks again on connect.
+// Backend unavailability is answered with writeBackendUnavailable, like browse.
+
+func coreIntent(userID string) contracts.Intent { return contracts.Intent{UserID: userID} }
+
+// coreFailure maps a typed-client error onto the status and error code the
+// legacy routes report. Unavailability is handled by the caller.
+func coreFailure(r *http.Request, op string, err error) (int, string) {
+	var be *backend.Error
+	switch {
+	case errors.Is(err, backend.ErrInvalidPassword):
+		return http.StatusUnauthorized, "invalid_password"
+	case errors.Is(err, backend.ErrSessionLocked):
+		return http.StatusForbidden, "session_locked"
+	case errors.As(err, &be) && be.Code == contracts.ErrInvalidURL:
+		return http.StatusBadRequest, "invalid_url"
+	case errors.Is(err, backend.ErrInvalidInput):
+		return http.StatusBadRequest, "invalid_input"
+	}
+	logging.Error(r.Context(), "core call failed", logging.Public("op", logging.Label(op)), logging.Err(err))
+	return http.StatusBadGateway, "backend_error"
+}
+
+// coreExpiry parses an RFC 3339 expiry from core; zero when absent or invalid.
+func coreExpiry(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// handleUnlock unlocks the user's identity in core with the supplied password.
+func handleUnlock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := authSubject(r)
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, UnlockResponse{Success: false, Error: "User ID not found"})
+		return
+	}
+	var req UnlockRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, UnlockResponse{Success: false, Error: "invalid_request"})
+		return
+	}
+
+	res, err := backendClient.Unlock(r.Context(), contracts.UnlockIntent{Intent: coreIntent(userID), Password: req.Password})
+	if errors.Is(err, backend.ErrUnavailable) {
+		writeBackendUnavailable(w, err)
+		return
+	}
+	if err != nil {
+		status, code := coreFailure(r, "unlock", err)
+		writeJSON(w, status, UnlockResponse{Success: false, Error: code})
+		return
+	}
+
+	session := identityManager.unlock(userID, coreExpiry(res.ExpiresAt))
+	writeJSON(w, http.StatusOK, UnlockResponse{
+		Success:   true,
+		ExpiresAt: session.ExpiresAt,
+		TTL:       int(time.Until(session.ExpiresAt).Seconds()),
+	})
+}
+
+// handleLock locks the identity locally first (so a core failure never leaves
+// the bridge unlocked), revokes Phase-1 sessions, then locks it in core.
+func handleLock(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := authSubject(r)
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, LockResponse{Success: false, Error: "User ID not found"})
+		return
+	}
+
+	identityManager.lock(userID)
+	if n := phase1SessionMgr.RevokeAll(userID); n > 0 {
+		logging.Info(r.Context(), "phase-1 sessions revoked on lock",
+			logging.Pseudonym("subject", userID), logging.Public("count", n))
+	}
+
+	_, err := backendClient.Lock(r.Context(), contracts.LockIntent{Intent: coreIntent(userID)})
+	if errors.Is(err, backend.ErrUnavailable) {
+		writeBackendUnavailable(w, err)
+		return
+	}
+	if err != nil {
+		status, code := coreFailure(r, "lock", err)
+		writeJSON(w, status, LockResponse{Success: false, Error: code})
+		return
+	}
+	writeJSON(w, http.StatusOK, LockResponse{Success: true})
+}
+
+// handleUnlockStatus reports core's unlock state. A lock seen in core (TTL,
+// restart, another bridge) also drops the local mirror.
+func handleUnlockStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := authSubject(r)
+	if userID == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "User ID not found"})
+		return
+	}
+
+	st, err := backendClient.IdentityStatus(r.Context(), contracts.IdentityStatusIntent{Intent: coreIntent(userID)})
+	if errors.Is(err, backend.ErrUnavailable) {
+		writeBackendUnavailable(w, err)
+		return
+	}
+	if err != nil {
+		status, code := coreFailure(r, "identity_status", err)
+		writeJSON(w, status, map[string]string{"error": code})
+		return
+	}
+	if !st.Unlocked {
+		identityManager.lock(userID)
+		writeJSON(w, http.StatusOK, UnlockStatusResponse{Unlocked: false})
+		return
+	}
+	writeJSON(w, http.StatusOK, UnlockStatusResponse{
+		Unlocked:     true,
+		ExpiresAt:    coreExpiry(st.ExpiresAt),
+		TTLRemaining: st.TTLSeconds,
+	})
+}
+
+// phaseDConnectAck builds a connect_ack for intent.
+func phaseDConnectAck(intent *ConnectIntent, status, errorCode string) ConnectAck {
+	return ConnectAck{
+		V:          PhaseDSchemaVersion,
+		Type:       "connect_ack",
+		RequestID:  intent.RequestID,
+		SessionID:  intent.SessionID,
+		Ack:        errorCode == "",
+		Status:     status,
+		ErrorCode:  errorCode,
+		ServerTime: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// handleConnect checks a Phase D connect_intent and performs the connect in
+// core, returning connect_ack.
+func handleConnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var intent ConnectIntent
+	if err := json.NewDecoder(r.Body).Decode(&intent); err != nil || intent.Type != "connect_intent" {
+		writeJSON(w, http.StatusBadRequest, phaseDConnectAck(&intent, "error", "INVALID_MESSAGE"))
+		return
+	}
+	if intent.TargetURL == "" {
+		writeJSON(w, http.StatusBadRequest, phaseDConnectAck(&intent, "error", "INVALID_URL"))
+		return
+	}
+
+	session.mu.Lock()
+	session.State = StateConnecting
+	session.TargetURL = intent.TargetURL
+	session.SessionID = intent.SessionID
+	session.Error = ""
+	now := time.Now()
+	session.StartedAt = &now
+	session.mu.Unlock()
+
+	// Privacy-preserving logging: the target is browsing metadata and the
+	// intent's requestId is client-chosen; the request_id comes from the context.
+	logging.Info(r.Context(), "connect", logging.Pseudonym("session", intent.SessionID))
+
+	_, err := backendClient.Connect(r.Context(), contracts.ConnectIntent{
+		Intent:    coreIntent(authSubject(r)),
+		TargetURL: intent.TargetURL,
+	})
+	if err != nil {
+		status, code := http.StatusServiceUnavailable, "BACKEND_UNAVAILABLE"
+		var be *backend.Error
+		switch {
+		case errors.Is(err, backend.ErrUnavailable):
+			logging.Warn(r.Context(), "connect: backend unavailable", logging.Err(err))
+		case errors.As(err, &be) && be.Code != "":
+			status, _ = coreFailure(r, "connect", err)
+			code = be.Code
+		default:
+			status, _ = coreFailure(r, "connect", err)
+			code = "BACKEND_ERROR"
+		}
+		session.mu.Lock()
+		session.State = StateError
+		session.Error = code
+		session.mu.Unlock()
+		writeJSON(w, status, phaseDConnectAck(&intent, "error", code))
+		return
+	}
+
+	session.mu.Lock()
+	session.State = StateSecure
+	session.mu.Unlock()
+
+	logging.Info(r.Context(), "secure", logging.Pseudonym("session", intent.SessionID))
+	writeJSON(w, http.StatusOK, phaseDConnectAck(&intent, "connected", ""))
+}
+
+// handleDisconnect resets the local session and disconnects in core.
+func handleDisconnect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	session.mu.Lock()
+	session.State = StateIdle
+	session.TargetURL = ""
+	session.SessionID = ""
+	session.Error = ""
+	session.StartedAt = nil
+	session.mu.Unlock()
+
+	logging.Info(r.Context(), "disconnect")
+
+	_, err := backendClient.Disconnect(r.Context(), contracts.DisconnectIntent{Intent: coreIntent(authSubject(r))})
+	if errors.Is(err, backend.ErrUnavailable) {
+		writeBackendUnavailable(w, err)
+		return
+	}
+	if err != nil {
+		status, code := coreFailure(r, "disconnect", err)
+		writeJSON(w, status, map[string]any{"success": false, "error": code})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"success": true})
+}
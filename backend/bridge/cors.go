@@ -1,48 +1,338 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
 )
 
-// allowOrigin returns true if the browser Origin is allowed to call the bridge.
-func allowOrigin(origin string) bool {
-	// Exact allow-list
-	switch origin {
-	case "https://privxx.app",
-		"https://www.privxx.app",
-		"https://privxx.lovable.app":
-		return true
+// CORSPolicy is the single browser-origin policy for every route. It is
+// applied once in server.ServeHTTP, before routing.
+type CORSPolicy struct {
+	// Origins are exact ("https://privxx.app") or host patterns
+	// ("https://*.lovable.app", "http://localhost:*"). "*." matches one or
+	// more labels; ":*" matches any port. Scheme and port must otherwise match.
+	Origins       []string             `json:"origins"`
+	Methods       []string             `json:"methods"`
+	Headers       []string             `json:"headers"`
+	Credentials   bool                 `json:"credentials"`
+	MaxAgeSeconds int                  `json:"maxAgeSeconds"`
+	Routes        map[string]CORSRoute `json:"routes,omitempty"` // path -> overrides
+}
+
+// CORSRoute overrides the methods and/or headers for one path.
+type CORSRoute struct {
+	Methods []string `json:"methods,omitempty"`
+	Headers []string `json:"headers,omitempty"`
+}
+
+func defaultCORSPolicy() CORSPolicy {
+	return CORSPolicy{
+		Origins: []string{
+			"https://privxx.app",
+			"https://www.privxx.app",
+			"https://privxx.lovable.app",
+			"https://*.lovable.app", // Lovable preview domains
+			"https://*.lovableproject.com",
+		},
+		Methods: []string{"GET", "POST"},
+		// X-User-Id is still sent by older web clients; it is stripped server-side.
+		Headers:       []string{"Authorization", "Content-Type", "X-Request-Id", "X-Correlation-Id", "X-Client-Info", "X-User-Id", "DPoP", "X-Session-Id"},
+		Credentials:   false, // bearer tokens only, no cookies
+		MaxAgeSeconds: 86400,
+		Routes: map[string]CORSRoute{
+			"/health": {Methods: []string{"GET"}},
+		},
 	}
+}
 
-	// Suffix allow-list (Lovable preview domains)
-	if strings.HasSuffix(origin, ".lovable.app") || strings.HasSuffix(origin, ".lovableproject.com") {
-		return true
+// loadCORSPolicy reads CORS_CONFIG (path to a JSON file) or CORS_POLICY
+// (inline JSON); either replaces the defaults wholesale. With
+// ENVIRONMENT=development and neither set, http://localhost:* and
+// http://127.0.0.1:* are added to the defaults. No setting allows every origin.
+func loadCORSPolicy() (CORSPolicy, error) {
+	path := strings.TrimSpace(os.Getenv("CORS_CONFIG"))
+	inline := strings.TrimSpace(os.Getenv("CORS_POLICY"))
+	var raw []byte
+	switch {
+	case path != "" && inline != "":
+		return CORSPolicy{}, errors.New("set only one of CORS_CONFIG and CORS_POLICY")
+	case path != "":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return CORSPolicy{}, fmt.Errorf("CORS_CONFIG: %w", err)
+		}
+		raw = b
+	case inline != "":
+		raw = []byte(inline)
+	default:
+		p := defaultCORSPolicy()
+		if os.Getenv("ENVIRONMENT") == "development" {
+			p.Origins = append(p.Origins, "http://localhost:*", "http://127.0.0.1:*")
+		}
+		return p, nil
+	}
+
+	var p CORSPolicy
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return CORSPolicy{}, fmt.Errorf("cors policy: %w", err)
+	}
+	return p, nil
+}
+
+// ============================
+// Compiled policy
+// ============================
+
+// corsPolicy is applied by server.ServeHTTP. main replaces it with the
+// configured policy before serving.
+var corsPolicy = mustCompileCORS(defaultCORSPolicy())
+
+type originPattern struct {
+	scheme   string
+	host     string // exact host, or the suffix after "*." (with leading dot)
+	wildHost bool
+	port     string // "" = default port, "*" = any
+}
+
+type corsRouteRule struct {
+	methods string // Access-Control-Allow-Methods value
+	allowed map[string]bool
+	headers string
+}
+
+type corsRules struct {
+	exact       map[string]bool
+	patterns    []originPattern
+	credentials bool
+	maxAge      string
+	def         corsRouteRule
+	routes      map[string]corsRouteRule
+}
+
+func mustCompileCORS(p CORSPolicy) *corsRules {
+	c, err := compileCORS(p)
+	if err != nil {
+		panic(err)
 	}
+	return c
+}
 
+func compileCORS(p CORSPolicy) (*corsRules, error) {
+	if len(p.Methods) == 0 {
+		return nil, errors.New("cors policy: methods required")
+	}
+	if p.MaxAgeSeconds < 0 {
+		return nil, errors.New("cors policy: maxAgeSeconds must be >= 0")
+	}
+	c := &corsRules{
+		exact:       map[string]bool{},
+		credentials: p.Credentials,
+		maxAge:      strconv.Itoa(p.MaxAgeSeconds),
+		def:         compileCORSRoute(p.Methods, p.Headers),
+		routes:      map[string]corsRouteRule{},
+	}
+	for _, o := range p.Origins {
+		if o == "*" || o == "null" {
+			return nil, fmt.Errorf("cors policy: origin %q is not allowed; list origins explicitly", o)
+		}
+		pat, err := parseOriginPattern(o)
+		if err != nil {
+			return nil, err
+		}
+		if !pat.wildHost && pat.port != "*" {
+			c.exact[pat.origin()] = true
+			continue
+		}
+		c.patterns = append(c.patterns, pat)
+	}
+	for path, r := range p.Routes {
+		methods, headers := r.Methods, r.Headers
+		if len(methods) == 0 {
+			methods = p.Methods
+		}
+		if len(headers) == 0 {
+			headers = p.Headers
+		}
+		c.routes[path] = compileCORSRoute(methods, headers)
+	}
+	return c, nil
+}
+
+func compileCORSRoute(methods, headers []string) corsRouteRule {
+	r := corsRouteRule{allowed: map[string]bool{}}
+	var ms []string
+	for _, m := range methods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m != "" && !r.allowed[m] {
+			r.allowed[m] = true
+			ms = append(ms, m)
+		}
+	}
+	r.methods = strings.Join(ms, ", ")
+	r.headers = strings.Join(headers, ", ")
+	return r
+}
+
+// parseOriginPattern accepts scheme://host[:port] where host may start with
+// "*." and port may be "*".
+func parseOriginPattern(s string) (originPattern, error) {
+	bad := fmt.Errorf("cors policy: invalid origin %q", s)
+	scheme, rest, ok := strings.Cut(strings.ToLower(strings.TrimSpace(s)), "://")
+	if !ok || (scheme != "https" && scheme != "http") || rest == "" || strings.ContainsAny(rest, "/?#@") {
+		return originPattern{}, bad
+	}
+	host, port := rest, ""
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.HasSuffix(rest, "]") {
+		host, port = rest[:i], rest[i+1:]
+		if port != "*" {
+			if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+				return originPattern{}, bad
+			}
+		}
+	}
+	p := originPattern{scheme: scheme, host: host, port: port}
+	if strings.HasPrefix(host, "*.") {
+		p.wildHost, p.host = true, host[1:]
+	}
+	if p.host == "" || p.host == "." || strings.Contains(p.host, "*") {
+		return originPattern{}, bad
+	}
+	return p, nil
+}
+
+func (p originPattern) origin() string {
+	if p.port == "" {
+		return p.scheme + "://" + p.host
+	}
+	return p.scheme + "://" + p.host + ":" + p.port
+}
+
+func (p originPattern) match(o originPattern) bool {
+	if p.scheme != o.scheme || (p.port != "*" && p.port != o.port) {
+		return false
+	}
+	if p.wildHost {
+		return strings.HasSuffix(o.host, p.host) && len(o.host) > len(p.host)
+	}
+	return p.host == o.host
+}
+
+// allowOrigin reports whether a browser Origin header value may call the bridge.
+func (c *corsRules) allowOrigin(origin string) bool {
+	o, err := parseOriginPattern(origin)
+	if err != nil || o.wildHost || o.port == "*" {
+		return false
+	}
+	// Browsers omit default ports, but tolerate an explicit one.
+	if (o.scheme == "https" && o.port == "443") || (o.scheme == "http" && o.port == "80") {
+		o.port = ""
+	}
+	if c.exact[o.origin()] {
+		return true
+	}
+	for _, p := range c.patterns {
+		if p.match(o) {
+			return true
+		}
+	}
 	return false
 }
 
-// cors wraps the handler and:
-// - Adds Access-Control-Allow-* for allowed origins
-// - Answers preflight OPTIONS with 204 (so the real GET can proceed)
-func cors(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-		if origin != "" && allowOrigin(origin) {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Vary", "Origin")
-			w.Header().Set("Access-Control-Allow-Methods", "GET,POST,OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "authorization,content-type,apikey,x-request-id,x-user-id")
-			w.Header().Set("Access-Control-Allow-Credentials", "false")
+func (c *corsRules) route(path string) corsRouteRule {
+	if r, ok := c.routes[path]; ok {
+		return r
+	}
+	return c.def
+}
+
+// apply sets CORS headers for r and reports whether it fully answered the
+// request (a preflight). Rejected origins get no Access-Control-Allow-*
+// headers at all, so the browser blocks the response.
+func (c *corsRules) apply(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if origin == "" {
+		return false // not a CORS request
+	}
+
+	h := w.Header()
+	// The response depends on Origin whether or not it is allowed.
+	addVary(h, "Origin")
+	if preflight {
+		addVary(h, "Access-Control-Request-Method", "Access-Control-Request-Headers")
+	}
+
+	rule := c.route(r.URL.Path)
+	if !c.allowOrigin(origin) {
+		logging.Info(r.Context(), "cors origin rejected", logging.Pseudonym("origin", origin))
+		if preflight {
+			w.WriteHeader(http.StatusForbidden)
+			return true
 		}
+		return false
+	}
+
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		h.Set("Access-Control-Expose-Headers", "X-Request-Id")
+		return false
+	}
+
+	if !rule.allowed[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		h.Del("Access-Control-Allow-Origin")
+		h.Del("Access-Control-Allow-Credentials")
+		w.WriteHeader(http.StatusForbidden)
+		return true
+	}
+	h.Set("Access-Control-Allow-Methods", rule.methods)
+	if rule.headers != "" {
+		h.Set("Access-Control-Allow-Headers", rule.headers)
+	}
+	h.Set("Access-Control-Max-Age", c.maxAge)
+	w.WriteHeader(http.StatusNoContent)
+	return true
+}
 
-		// Preflight: respond here (don’t fall through to /health JSON)
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusNoContent) // 204
-			return
+// addVary appends values to Vary without duplicating existing ones.
+func addVary(h http.Header, values ...string) {
+	have := map[string]bool{}
+	for _, v := range h.Values("Vary") {
+		for _, f := range strings.Split(v, ",") {
+			have[strings.ToLower(strings.TrimSpace(f))] = true
+		}
+	}
+	for _, v := range values {
+		if !have[strings.ToLower(v)] {
+			h.Add("Vary", v)
+			have[strings.ToLower(v)] = true
 		}
+	}
+}
 
-		next.ServeHTTP(w, r)
-	})
+// summary is logged at startup.
+func (c *corsRules) summary() string {
+	var out []string
+	for o := range c.exact {
+		out = append(out, o)
+	}
+	for _, p := range c.patterns {
+		host := p.host
+		if p.wildHost {
+			host = "*" + host
+		}
+		out = append(out, originPattern{scheme: p.scheme, host: host, port: p.port}.origin())
+	}
+	sort.Strings(out)
+	return strings.Join(out, ", ")
 }
@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+# Table-driven checks of the CORS policy (cors.go) against a running bridge:
+# once with the built-in defaults, once with a CORS_POLICY override.
+# Builds the bridge unless BRIDGE_BIN points at a binary.
+set -euo pipefail
+
+PORT="${PORT:-18793}"
+BASE="http://127.0.0.1:$PORT"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" 2>/dev/null && wait "${PID:-}" 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${BRIDGE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/bridge"
+  go build -o "$BIN" .
+fi
+
+export BRIDGE_MODE=phase1 PORT BRIDGE_DATA_DIR="$WORK/data"
+export BRIDGE_CONTACTS_KEY=$(openssl rand -hex 32) SUPABASE_JWT_SECRET=$(openssl rand -hex 32)
+
+start() {
+  kill "${PID:-}" 2>/dev/null && wait "$PID" 2>/dev/null || true
+  "$BIN" >>"$WORK/bridge.log" 2>&1 &
+  PID=$!
+  for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && return; sleep 0.1; done
+  echo "bridge did not start"; cat "$WORK/bridge.log"; exit 1
+}
+
+FAIL=0
+# check <method> <path> <origin|-> <preflight method|-> <status> <allow-origin|-> <vary substring|-> [credentials]
+check() {
+  local args=(-sS -o /dev/null -D "$WORK/h" -w '%{http_code}' -X "$1")
+  [ "$3" != - ] && args+=(-H "Origin: $3")
+  [ "$4" != - ] && args+=(-H "Access-Control-Request-Method: $4" -H "Access-Control-Request-Headers: authorization")
+  local code acao vary cred
+  code=$(curl "${args[@]}" "$BASE$2")
+  acao=$(grep -i '^access-control-allow-origin:' "$WORK/h" | cut -d' ' -f2- | tr -d '\r' || true)
+  vary=$(grep -i '^vary:' "$WORK/h" | cut -d' ' -f2- | tr -d '\r' | paste -sd, || true)
+  cred=$(grep -i '^access-control-allow-credentials:' "$WORK/h" | cut -d' ' -f2- | tr -d '\r' || true)
+  local want_acao="$6"; [ "$want_acao" = - ] && want_acao=""
+  if [ "$code" != "$5" ] || [ "$acao" != "$want_acao" ] ||
+     { [ "$7" != - ] && [[ "$vary" != *"$7"* ]]; } || [ "$cred" != "${8:-}" ]; then
+    echo "FAIL: $* -> status=$code acao='$acao' vary='$vary' cred='$cred'"
+    FAIL=1
+  else
+    echo "ok:   $1 $2 origin=$3 preflight=$4"
+  fi
+}
+
+echo "== defaults =="
+start
+#     method  path               origin                          preflight status acao                             vary
+check GET     /health            https://privxx.app              -         200    https://privxx.app               Origin
+check GET     /health            https://evil.example            -         200    -                                Origin
+check GET     /health            -                               -         200    -                                -
+check OPTIONS /v1/message/send   https://privxx.app              POST      204    https://privxx.app               Access-Control-Request-Method
+check OPTIONS /v1/message/send   https://preview-1.lovable.app   POST      204    https://preview-1.lovable.app    Origin
+check OPTIONS /v1/message/send   https://lovable.app             POST      403    -                                Origin
+check OPTIONS /v1/message/send   http://privxx.app               POST      403    -                                Origin
+check OPTIONS /v1/message/send   https://privxx.app.evil.example POST      403    -                                Origin
+check OPTIONS /v1/message/send   https://privxx.app              DELETE    403    -                                Origin
+check OPTIONS /health            https://privxx.app              POST      403    -                                Origin
+check OPTIONS /health            http://localhost:5173           GET       403    -                                Origin
+
+echo "== CORS_POLICY override =="
+export CORS_POLICY='{"origins":["https://app.example","http://localhost:*"],"methods":["POST"],"headers":["Authorization"],"credentials":true,"maxAgeSeconds":60,"routes":{"/health":{"methods":["GET"]}}}'
+start
+check OPTIONS /v1/message/send   https://app.example             POST      204    https://app.example              Origin                         true
+check OPTIONS /v1/message/send   http://localhost:5173           POST      204    http://localhost:5173            Origin                         true
+check OPTIONS /v1/message/send   https://privxx.app              POST      403    -                                Origin
+check OPTIONS /health            https://app.example             GET       204    https://app.example              Origin                         true
+check OPTIONS /health            https://app.example             POST      403    -                                Origin
+
+echo "== invalid policy refuses to start =="
+CORS_POLICY='{"origins":["*"],"methods":["GET"]}' timeout 5 "$BIN" >/dev/null 2>&1 && { echo "FAIL: wildcard origin accepted"; FAIL=1; } || echo "ok:   wildcard origin rejected"
+
+[ "$FAIL" = 0 ] && echo && echo "cors checks OK"
+exit "$FAIL"
@@ -6,12 +6,14 @@ go 1.22
 // require gitlab.com/xx_network/client v0.x.x
 
 require (
+	github.com/Bulldog-Master/privxx/backend/core v0.0.0-00010101000000-000000000000
+	github.com/golang-jwt/jwt/v5 v5.3.0
 	gitlab.com/elixxir/client/v4 v4.8.4
 	golang.org/x/crypto v0.18.0
 )
 
 require (
-	filippo.io/edwards25519 v1.0.0 // indirect
+	filippo.io/edwards25519 v1.0.0
 	git.xx.network/elixxir/grpc-web-go-client v0.0.1 // indirect
 	github.com/Max-Sum/base32768 v0.0.0-20230304063302-18e6ce5945fd // indirect
 	github.com/badoux/checkmail v1.2.1 // indirect
@@ -22,7 +24,6 @@ require (
 	github.com/elliotchance/orderedmap v1.5.1 // indirect
 	github.com/goccy/go-json v0.10.5 // indirect
 	github.com/golang-collections/collections v0.0.0-20130729185459-604e922904d3 // indirect
-	github.com/golang-jwt/jwt/v5 v5.3.0 // indirect
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/gorilla/websocket v1.5.0 // indirect
 	github.com/improbable-eng/grpc-web v0.15.0 // indirect
@@ -65,3 +66,6 @@ require (
 	nhooyr.io/websocket v1.8.7 // indirect
 	src.agwa.name/tlshacks v0.0.0-20220518131152-d2c6f4e2b780 // indirect
 )
+
+// The backend core contracts live in the same repository.
+replace github.com/Bulldog-Master/privxx/backend/core => ../core
@@ -4,18 +4,49 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 )
 
 // ctxKey is a private type to avoid context key collisions
 type ctxKey string
 
-// userIDKey is where authMiddleware stores the Supabase user id (sub)
-const userIDKey ctxKey = "userID"
+// principalKey is where the auth middleware stores the verified *Principal.
+const principalKey ctxKey = "principal"
 
-// getUserIDFromContext fetches the user id from request context
-func getUserIDFromContext(ctx context.Context) (string, bool) {
-	id, ok := ctx.Value(userIDKey).(string)
-	return id, ok
+// Principal is the authenticated caller, derived only from a verified token.
+type Principal struct {
+	Subject   string
+	Email     string
+	Audience  string
+	Method    string    // TokenVerifier that accepted the token
+	ExpiresAt time.Time // zero if the token carried no exp
+}
+
+func principalFromClaims(c *JWTClaims) *Principal {
+	p := &Principal{Subject: c.Sub, Email: c.Email, Audience: c.Aud, Method: c.Method}
+	if c.ExpiresAt > 0 {
+		p.ExpiresAt = time.Unix(c.ExpiresAt, 0)
+	}
+	return p
+}
+
+// withPrincipal returns r carrying p. Only auth middleware may call this.
+func withPrincipal(r *http.Request, p *Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey, p))
+}
+
+// principalFromContext returns the verified caller, if any.
+func principalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey).(*Principal)
+	return p, ok && p != nil && p.Subject != ""
+}
+
+// authSubject returns the verified caller's subject, or "" when unauthenticated.
+func authSubject(r *http.Request) string {
+	if p, ok := principalFromContext(r.Context()); ok {
+		return p.Subject
+	}
+	return ""
 }
 
 // writeJSON writes a JSON response with status code
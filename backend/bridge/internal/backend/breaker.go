@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// breakerState is the circuit state.
+type breakerState int
+
+const (
+	breakerClosed   breakerState = iota // requests flow
+	breakerOpen                         // requests fail fast until cooldown ends
+	breakerHalfOpen                     // one trial request decides
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker opens after threshold consecutive failures and stays open for
+// cooldown. After that a single trial request is let through: success
+// closes the circuit, failure re-opens it.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	trialBusy bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may proceed, and if not, how long until
+// the next trial.
+func (b *breaker) allow(now time.Time) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		wait := b.openedAt.Add(b.cooldown).Sub(now)
+		if wait > 0 {
+			return false, wait
+		}
+		b.state = breakerHalfOpen
+		b.trialBusy = true
+		return true, 0
+	case breakerHalfOpen:
+		if b.trialBusy {
+			return false, b.cooldown
+		}
+		b.trialBusy = true
+		return true, 0
+	default:
+		return true, 0
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.trialBusy = breakerClosed, 0, false
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.trialBusy = false
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt = breakerOpen, now
+	}
+}
+
+func (b *breaker) current() breakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
@@ -1,39 +1,69 @@
 package backend
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
 )
 
+// ErrUnavailable matches every *UnavailableError (errors.Is).
+var ErrUnavailable = errors.New("backend unavailable")
+
+// UnavailableError means the request did not get a usable answer from the
+// backend: the circuit is open, the transport failed, or it returned 5xx.
+type UnavailableError struct {
+	Reason     string        // "circuit_open", "transport_error" or "bad_status"
+	RetryAfter time.Duration // set when the circuit is open
+	Err        error
+}
+
+func (e *UnavailableError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("backend unavailable (%s): %v", e.Reason, e.Err)
+	}
+	return "backend unavailable (" + e.Reason + ")"
+}
+
+func (e *UnavailableError) Unwrap() error { return e.Err }
+
+func (e *UnavailableError) Is(target error) bool { return target == ErrUnavailable }
+
+// Client is the bridge's only path to the backend core. Every request goes
+// through the circuit breaker; the health prober keeps a cached view of the
+// backend so handlers never call /health inline.
 type Client struct {
 	addr    string
 	timeout time.Duration
 	http    *http.Client
+	breaker *breaker
+	probe   probeState
 }
 
 func New(addr string, timeout time.Duration) *Client {
-	return &Client{
-		addr:    addr,
-		timeout: timeout,
-		http: &http.Client{
-			Timeout: timeout,
-		},
-	}
+	cfg := DefaultConfig()
+	cfg.URL, cfg.Timeout = addr, timeout
+	return NewFromConfig(cfg)
 }
 
-type HealthResponse struct {
-	Status string `json:"status"`
-	Stub   bool   `json:"stub,omitempty"`
-}
+// URL returns the backend base URL.
+func (c *Client) URL() string { return c.addr }
+
+// HealthResponse is core's /health body.
+type HealthResponse = contracts.Health
 
+// Health calls GET /health, bypassing the breaker. Used by the prober.
 func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
-		c.addr+"/health",
+		c.addr+contracts.PathHealth,
 		nil,
 	)
 	if err != nil {
@@ -57,3 +87,43 @@ func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
 
 	return &out, nil
 }
+
+// Do sends req through the circuit breaker. Transport errors and 5xx
+// responses count as failures and come back as *UnavailableError (the 5xx
+// body is discarded); any other response is returned for the caller to close.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	now := time.Now()
+	if ok, wait := c.breaker.allow(now); !ok {
+		return nil, &UnavailableError{Reason: "circuit_open", RetryAfter: wait}
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.breaker.failure(time.Now())
+		return nil, &UnavailableError{Reason: "transport_error", Err: err}
+	}
+	if resp.StatusCode >= 500 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+		resp.Body.Close()
+		c.breaker.failure(time.Now())
+		return nil, &UnavailableError{Reason: "bad_status", Err: fmt.Errorf("status %d", resp.StatusCode)}
+	}
+	c.breaker.success()
+	return resp, nil
+}
+
+// PostJSON posts body (already-encoded JSON) to path. Endpoints with a
+// contract use the typed methods in rpc.go instead.
+func (c *Client) PostJSON(ctx context.Context, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id := RequestID(ctx); id != "" {
+		req.Header.Set(contracts.RequestIDHeader, id)
+	}
+	return c.Do(req)
+}
+
+// Circuit returns the breaker state: "closed", "open" or "half_open".
+func (c *Client) Circuit() string { return c.breaker.current().String() }
@@ -1,44 +1,95 @@
 package backend
 
 import (
-	"errors"
+	"fmt"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultURL is where the backend core listens by default (core -addr).
+const DefaultURL = "http://127.0.0.1:8091"
+
 // Config represents backend connection configuration.
-// NOTE: Code-only. Not wired. Not used.
 type Config struct {
-	Addr    string
-	Timeout time.Duration
+	URL             string        // base URL, no trailing slash
+	Timeout         time.Duration // per request
+	ProbeInterval   time.Duration // background /health probe
+	BreakerFailures int           // consecutive failures that open the circuit
+	BreakerCooldown time.Duration // how long the circuit stays open
 }
 
-// LoadConfig loads backend config from environment variables.
-// Required:
-//
-//	BACKEND_ADDR
-//
-// Optional:
+// DefaultConfig is used when nothing is configured.
+func DefaultConfig() *Config {
+	return &Config{
+		URL:             DefaultURL,
+		Timeout:         5 * time.Second,
+		ProbeInterval:   10 * time.Second,
+		BreakerFailures: 3,
+		BreakerCooldown: 15 * time.Second,
+	}
+}
+
+// LoadConfig loads backend config from environment variables. All optional:
 //
-//	BACKEND_TIMEOUT (default: 5s)
+//	BACKEND_URL               full base URL (default http://127.0.0.1:8091)
+//	BACKEND_ADDR/BACKEND_PORT older form: host (or full URL) and port; used when BACKEND_URL is unset
+//	BACKEND_TIMEOUT           per-request timeout (default 5s)
+//	BACKEND_PROBE_INTERVAL    health probe interval (default 10s)
+//	BACKEND_BREAKER_FAILURES  consecutive failures before failing fast (default 3)
+//	BACKEND_BREAKER_COOLDOWN  fail-fast period before a trial request (default 15s)
 func LoadConfig() (*Config, error) {
-	addr := os.Getenv("BACKEND_ADDR")
-	if addr == "" {
-		return nil, errors.New("BACKEND_ADDR not set")
-	}
+	cfg := DefaultConfig()
 
-	timeoutStr := os.Getenv("BACKEND_TIMEOUT")
-	if timeoutStr == "" {
-		timeoutStr = "5s"
+	raw := strings.TrimSpace(os.Getenv("BACKEND_URL"))
+	if raw == "" {
+		addr := strings.TrimSpace(os.Getenv("BACKEND_ADDR"))
+		port := strings.TrimSpace(os.Getenv("BACKEND_PORT"))
+		switch {
+		case strings.Contains(addr, "://"):
+			raw = addr
+		case addr != "" || port != "":
+			if addr == "" {
+				addr = "127.0.0.1"
+			}
+			if port == "" {
+				port = "8091"
+			}
+			raw = "http://" + addr + ":" + port
+		}
 	}
-
-	timeout, err := time.ParseDuration(timeoutStr)
-	if err != nil {
-		return nil, err
+	if raw != "" {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid backend URL %q", raw)
+		}
+		cfg.URL = strings.TrimRight(raw, "/")
 	}
 
-	return &Config{
-		Addr:    addr,
-		Timeout: timeout,
-	}, nil
+	for _, d := range []struct {
+		env string
+		dst *time.Duration
+	}{
+		{"BACKEND_TIMEOUT", &cfg.Timeout},
+		{"BACKEND_PROBE_INTERVAL", &cfg.ProbeInterval},
+		{"BACKEND_BREAKER_COOLDOWN", &cfg.BreakerCooldown},
+	} {
+		if v := strings.TrimSpace(os.Getenv(d.env)); v != "" {
+			t, err := time.ParseDuration(v)
+			if err != nil || t <= 0 {
+				return nil, fmt.Errorf("%s must be a positive duration", d.env)
+			}
+			*d.dst = t
+		}
+	}
+	if v := strings.TrimSpace(os.Getenv("BACKEND_BREAKER_FAILURES")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("BACKEND_BREAKER_FAILURES must be >= 1")
+		}
+		cfg.BreakerFailures = n
+	}
+	return cfg, nil
 }
@@ -1,10 +1,16 @@
 package backend
 
+import "net/http"
+
 // NewFromConfig constructs a backend Client from Config.
-// NOTE:
-// - Code-only
-// - NOT wired
-// - NOT used anywhere yet
 func NewFromConfig(cfg *Config) *Client {
-	return New(cfg.Addr, cfg.Timeout)
+	return &Client{
+		addr:    cfg.URL,
+		timeout: cfg.Timeout,
+		http: &http.Client{
+			Timeout: cfg.Timeout,
+		},
+		breaker: newBreaker(cfg.BreakerFailures, cfg.BreakerCooldown),
+		probe:   probeState{interval: cfg.ProbeInterval},
+	}
 }
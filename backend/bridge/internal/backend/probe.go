@@ -2,23 +2,94 @@ package backend
 
 import (
 	"context"
-	"log"
+	"sync"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
 )
 
-// ProbeHealth performs a one-shot backend health check.
-// NOTE:
-// - Code-only
-// - NOT wired to handlers
-// - NOT called from main
+// Status is the cached result of the last health probe.
+type Status struct {
+	Reachable   bool      `json:"reachable"`
+	XXDKReady   bool      `json:"xxdkReady"`
+	Stub        bool      `json:"stub,omitempty"`
+	Circuit     string    `json:"circuit"`
+	CheckedAt   time.Time `json:"checkedAt"` // zero until the first probe
+	LastError   string    `json:"lastError,omitempty"`
+	Consecutive int       `json:"consecutiveFailures,omitempty"`
+}
+
+type probeState struct {
+	mu       sync.RWMutex
+	last     Status
+	interval time.Duration
+}
+
+// ProbeHealth performs a one-shot backend health check and updates the
+// cached Status and the breaker.
 func ProbeHealth(c *Client) error {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
 	resp, err := c.Health(ctx)
+	now := time.Now()
+
+	c.probe.mu.Lock()
+	prev := c.probe.last
+	st := Status{CheckedAt: now}
 	if err != nil {
-		return err
+		st.LastError = err.Error()
+		st.Consecutive = prev.Consecutive + 1
+	} else {
+		st.Reachable, st.XXDKReady, st.Stub = true, resp.XXDKReady, resp.Stub
 	}
+	c.probe.last = st
+	c.probe.mu.Unlock()
 
-	log.Printf("[BACKEND] health ok (stub=%v)", resp.Stub)
+	// The prober is the breaker's way back: a healthy probe closes it.
+	if err != nil {
+		c.breaker.failure(now)
+		if prev.Reachable || prev.CheckedAt.IsZero() {
+			logging.Warn(ctx, "backend health failed", logging.Err(err))
+		}
+		return err
+	}
+	c.breaker.success()
+	if !prev.Reachable {
+		logging.Info(ctx, "backend health ok",
+			logging.Public("stub", resp.Stub), logging.Public("xxdk_ready", resp.XXDKReady))
+	}
 	return nil
 }
+
+// StartProber probes once immediately, then every ProbeInterval until ctx is done.
+func (c *Client) StartProber(ctx context.Context) {
+	go func() {
+		_ = ProbeHealth(c)
+		ticker := time.NewTicker(c.probe.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = ProbeHealth(c)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Status returns the cached health without contacting the backend.
+func (c *Client) Status() Status {
+	c.probe.mu.RLock()
+	st := c.probe.last
+	c.probe.mu.RUnlock()
+	st.Circuit = c.Circuit()
+	return st
+}
+
+// XXDKReady is the cached readiness reported by the last successful probe.
+func (c *Client) XXDKReady() bool {
+	st := c.Status()
+	return st.Reachable && st.XXDKReady
+}
@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// The typed methods below cover every core contract endpoint. The bridge's
+// identity and connect routes call them (core_routes.go); cmd/backend-contract
+// exercises all of them against core.
+
+// maxResultBytes bounds a decoded contract result.
+const maxResultBytes = 4 << 20
+
+// ErrVersionMismatch matches every *VersionError (errors.Is).
+var ErrVersionMismatch = errors.New("backend contract version mismatch")
+
+// Sentinels for the contract error codes; errors.Is matches any *Error with
+// the same code.
+var (
+	ErrInvalidInput       = &Error{Code: contracts.ErrInvalidInput}
+	ErrUnsupportedVersion = &Error{Code: contracts.ErrUnsupportedVersion}
+	ErrSessionLocked      = &Error{Code: contracts.ErrSessionLocked}
+	ErrInvalidPassword    = &Error{Code: contracts.ErrInvalidPassword}
+	ErrNotFound           = &Error{Code: contracts.ErrNotFound}
+)
+
+// Error is a failure the backend reported through the contract: a non-2xx
+// status or ok=false, with the contracts.ErrorResponse code when present.
+type Error struct {
+	Op        string // client method, e.g. "SendMessage"
+	Status    int
+	Code      string // contracts.Err*; empty when the body had none
+	Message   string
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("backend %s: status %d", e.Op, e.Status)
+	if e.Code != "" {
+		msg += " " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Op == "" && t.Code != "" && t.Code == e.Code
+}
+
+// VersionError means the backend answered with a contract version this
+// client does not speak.
+type VersionError struct {
+	Op        string
+	Got, Want int
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("backend %s: contract v=%d, want v=%d", e.Op, e.Got, e.Want)
+}
+
+func (e *VersionError) Is(target error) bool { return target == ErrVersionMismatch }
+
+// WithRequestID attaches the request ID sent as X-Request-Id on backend
+// calls (the same context key the logging package reads).
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return logging.WithRequestID(ctx, id)
+}
+
+// RequestID returns the ID set by WithRequestID, or "".
+func RequestID(ctx context.Context) string { return logging.RequestID(ctx) }
+
+type intentPtr[I any] interface {
+	*I
+	IntentHead() *contracts.Intent
+}
+
+type resultPtr[O any] interface {
+	*O
+	ResultHead() *contracts.Result
+}
+
+// invoke posts in to path and decodes the result. It stamps the intent
+// envelope (v, requestId from ctx or fresh) and checks the result envelope.
+func invoke[I, O any, PI intentPtr[I], PO resultPtr[O]](ctx context.Context, c *Client, op, path string, in PI) (PO, error) {
+	head := in.IntentHead()
+	head.V = contracts.ContractVersionV1
+	if id := RequestID(ctx); id != "" {
+		head.RequestID = id
+	} else if head.RequestID == "" {
+		head.RequestID = logging.NewRequestID()
+	}
+	rid := head.RequestID
+
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(contracts.RequestIDHeader, rid)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	out := PO(new(O))
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResultBytes)).Decode(out); err != nil {
+		return nil, &Error{Op: op, Status: resp.StatusCode, Message: "malformed response", RequestID: rid}
+	}
+	res := out.ResultHead()
+	if res.V != contracts.ContractVersionV1 {
+		return nil, &VersionError{Op: op, Got: res.V, Want: contracts.ContractVersionV1}
+	}
+	if resp.StatusCode/100 != 2 || !res.Ok {
+		e := &Error{Op: op, Status: resp.StatusCode, RequestID: rid}
+		if res.Error != nil {
+			e.Code = res.Error.Code
+			if res.Error.Message != nil {
+				e.Message = *res.Error.Message
+			}
+		}
+		return nil, e
+	}
+	return out, nil
+}
+
+func (c *Client) Connect(ctx context.Context, in contracts.ConnectIntent) (*contracts.ConnectResult, error) {
+	return invoke[contracts.ConnectIntent, contracts.ConnectResult](ctx, c, "Connect", contracts.PathConnect, &in)
+}
+
+func (c *Client) Disconnect(ctx context.Context, in contracts.DisconnectIntent) (*contracts.DisconnectResult, error) {
+	return invoke[contracts.DisconnectIntent, contracts.DisconnectResult](ctx, c, "Disconnect", contracts.PathDisconnect, &in)
+}
+
+func (c *Client) CoreStatus(ctx context.Context, in contracts.StatusIntent) (*contracts.StatusResult, error) {
+	return invoke[contracts.StatusIntent, contracts.StatusResult](ctx, c, "CoreStatus", contracts.PathStatus, &in)
+}
+
+func (c *Client) CreateConversation(ctx context.Context, in contracts.CreateConversationIntent) (*contracts.CreateConversationResult, error) {
+	return invoke[contracts.CreateConversationIntent, contracts.CreateConversationResult](ctx, c, "CreateConversation", contracts.PathCreateConversation, &in)
+}
+
+func (c *Client) OpenConversation(ctx context.Context, in contracts.OpenConversationIntent) (*contracts.OpenConversationResult, error) {
+	return invoke[contracts.OpenConversationIntent, contracts.OpenConversationResult](ctx, c, "OpenConversation", contracts.PathOpenConversation, &in)
+}
+
+func (c *Client) ListConversations(ctx context.Context, in contracts.ListConversationsIntent) (*contracts.ListConversationsResult, error) {
+	return invoke[contracts.ListConversationsIntent, contracts.ListConversationsResult](ctx, c, "ListConversations", contracts.PathListConversations, &in)
+}
+
+func (c *Client) SendMessage(ctx context.Context, in contracts.SendMessageIntent) (*contracts.SendMessageResult, error) {
+	return invoke[contracts.SendMessageIntent, contracts.SendMessageResult](ctx, c, "SendMessage", contracts.PathSendMessage, &in)
+}
+
+func (c *Client) Inbox(ctx context.Context, in contracts.InboxIntent) (*contracts.InboxResult, error) {
+	return invoke[contracts.InboxIntent, contracts.InboxResult](ctx, c, "Inbox", contracts.PathInbox, &in)
+}
+
+func (c *Client) Thread(ctx context.Context, in contracts.ThreadIntent) (*contracts.ThreadResult, error) {
+	return invoke[contracts.ThreadIntent, contracts.ThreadResult](ctx, c, "Thread", contracts.PathThread, &in)
+}
+
+func (c *Client) Ack(ctx context.Context, in contracts.AckIntent) (*contracts.AckResult, error) {
+	return invoke[contracts.AckIntent, contracts.AckResult](ctx, c, "Ack", contracts.PathAck, &in)
+}
+
+func (c *Client) Unlock(ctx context.Context, in contracts.UnlockIntent) (*contracts.UnlockResult, error) {
+	return invoke[contracts.UnlockIntent, contracts.UnlockResult](ctx, c, "Unlock", contracts.PathUnlock, &in)
+}
+
+func (c *Client) Lock(ctx context.Context, in contracts.LockIntent) (*contracts.LockResult, error) {
+	return invoke[contracts.LockIntent, contracts.LockResult](ctx, c, "Lock", contracts.PathLock, &in)
+}
+
+func (c *Client) IdentityStatus(ctx context.Context, in contracts.IdentityStatusIntent) (*contracts.IdentityStatusResult, error) {
+	return invoke[contracts.IdentityStatusIntent, contracts.IdentityStatusResult](ctx, c, "IdentityStatus", contracts.PathIdentityStatus, &in)
+}
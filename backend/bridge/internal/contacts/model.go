@@ -0,0 +1,30 @@
+package contacts
+
+// Verification states for a contact's identity key.
+const (
+	VerificationUnverified = "unverified"
+	VerificationVerified   = "verified"
+	VerificationChanged    = "changed" // identity key changed since last verification
+)
+
+// Contact is a peer identity in the owner's contact book.
+// Stored sealed at rest; Fingerprint and identity keys are internal-only and never logged.
+type Contact struct {
+	ContactID         string   `json:"contact_id"`
+	OwnerSubject      string   `json:"owner_subject"` // jwt.sub (internal)
+	Alias             string   `json:"alias"`
+	IdentityPublicKey []byte   `json:"identity_public_key"`
+	Fingerprint       string   `json:"fingerprint"`             // derived from IdentityPublicKey
+	RefEncrypted      []byte   `json:"ref_encrypted,omitempty"` // opaque transport reference
+	Verification      string   `json:"verification"`            // see Verification* constants
+	VerifiedAtUnix    int64    `json:"verified_at_unix,omitempty"`
+	KeyChangedAtUnix  int64    `json:"key_changed_at_unix,omitempty"`
+	PreviousKeys      [][]byte `json:"previous_keys,omitempty"` // prior identity keys, oldest first
+	CreatedAtUnix     int64    `json:"created_at_unix"`
+	UpdatedAtUnix     int64    `json:"updated_at_unix"`
+}
+
+// IdentityChanged reports whether the client must warn before trusting this contact.
+func (c *Contact) IdentityChanged() bool {
+	return c.Verification == VerificationChanged
+}
@@ -0,0 +1,404 @@
+package contacts
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+)
+
+var (
+	ErrNotFound        = errors.New("contact not found")
+	ErrDuplicate       = errors.New("contact already exists for identity")
+	ErrInvalidIdentity = errors.New("invalid identity public key")
+)
+
+// MaxAliasLen bounds alias length (bytes).
+const MaxAliasLen = 64
+
+// sealedDB is the plaintext form of the contacts file. It only exists in memory.
+type sealedDB struct {
+	Contacts map[string]*Contact `json:"contacts"` // contactID -> contact
+	// Identities holds each auth subject's long-term ed25519 identity seed.
+	Identities map[string][]byte `json:"identities,omitempty"` // jwt.sub -> seed
+}
+
+// Repo is the contact book. The whole book is sealed at rest with
+// XChaCha20-Poly1305 and rewritten atomically on every change.
+// Contact books are small; Phase-1 keeps the decrypted copy in memory.
+type Repo struct {
+	mu   sync.Mutex
+	path string
+	key  [32]byte
+	db   *sealedDB
+}
+
+// NewRepo opens (or creates) the sealed contact book in dir.
+// key must be 32 bytes and is required to read an existing book.
+func NewRepo(dir string, key []byte) (*Repo, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("dir required")
+	}
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("contacts key must be %d bytes", chacha20poly1305.KeySize)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	r := &Repo{path: filepath.Join(dir, "contacts.sealed")}
+	copy(r.key[:], key)
+
+	db, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.db = db
+	return r, nil
+}
+
+// LocalIdentity returns ownerSubject's persistent identity key, creating it on
+// first use. Each auth subject has its own key, so safety numbers, QR payloads
+// and signatures identify the user, not the bridge serving them.
+func (r *Repo) LocalIdentity(ownerSubject string) (ed25519.PrivateKey, error) {
+	if ownerSubject == "" {
+		return nil, fmt.Errorf("ownerSubject required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seed := r.db.Identities[ownerSubject]
+	if len(seed) != ed25519.SeedSize {
+		seed = make([]byte, ed25519.SeedSize)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, err
+		}
+		r.db.Identities[ownerSubject] = seed
+		if err := r.persist(); err != nil {
+			delete(r.db.Identities, ownerSubject)
+			return nil, err
+		}
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// LocalIdentityPublicKey returns the public half of ownerSubject's LocalIdentity.
+func (r *Repo) LocalIdentityPublicKey(ownerSubject string) ([]byte, error) {
+	priv, err := r.LocalIdentity(ownerSubject)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), priv.Public().(ed25519.PublicKey)...), nil
+}
+
+// SubjectForIdentity returns the subject whose LocalIdentity has public key
+// pub, if that identity is held here.
+func (r *Repo) SubjectForIdentity(pub []byte) (string, bool) {
+	if len(pub) != ed25519.PublicKeySize {
+		return "", false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for subject, seed := range r.db.Identities {
+		if len(seed) != ed25519.SeedSize {
+			continue
+		}
+		if bytes.Equal(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey), pub) {
+			return subject, true
+		}
+	}
+	return "", false
+}
+
+// Add stores a new contact for ownerSubject.
+// A second contact with the same identity key for the same owner is rejected.
+func (r *Repo) Add(ownerSubject, alias string, identityPublicKey, refEncrypted []byte) (*Contact, error) {
+	if ownerSubject == "" {
+		return nil, fmt.Errorf("ownerSubject required")
+	}
+	alias = strings.TrimSpace(alias)
+	if alias == "" || len(alias) > MaxAliasLen {
+		return nil, fmt.Errorf("alias must be 1..%d bytes", MaxAliasLen)
+	}
+	if len(identityPublicKey) == 0 {
+		return nil, ErrInvalidIdentity
+	}
+	fp := Fingerprint(identityPublicKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.db.Contacts {
+		if c.OwnerSubject == ownerSubject && c.Fingerprint == fp {
+			return nil, ErrDuplicate
+		}
+	}
+
+	id, err := store.NewOpaqueID("contact")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Unix()
+	c := &Contact{
+		ContactID:         id,
+		OwnerSubject:      ownerSubject,
+		Alias:             alias,
+		IdentityPublicKey: append([]byte(nil), identityPublicKey...),
+		Fingerprint:       fp,
+		RefEncrypted:      append([]byte(nil), refEncrypted...),
+		Verification:      VerificationUnverified,
+		CreatedAtUnix:     now,
+		UpdatedAtUnix:     now,
+	}
+	r.db.Contacts[id] = c
+	if err := r.persist(); err != nil {
+		delete(r.db.Contacts, id)
+		return nil, err
+	}
+	return c.clone(), nil
+}
+
+// Get returns a contact owned by ownerSubject.
+func (r *Repo) Get(ownerSubject, contactID string) (*Contact, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.owned(ownerSubject, contactID)
+	if err != nil {
+		return nil, err
+	}
+	return c.clone(), nil
+}
+
+// List returns all contacts for ownerSubject ordered by alias.
+func (r *Repo) List(ownerSubject string) []*Contact {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []*Contact
+	for _, c := range r.db.Contacts {
+		if c.OwnerSubject == ownerSubject {
+			out = append(out, c.clone())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Alias < out[j].Alias })
+	return out
+}
+
+// Rename changes a contact's alias.
+func (r *Repo) Rename(ownerSubject, contactID, alias string) (*Contact, error) {
+	alias = strings.TrimSpace(alias)
+	if alias == "" || len(alias) > MaxAliasLen {
+		return nil, fmt.Errorf("alias must be 1..%d bytes", MaxAliasLen)
+	}
+	return r.update(ownerSubject, contactID, func(c *Contact) {
+		c.Alias = alias
+	})
+}
+
+// SetVerified records the result of an out-of-band safety number comparison.
+func (r *Repo) SetVerified(ownerSubject, contactID string, verified bool) (*Contact, error) {
+	return r.update(ownerSubject, contactID, func(c *Contact) {
+		if verified {
+			c.Verification = VerificationVerified
+			c.VerifiedAtUnix = time.Now().UTC().Unix()
+			return
+		}
+		c.Verification = VerificationUnverified
+		c.VerifiedAtUnix = 0
+	})
+}
+
+// UpdateIdentityKey records a (possibly new) identity key observed for a contact.
+// If the key differs, the old key is retained, the fingerprint is recomputed and
+// the contact drops to VerificationChanged until the user re-verifies.
+// A key that already belongs to another of the owner's contacts is rejected
+// with ErrDuplicate. changed reports whether a different key was installed.
+func (r *Repo) UpdateIdentityKey(ownerSubject, contactID string, identityPublicKey []byte) (c *Contact, changed bool, err error) {
+	if len(identityPublicKey) == 0 {
+		return nil, false, ErrInvalidIdentity
+	}
+	fp := Fingerprint(identityPublicKey)
+	c, err = r.updateChecked(ownerSubject, contactID, func(c *Contact) error {
+		if c.Fingerprint == fp {
+			return nil
+		}
+		for id, other := range r.db.Contacts {
+			if id != contactID && other.OwnerSubject == ownerSubject && other.Fingerprint == fp {
+				return ErrDuplicate
+			}
+		}
+		changed = true
+		c.PreviousKeys = append(c.PreviousKeys, c.IdentityPublicKey)
+		c.IdentityPublicKey = append([]byte(nil), identityPublicKey...)
+		c.Fingerprint = fp
+		c.Verification = VerificationChanged
+		c.VerifiedAtUnix = 0
+		c.KeyChangedAtUnix = time.Now().UTC().Unix()
+		return nil
+	})
+	return c, changed, err
+}
+
+// ObserveKey records identityPublicKey as seen on an incoming envelope in
+// ownerSubject's conversation with peerFingerprint. If the key's fingerprint
+// differs, the contact the conversation was opened with gets the new key
+// (see UpdateIdentityKey). A conversation with no matching contact is left
+// alone. changed reports whether a contact's key changed.
+func (r *Repo) ObserveKey(ownerSubject, peerFingerprint string, identityPublicKey []byte) (c *Contact, changed bool, err error) {
+	if len(identityPublicKey) == 0 {
+		return nil, false, ErrInvalidIdentity
+	}
+	if Fingerprint(identityPublicKey) == peerFingerprint {
+		return nil, false, nil
+	}
+	id := r.contactIDByFingerprint(ownerSubject, peerFingerprint)
+	if id == "" {
+		return nil, false, nil
+	}
+	return r.UpdateIdentityKey(ownerSubject, id, identityPublicKey)
+}
+
+func (r *Repo) contactIDByFingerprint(ownerSubject, fp string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, c := range r.db.Contacts {
+		if c.OwnerSubject == ownerSubject && c.Fingerprint == fp {
+			return id
+		}
+	}
+	return ""
+}
+
+// Delete removes a contact. Conversations created from it are unaffected.
+func (r *Repo) Delete(ownerSubject, contactID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.owned(ownerSubject, contactID)
+	if err != nil {
+		return err
+	}
+	delete(r.db.Contacts, contactID)
+	if err := r.persist(); err != nil {
+		r.db.Contacts[contactID] = c
+		return err
+	}
+	return nil
+}
+
+// update applies fn to a copy of the contact and persists it.
+func (r *Repo) update(ownerSubject, contactID string, fn func(*Contact)) (*Contact, error) {
+	return r.updateChecked(ownerSubject, contactID, func(c *Contact) error {
+		fn(c)
+		return nil
+	})
+}
+
+// updateChecked is update for changes that can be refused. fn runs with r.mu held.
+func (r *Repo) updateChecked(ownerSubject, contactID string, fn func(*Contact) error) (*Contact, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, err := r.owned(ownerSubject, contactID)
+	if err != nil {
+		return nil, err
+	}
+	next := prev.clone()
+	if err := fn(next); err != nil {
+		return nil, err
+	}
+	next.UpdatedAtUnix = time.Now().UTC().Unix()
+
+	r.db.Contacts[contactID] = next
+	if err := r.persist(); err != nil {
+		r.db.Contacts[contactID] = prev
+		return nil, err
+	}
+	return next.clone(), nil
+}
+
+// owned looks up a contact and enforces ownership. Caller holds r.mu.
+func (r *Repo) owned(ownerSubject, contactID string) (*Contact, error) {
+	c, ok := r.db.Contacts[contactID]
+	if !ok || ownerSubject == "" || c.OwnerSubject != ownerSubject {
+		return nil, ErrNotFound
+	}
+	return c, nil
+}
+
+// ---- sealed persistence ----
+
+func (r *Repo) load() (*sealedDB, error) {
+	b, err := os.ReadFile(r.path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(b) == 0) {
+		return &sealedDB{Contacts: map[string]*Contact{}, Identities: map[string][]byte{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := chacha20poly1305.NewX(r.key[:])
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < chacha20poly1305.NonceSizeX {
+		return nil, errors.New("contacts file too short")
+	}
+	pt, err := aead.Open(nil, b[:chacha20poly1305.NonceSizeX], b[chacha20poly1305.NonceSizeX:], nil)
+	if err != nil {
+		return nil, errors.New("contacts file cannot be opened with configured key")
+	}
+
+	var db sealedDB
+	if err := json.Unmarshal(pt, &db); err != nil {
+		return nil, err
+	}
+	if db.Contacts == nil {
+		db.Contacts = map[string]*Contact{}
+	}
+	if db.Identities == nil {
+		db.Identities = map[string][]byte{}
+	}
+	return &db, nil
+}
+
+// persist seals the whole book and atomically replaces the file. Caller holds r.mu.
+func (r *Repo) persist() error {
+	pt, err := json.Marshal(r.db)
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.NewX(r.key[:])
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	out := aead.Seal(nonce, nonce, pt, nil)
+
+	return store.WriteFileAtomic(r.path, out, 0o600)
+}
+
+func (c *Contact) clone() *Contact {
+	cp := *c
+	cp.IdentityPublicKey = append([]byte(nil), c.IdentityPublicKey...)
+	cp.RefEncrypted = append([]byte(nil), c.RefEncrypted...)
+	cp.PreviousKeys = append([][]byte(nil), c.PreviousKeys...)
+	return &cp
+}
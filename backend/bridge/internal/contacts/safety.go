@@ -0,0 +1,91 @@
+package contacts
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	safetyNumberVersion    = 1
+	safetyNumberIterations = 5200
+	qrPayloadPrefix        = "privxx-sn"
+)
+
+// Fingerprint derives the internal lookup fingerprint for an identity key.
+func Fingerprint(identityPublicKey []byte) string {
+	h := sha256.Sum256(identityPublicKey)
+	return hex.EncodeToString(h[:])
+}
+
+// SafetyNumber computes a 60-digit human-comparable number for two identities.
+// Both sides compute the same value: the per-identity halves are ordered
+// before concatenation, so argument order does not matter.
+// Output is twelve space-separated groups of five digits.
+func SafetyNumber(localIdentity, peerIdentity []byte) (string, error) {
+	if len(localIdentity) == 0 || len(peerIdentity) == 0 {
+		return "", errors.New("both identities required")
+	}
+	a := safetyHalf(localIdentity)
+	b := safetyHalf(peerIdentity)
+	if b < a {
+		a, b = b, a
+	}
+	digits := a + b
+
+	groups := make([]string, 0, len(digits)/5)
+	for i := 0; i < len(digits); i += 5 {
+		groups = append(groups, digits[i:i+5])
+	}
+	return strings.Join(groups, " "), nil
+}
+
+// safetyHalf derives 30 digits from one identity via iterated SHA-512.
+// The iteration count makes brute-forcing a colliding identity expensive.
+func safetyHalf(identity []byte) string {
+	var version [2]byte
+	binary.BigEndian.PutUint16(version[:], safetyNumberVersion)
+
+	h := sha512.Sum512(append(append(version[:], identity...), identity...))
+	digest := h[:]
+	for i := 1; i < safetyNumberIterations; i++ {
+		next := sha512.Sum512(append(append([]byte(nil), digest...), identity...))
+		digest = next[:]
+	}
+
+	var sb strings.Builder
+	for i := 0; i < 30; i += 5 {
+		chunk := uint64(digest[i])<<32 | uint64(digest[i+1])<<24 | uint64(digest[i+2])<<16 |
+			uint64(digest[i+3])<<8 | uint64(digest[i+4])
+		fmt.Fprintf(&sb, "%05d", chunk%100000)
+	}
+	return sb.String()
+}
+
+// QRPayload returns a scannable payload binding both identity fingerprints.
+// Format: privxx-sn:<version>:<b64url(local sha256)>:<b64url(peer sha256)>
+func QRPayload(localIdentity, peerIdentity []byte) (string, error) {
+	if len(localIdentity) == 0 || len(peerIdentity) == 0 {
+		return "", errors.New("both identities required")
+	}
+	l := sha256.Sum256(localIdentity)
+	p := sha256.Sum256(peerIdentity)
+	return fmt.Sprintf("%s:%d:%s:%s", qrPayloadPrefix, safetyNumberVersion,
+		base64.RawURLEncoding.EncodeToString(l[:]),
+		base64.RawURLEncoding.EncodeToString(p[:])), nil
+}
+
+// MatchQRPayload checks a payload scanned from the peer's screen.
+// The peer encodes itself as "local", so the halves are swapped.
+func MatchQRPayload(scanned string, localIdentity, peerIdentity []byte) bool {
+	want, err := QRPayload(peerIdentity, localIdentity)
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(scanned) == want
+}
@@ -0,0 +1,257 @@
+package conversations
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+)
+
+var (
+	ErrNotGroup        = errors.New("conversation is not a group")
+	ErrNotMember       = errors.New("not a member")
+	ErrAlreadyMember   = errors.New("already a member")
+	ErrGroupFull       = errors.New("group member limit reached")
+	ErrForbidden       = errors.New("conversation forbidden for caller")
+	ErrStaleMembership = errors.New("stale membership version")
+)
+
+// CreateGroup creates a new group conversation owned by ownerSubject.
+// Groups are never looked up by fingerprint, so no fingerprint index is written.
+// signerPublicKey verifies membership control envelopes for this group.
+func (r *Repo) CreateGroup(ownerSubject string, members []Member, signerPublicKey []byte) (*Conversation, error) {
+	if ownerSubject == "" {
+		return nil, fmt.Errorf("ownerSubject required")
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("at least one member required")
+	}
+	if len(members) > MaxGroupMembers {
+		return nil, ErrGroupFull
+	}
+	if len(signerPublicKey) == 0 {
+		return nil, fmt.Errorf("signerPublicKey required")
+	}
+
+	convID, err := store.NewOpaqueID("conv")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Unix()
+	conv := &Conversation{
+		OwnerSubject:      ownerSubject,
+		ConversationID:    convID,
+		CreatedAtUnix:     now,
+		State:             StateActive,
+		Kind:              KindGroup,
+		MembershipVersion: 1,
+		KeyEpoch:          1,
+		SignerPublicKey:   append([]byte(nil), signerPublicKey...),
+	}
+	for _, m := range members {
+		if err := conv.addMember(m, now); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.put(conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// AddMember appends a member to a group owned by ownerSubject.
+// The group key is NOT rotated: new members receive the current epoch key.
+func (r *Repo) AddMember(ownerSubject, conversationID string, m Member) (*Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conv, err := r.getOwnedGroup(ownerSubject, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(conv.Members) >= MaxGroupMembers {
+		return nil, ErrGroupFull
+	}
+	if err := conv.addMember(m, time.Now().UTC().Unix()); err != nil {
+		return nil, err
+	}
+	conv.MembershipVersion++
+
+	if err := r.put(conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// RemoveMember removes a member from a group owned by ownerSubject.
+// It returns the updated conversation and the removed member.
+// Removal always bumps KeyEpoch so the departed member cannot read new traffic.
+func (r *Repo) RemoveMember(ownerSubject, conversationID, memberID string) (*Conversation, *Member, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conv, err := r.getOwnedGroup(ownerSubject, conversationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	idx := -1
+	for i := range conv.Members {
+		if conv.Members[i].MemberID == memberID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return nil, nil, ErrNotMember
+	}
+
+	removed := conv.Members[idx]
+	conv.Members = append(conv.Members[:idx:idx], conv.Members[idx+1:]...)
+	conv.MembershipVersion++
+	conv.KeyEpoch++
+
+	if err := r.put(conv); err != nil {
+		return nil, nil, err
+	}
+	return conv, &removed, nil
+}
+
+// JoinGroup records a group created elsewhere, from a verified group_create
+// control envelope: same conversation ID, owned locally by ownerSubject (the
+// member it was addressed to). If the ID is already known the existing record
+// is returned unchanged.
+func (r *Repo) JoinGroup(ownerSubject, conversationID string, signerPublicKey []byte, version, keyEpoch uint64, members []Member) (*Conversation, error) {
+	if ownerSubject == "" || conversationID == "" {
+		return nil, fmt.Errorf("ownerSubject and conversationID required")
+	}
+	if len(signerPublicKey) == 0 {
+		return nil, fmt.Errorf("signerPublicKey required")
+	}
+	if len(members) > MaxGroupMembers {
+		return nil, ErrGroupFull
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if conv, err := r.GetConversation(conversationID); err == nil {
+		// A repeated group_create is fine; one naming another owner's record
+		// or a different signer must not attach to it.
+		if conv.OwnerSubject != ownerSubject || conv.Kind != KindGroup ||
+			!bytes.Equal(conv.SignerPublicKey, signerPublicKey) {
+			return nil, ErrForbidden
+		}
+		return conv, nil
+	}
+	conv := &Conversation{
+		OwnerSubject:      ownerSubject,
+		ConversationID:    conversationID,
+		CreatedAtUnix:     time.Now().UTC().Unix(),
+		State:             StateActive,
+		Kind:              KindGroup,
+		Members:           append([]Member(nil), members...),
+		MembershipVersion: version,
+		KeyEpoch:          keyEpoch,
+		SignerPublicKey:   append([]byte(nil), signerPublicKey...),
+	}
+	if err := r.put(conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// ApplyMembership installs a membership snapshot received from a verified control envelope.
+// Snapshots are applied only when newer than the local record (last version wins),
+// so replays and out-of-order deliveries converge on the same membership.
+func (r *Repo) ApplyMembership(conversationID string, version, keyEpoch uint64, members []Member) (*Conversation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conv, err := r.GetConversation(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if !conv.IsGroup() {
+		return nil, ErrNotGroup
+	}
+	if version <= conv.MembershipVersion {
+		return nil, ErrStaleMembership
+	}
+	if len(members) > MaxGroupMembers {
+		return nil, ErrGroupFull
+	}
+
+	conv.Members = append([]Member(nil), members...)
+	conv.MembershipVersion = version
+	if keyEpoch > conv.KeyEpoch {
+		conv.KeyEpoch = keyEpoch
+	}
+
+	if err := r.put(conv); err != nil {
+		return nil, err
+	}
+	return conv, nil
+}
+
+// getOwnedGroup loads a group and enforces ownership. Caller holds r.mu.
+func (r *Repo) getOwnedGroup(ownerSubject, conversationID string) (*Conversation, error) {
+	if ownerSubject == "" {
+		return nil, fmt.Errorf("ownerSubject required")
+	}
+	conv, err := r.GetConversation(conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conv.OwnerSubject != ownerSubject {
+		return nil, ErrForbidden
+	}
+	if !conv.IsGroup() {
+		return nil, ErrNotGroup
+	}
+	return conv, nil
+}
+
+// put appends the latest record version and repoints the ID index at it (latest wins).
+func (r *Repo) put(conv *Conversation) error {
+	off, err := r.kv.AppendRecord(conv)
+	if err != nil {
+		return err
+	}
+	return r.kv.PutIDOffset(conv.ConversationID, off)
+}
+
+// addMember validates m and appends it with a fresh opaque member ID.
+func (c *Conversation) addMember(m Member, now int64) error {
+	if m.Fingerprint == "" {
+		return fmt.Errorf("member fingerprint required")
+	}
+	if c.hasFingerprint(m.Fingerprint) {
+		return ErrAlreadyMember
+	}
+	switch m.Role {
+	case "":
+		m.Role = RoleMember
+	case RoleAdmin, RoleMember:
+	default:
+		return fmt.Errorf("invalid member role")
+	}
+
+	memberID, err := store.NewOpaqueID("mem")
+	if err != nil {
+		return err
+	}
+	m.MemberID = memberID
+	m.IdentityPublicKey = append([]byte(nil), m.IdentityPublicKey...)
+	m.RefEncrypted = append([]byte(nil), m.RefEncrypted...)
+	m.JoinedAtUnix = now
+	c.Members = append(c.Members, m)
+	return nil
+}
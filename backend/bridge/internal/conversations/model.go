@@ -1,5 +1,30 @@
 package conversations
 
+import "github.com/Bulldog-Master/privxx/backend/core/contracts"
+
+// Conversation kinds. The values come from the backend core's contracts, but
+// records are separate: this repo never reads or writes core's conversations.
+// An empty Kind is treated as KindDirect (records written before groups existed).
+const (
+	KindDirect = contracts.KindDirect
+	KindGroup  = contracts.KindGroup
+)
+
+// Conversation states.
+const (
+	StateActive   = contracts.StateActive
+	StateArchived = contracts.StateArchived
+)
+
+// Member roles inside a group conversation.
+const (
+	RoleAdmin  = contracts.RoleAdmin
+	RoleMember = contracts.RoleMember
+)
+
+// MaxGroupMembers caps fan-out per message (Phase-1: no bulk transport).
+const MaxGroupMembers = contracts.MaxParticipants
+
 // Conversation is the Phase-1 canonical conversation record.
 // NOTE: peerFingerprint is internal-only and never exposed or logged.
 type Conversation struct {
@@ -9,4 +34,47 @@ type Conversation struct {
 	PeerRefEncrypted []byte `json:"peer_ref_encrypted"` // opaque bytes, never used for lookup
 	CreatedAtUnix    int64  `json:"created_at_unix"`    // backend-only
 	State            string `json:"state"`              // "active" | "archived"
+
+	// Group-only fields. Direct conversations leave these empty.
+	Kind              string   `json:"kind,omitempty"`               // "direct" | "group"
+	Members           []Member `json:"members,omitempty"`            // full membership snapshot
+	MembershipVersion uint64   `json:"membership_version,omitempty"` // bumped on every membership change
+	KeyEpoch          uint64   `json:"key_epoch,omitempty"`          // bumped on rekey (member removal)
+	SignerPublicKey   []byte   `json:"signer_public_key,omitempty"`  // verifies control envelopes
+}
+
+// Member is a single participant of a group conversation.
+// Fingerprint is internal-only; MemberID is the opaque handle exposed to clients.
+type Member struct {
+	MemberID          string `json:"member_id"`
+	Fingerprint       string `json:"fingerprint"`                   // internal-only
+	IdentityPublicKey []byte `json:"identity_public_key,omitempty"` // internal-only; group keys are sealed to it
+	RefEncrypted      []byte `json:"ref_encrypted,omitempty"`       // opaque transport reference
+	Role              string `json:"role"`                          // "admin" | "member"
+	JoinedAtUnix      int64  `json:"joined_at_unix"`
+}
+
+// IsGroup reports whether the conversation has a participant list.
+func (c *Conversation) IsGroup() bool {
+	return c.Kind == KindGroup
+}
+
+// MemberByID returns the member with the given opaque ID.
+func (c *Conversation) MemberByID(memberID string) (*Member, bool) {
+	for i := range c.Members {
+		if c.Members[i].MemberID == memberID {
+			return &c.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+// hasFingerprint reports whether fingerprint is already a member.
+func (c *Conversation) hasFingerprint(fingerprint string) bool {
+	for _, m := range c.Members {
+		if m.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
 }
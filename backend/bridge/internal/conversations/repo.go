@@ -2,6 +2,7 @@ package conversations
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
@@ -10,6 +11,7 @@ import (
 // Repo persists and retrieves conversations.
 // Fingerprints are internal-only and must never be logged.
 type Repo struct {
+	mu sync.Mutex // serializes read-modify-write of membership records
 	kv *store.FileKV
 }
 
@@ -17,7 +19,35 @@ func NewRepo(kv *store.FileKV) *Repo {
 	return &Repo{kv: kv}
 }
 
-// CreateOrGetConversation is idempotent by peerFingerprint.
+// directIndexKey scopes the fingerprint index to one owner, so two accounts
+// that talk to the same peer each get their own conversation.
+func directIndexKey(ownerSubject, peerFingerprint string) string {
+	return ownerSubject + "\x00" + peerFingerprint
+}
+
+// directID resolves ownerSubject's conversation with peerFingerprint. Entries
+// written before the index was owner-scoped are keyed by the bare
+// fingerprint; they are honoured only when the record belongs to ownerSubject.
+func (r *Repo) directID(ownerSubject, peerFingerprint string) (string, error) {
+	id, err := r.kv.GetConversationIDByFingerprint(directIndexKey(ownerSubject, peerFingerprint))
+	if err != store.ErrNotFound {
+		return id, err
+	}
+	id, err = r.kv.GetConversationIDByFingerprint(peerFingerprint)
+	if err != nil {
+		return "", err
+	}
+	conv, err := r.GetConversation(id)
+	if err != nil {
+		return "", err
+	}
+	if conv.OwnerSubject != ownerSubject {
+		return "", store.ErrNotFound
+	}
+	return id, nil
+}
+
+// CreateOrGetConversation is idempotent by (ownerSubject, peerFingerprint).
 // peerRefEncrypted is stored opaque and never used for lookup.
 func (r *Repo) CreateOrGetConversation(ownerSubject string, peerFingerprint string, peerRefEncrypted []byte) (*Conversation, error) {
 	if ownerSubject == "" {
@@ -26,8 +56,11 @@ func (r *Repo) CreateOrGetConversation(ownerSubject string, peerFingerprint stri
 	if peerFingerprint == "" {
 		return nil, fmt.Errorf("peerFingerprint required")
 	}
-	// 1) Lookup by fingerprint
-	if existingID, err := r.kv.GetConversationIDByFingerprint(peerFingerprint); err == nil {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 1) Lookup by owner + fingerprint
+	if existingID, err := r.directID(ownerSubject, peerFingerprint); err == nil {
 		return r.GetConversation(existingID)
 	} else if err != store.ErrNotFound {
 		return nil, err
@@ -45,7 +78,7 @@ func (r *Repo) CreateOrGetConversation(ownerSubject string, peerFingerprint stri
 		PeerFingerprint:  peerFingerprint,
 		PeerRefEncrypted: append([]byte(nil), peerRefEncrypted...),
 		CreatedAtUnix:    time.Now().UTC().Unix(),
-		State:            "active",
+		State:            StateActive,
 	}
 
 	// 3) Append record + index (write record first, then index)
@@ -56,13 +89,71 @@ func (r *Repo) CreateOrGetConversation(ownerSubject string, peerFingerprint stri
 	if err := r.kv.PutIDOffset(convID, off); err != nil {
 		return nil, err
 	}
-	if err := r.kv.PutFingerprintIndex(peerFingerprint, convID); err != nil {
+	if err := r.kv.PutFingerprintIndex(directIndexKey(ownerSubject, peerFingerprint), convID); err != nil {
+		return nil, err
+	}
+
+	return conv, nil
+}
+
+// AdoptConversation records ownerSubject's side of a direct conversation
+// whose ID the peer chose (the inviter's ID from an accepted invite), so both
+// sides address envelopes by the same ID. It is idempotent for the same
+// owner and peer; an ID held by anyone else is ErrForbidden. The owner's
+// index entry for peerFingerprint moves to the adopted conversation; an
+// earlier one with that peer stays readable by its own ID.
+func (r *Repo) AdoptConversation(ownerSubject, conversationID, peerFingerprint string, peerRefEncrypted []byte) (*Conversation, error) {
+	if ownerSubject == "" || conversationID == "" {
+		return nil, fmt.Errorf("ownerSubject and conversationID required")
+	}
+	if peerFingerprint == "" {
+		return nil, fmt.Errorf("peerFingerprint required")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	conv, err := r.GetConversation(conversationID)
+	switch {
+	case err == nil:
+		if conv.OwnerSubject != ownerSubject || conv.IsGroup() || conv.PeerFingerprint != peerFingerprint {
+			return nil, ErrForbidden
+		}
+		return conv, nil
+	case err != store.ErrNotFound:
 		return nil, err
 	}
 
+	conv = &Conversation{
+		OwnerSubject:     ownerSubject,
+		ConversationID:   conversationID,
+		PeerFingerprint:  peerFingerprint,
+		PeerRefEncrypted: append([]byte(nil), peerRefEncrypted...),
+		CreatedAtUnix:    time.Now().UTC().Unix(),
+		State:            StateActive,
+	}
+	off, err := r.kv.AppendRecord(conv)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.kv.PutIDOffset(conversationID, off); err != nil {
+		return nil, err
+	}
+	if err := r.kv.PutFingerprintIndex(directIndexKey(ownerSubject, peerFingerprint), conversationID); err != nil {
+		return nil, err
+	}
 	return conv, nil
 }
 
+// FindDirect returns ownerSubject's existing conversation with
+// peerFingerprint without creating one; store.ErrNotFound when there is none.
+func (r *Repo) FindDirect(ownerSubject, peerFingerprint string) (*Conversation, error) {
+	id, err := r.directID(ownerSubject, peerFingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetConversation(id)
+}
+
 func (r *Repo) GetConversation(conversationID string) (*Conversation, error) {
 	if conversationID == "" {
 		return nil, fmt.Errorf("conversationID required")
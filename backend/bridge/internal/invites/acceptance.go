@@ -0,0 +1,196 @@
+package invites
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+)
+
+// Acceptance states as reported to clients.
+const (
+	AcceptancePending  = "pending"
+	AcceptanceAccepted = "accepted"
+	AcceptanceRejected = "rejected"
+)
+
+var ErrAcceptanceExists = errors.New("invite already being accepted")
+
+// Acceptance is the acceptor-side record of a sent handshake. The acceptor's
+// conversation is created only when the inviter confirms it.
+type Acceptance struct {
+	InviteID           string `json:"invite_id"`
+	AcceptorSubject    string `json:"acceptor_subject"` // jwt.sub (internal)
+	InviterIdentityKey []byte `json:"inviter_identity_key"`
+	InviterRef         []byte `json:"inviter_ref,omitempty"` // opaque transport reference
+	State              string `json:"state"`
+	CreatedAtUnix      int64  `json:"created_at_unix"`
+	ExpiresAtUnix      int64  `json:"expires_at_unix"` // the token's expiry
+	ResolvedAtUnix     int64  `json:"resolved_at_unix,omitempty"`
+	ConversationID     string `json:"conversation_id,omitempty"` // set when accepted
+}
+
+// acceptances persists Acceptance records as one JSON file, rewritten atomically.
+type acceptances struct {
+	mu   sync.Mutex
+	path string
+	byID map[string]*Acceptance
+}
+
+func openAcceptances(dir string) (*acceptances, error) {
+	a := &acceptances{
+		path: filepath.Join(dir, "acceptances.json"),
+		byID: map[string]*Acceptance{},
+	}
+	b, err := os.ReadFile(a.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &a.byID); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// BeginAcceptance records a pending acceptance of inviteID by acceptorSubject.
+// Repeating it for a still-pending acceptance by the same subject is allowed,
+// so a lost handshake can be resent.
+func (s *Store) BeginAcceptance(acc Acceptance) (*Acceptance, error) {
+	if acc.InviteID == "" || acc.AcceptorSubject == "" {
+		return nil, fmt.Errorf("inviteID and acceptorSubject required")
+	}
+	a := s.accepts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if prev, ok := a.byID[acc.InviteID]; ok {
+		if prev.AcceptorSubject != acc.AcceptorSubject || prev.State != AcceptancePending {
+			return nil, ErrAcceptanceExists
+		}
+		cp := *prev
+		return &cp, nil
+	}
+	acc.State = AcceptancePending
+	acc.CreatedAtUnix = time.Now().UTC().Unix()
+	rec := acc
+	a.byID[acc.InviteID] = &rec
+	if err := a.persist(); err != nil {
+		delete(a.byID, acc.InviteID)
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// AbortAcceptance drops a pending acceptance whose handshake could not be sent.
+func (s *Store) AbortAcceptance(inviteID, acceptorSubject string) error {
+	a := s.accepts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	prev, ok := a.byID[inviteID]
+	if !ok || prev.AcceptorSubject != acceptorSubject || prev.State != AcceptancePending {
+		return nil
+	}
+	delete(a.byID, inviteID)
+	if err := a.persist(); err != nil {
+		a.byID[inviteID] = prev
+		return err
+	}
+	return nil
+}
+
+// PendingAcceptance returns the pending acceptance of inviteID.
+func (s *Store) PendingAcceptance(inviteID string) (*Acceptance, error) {
+	a := s.accepts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	acc, ok := a.byID[inviteID]
+	if !ok || acc.State != AcceptancePending {
+		return nil, ErrNotFound
+	}
+	cp := *acc
+	return &cp, nil
+}
+
+// ResolveAcceptance settles a pending acceptance exactly once. conversationID
+// is called only for accepted ones, to create the acceptor's conversation.
+func (s *Store) ResolveAcceptance(inviteID string, accepted bool, conversationID func(*Acceptance) (string, error)) (*Acceptance, error) {
+	a := s.accepts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	prev, ok := a.byID[inviteID]
+	if !ok || prev.State != AcceptancePending {
+		return nil, ErrNotOutstanding
+	}
+	next := *prev
+	next.ResolvedAtUnix = time.Now().UTC().Unix()
+	next.State = AcceptanceRejected
+	if accepted {
+		convID, err := conversationID(&next)
+		if err != nil {
+			return nil, err
+		}
+		next.State = AcceptanceAccepted
+		next.ConversationID = convID
+	}
+	a.byID[inviteID] = &next
+	if err := a.persist(); err != nil {
+		a.byID[inviteID] = prev
+		return nil, err
+	}
+	cp := next
+	return &cp, nil
+}
+
+// Acceptances returns acceptorSubject's acceptances, newest first.
+func (s *Store) Acceptances(acceptorSubject string) []Acceptance {
+	a := s.accepts
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var out []Acceptance
+	for _, acc := range a.byID {
+		if acc.AcceptorSubject == acceptorSubject {
+			out = append(out, *acc)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAtUnix > out[j].CreatedAtUnix })
+	return out
+}
+
+// cleanup drops acceptances whose token expired before cutoff.
+func (a *acceptances) cleanup(cutoff int64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	removed := 0
+	for id, acc := range a.byID {
+		if acc.ExpiresAtUnix < cutoff {
+			delete(a.byID, id)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return nil
+	}
+	return a.persist()
+}
+
+// persist atomically rewrites the acceptances file. Caller holds a.mu.
+func (a *acceptances) persist() error {
+	b, err := json.Marshal(a.byID)
+	if err != nil {
+		return err
+	}
+	return store.WriteFileAtomic(a.path, b, 0o600)
+}
@@ -0,0 +1,324 @@
+package invites
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/contacts"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
+)
+
+// Wire types the orchestrator routes to OnHandshake and OnHandshakeAck.
+const (
+	HandshakeEnvelopeType    = "handshake"
+	HandshakeAckEnvelopeType = "handshake_ack"
+)
+
+const (
+	DefaultInviteTTL = 24 * time.Hour
+	MaxInviteTTL     = 7 * 24 * time.Hour
+)
+
+// maxConversationIDLen bounds the conversation ID an ack may name.
+const maxConversationIDLen = 128
+
+var (
+	ErrBadHandshake  = errors.New("handshake envelope invalid")
+	ErrNoReception   = errors.New("invite token has no reception reference")
+	ErrSelfInvite    = errors.New("cannot accept own invite")
+	ErrUnknownInvite = errors.New("handshake for unknown invite")
+)
+
+// HandshakeEnvelopeV1 is sent by the acceptor to the inviter's reception identity.
+// It is signed by the acceptor's identity key so the inviter can bind the new
+// conversation to that key.
+type HandshakeEnvelopeV1 struct {
+	V                   int    `json:"v"`
+	Type                string `json:"type"` // always "handshake"
+	InviteID            string `json:"invite_id"`
+	AcceptorIdentityKey []byte `json:"acceptor_identity_key"`
+	AcceptorRef         []byte `json:"acceptor_ref,omitempty"` // opaque transport reference
+	CreatedAtUnix       int64  `json:"created_at_unix"`
+	Signature           []byte `json:"signature,omitempty"`
+}
+
+func (h *HandshakeEnvelopeV1) signingBytes() ([]byte, error) {
+	unsigned := *h
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// HandshakeAckEnvelopeV1 is the inviter's answer to a handshake, sent to the
+// acceptor's reception identity and signed by the inviter's identity key.
+// Only an accepted ack makes the acceptor create its side of the conversation,
+// under the inviter's ConversationID so envelopes route on both sides.
+type HandshakeAckEnvelopeV1 struct {
+	V              int    `json:"v"`
+	Type           string `json:"type"` // always "handshake_ack"
+	InviteID       string `json:"invite_id"`
+	Accepted       bool   `json:"accepted"`
+	ConversationID string `json:"conversation_id,omitempty"` // set when accepted
+	RecipientRef   []byte `json:"recipient_ref,omitempty"`   // the acceptor's reception reference
+	CreatedAtUnix  int64  `json:"created_at_unix"`
+	Signature      []byte `json:"signature,omitempty"`
+}
+
+func (h *HandshakeAckEnvelopeV1) signingBytes() ([]byte, error) {
+	unsigned := *h
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// Identities returns a subject's long-term ed25519 identity key.
+type Identities func(subject string) (ed25519.PrivateKey, error)
+
+// Service ties the invite store to conversation creation and the transport.
+type Service struct {
+	store        *Store
+	convRepo     *conversations.Repo
+	identities   Identities
+	receptionRef []byte
+	tx           transport.Adapter
+}
+
+// NewService wires invites to per-subject identity keys and the transport's
+// reception reference, which issued tokens embed so acceptors can reach us.
+// Tokens are signed by the inviter's key and handshakes by the acceptor's.
+func NewService(s *Store, convRepo *conversations.Repo, identities Identities, receptionRef []byte, tx transport.Adapter) (*Service, error) {
+	if s == nil || convRepo == nil || tx == nil {
+		return nil, fmt.Errorf("store, convRepo and transport required")
+	}
+	if len(receptionRef) == 0 {
+		return nil, fmt.Errorf("reception reference required")
+	}
+	if identities == nil {
+		return nil, fmt.Errorf("identity source required")
+	}
+	return &Service{
+		store:        s,
+		convRepo:     convRepo,
+		identities:   identities,
+		receptionRef: append([]byte(nil), receptionRef...),
+		tx:           tx,
+	}, nil
+}
+
+// Create issues a new invite and its shareable token. ttl <= 0 uses the default.
+func (svc *Service) Create(ownerSubject string, ttl time.Duration) (*Invite, string, error) {
+	if ttl <= 0 {
+		ttl = DefaultInviteTTL
+	}
+	if ttl > MaxInviteTTL {
+		ttl = MaxInviteTTL
+	}
+	identity, err := svc.identities(ownerSubject)
+	if err != nil {
+		return nil, "", err
+	}
+	inv, err := svc.store.Create(ownerSubject, ttl)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := SignToken(&TokenClaims{
+		V:                   1,
+		InviteID:            inv.InviteID,
+		InviterIdentityKey:  identity.Public().(ed25519.PublicKey),
+		InviterReceptionRef: svc.receptionRef,
+		IssuedAtUnix:        inv.CreatedAtUnix,
+		ExpiresAtUnix:       inv.ExpiresAtUnix,
+	}, identity)
+	if err != nil {
+		return nil, "", err
+	}
+	return inv, token, nil
+}
+
+func (svc *Service) List(ownerSubject string) []Invite {
+	return svc.store.List(ownerSubject)
+}
+
+func (svc *Service) Revoke(ownerSubject, inviteID string) (*Invite, error) {
+	return svc.store.Revoke(ownerSubject, inviteID)
+}
+
+// Accept verifies token, records a pending acceptance and sends the signed
+// handshake to the inviter. The acceptor's conversation is created only when
+// the inviter's ack confirms it (see OnHandshakeAck); revocation and expiry
+// are enforced by the inviter when the handshake arrives.
+func (svc *Service) Accept(ctx context.Context, acceptorSubject, token string) (*Acceptance, error) {
+	claims, err := ParseToken(token, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	identity, err := svc.identities(acceptorSubject)
+	if err != nil {
+		return nil, err
+	}
+	localPub := identity.Public().(ed25519.PublicKey)
+	if string(claims.InviterIdentityKey) == string(localPub) {
+		return nil, ErrSelfInvite
+	}
+	if len(claims.InviterReceptionRef) == 0 {
+		return nil, ErrNoReception
+	}
+
+	acc, err := svc.store.BeginAcceptance(Acceptance{
+		InviteID:           claims.InviteID,
+		AcceptorSubject:    acceptorSubject,
+		InviterIdentityKey: claims.InviterIdentityKey,
+		InviterRef:         claims.InviterReceptionRef,
+		ExpiresAtUnix:      claims.ExpiresAtUnix,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hs := &HandshakeEnvelopeV1{
+		V:                   1,
+		Type:                HandshakeEnvelopeType,
+		InviteID:            claims.InviteID,
+		AcceptorIdentityKey: localPub,
+		AcceptorRef:         svc.receptionRef,
+		CreatedAtUnix:       time.Now().UTC().Unix(),
+	}
+	b, err := hs.signingBytes()
+	if err != nil {
+		return nil, err
+	}
+	hs.Signature = ed25519.Sign(identity, b)
+	wire, err := json.Marshal(hs)
+	if err == nil {
+		err = svc.tx.Send(ctx, wire)
+	}
+	if err != nil {
+		if aerr := svc.store.AbortAcceptance(claims.InviteID, acceptorSubject); aerr != nil {
+			return nil, errors.Join(err, aerr)
+		}
+		return nil, err
+	}
+	return acc, nil
+}
+
+// Acceptances lists acceptorSubject's accepted tokens and their outcome.
+func (svc *Service) Acceptances(acceptorSubject string) []Acceptance {
+	return svc.store.Acceptances(acceptorSubject)
+}
+
+// OnHandshake handles an inbound handshake on the inviter side.
+// Only a pending invite is accepted, exactly once; revoked or expired invites
+// are rejected without creating a conversation. Either way the acceptor gets a
+// signed ack, so it creates its side only for an accepted invite.
+func (svc *Service) OnHandshake(ctx context.Context, b []byte) error {
+	var hs HandshakeEnvelopeV1
+	if err := json.Unmarshal(b, &hs); err != nil {
+		return ErrBadHandshake
+	}
+	if hs.V != 1 || hs.Type != HandshakeEnvelopeType || hs.InviteID == "" ||
+		len(hs.AcceptorIdentityKey) != ed25519.PublicKeySize || len(hs.Signature) == 0 {
+		return ErrBadHandshake
+	}
+	signed, err := hs.signingBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(hs.AcceptorIdentityKey), signed, hs.Signature) {
+		return ErrBadHandshake
+	}
+
+	inv, err := svc.store.Accept(hs.InviteID, hs.AcceptorIdentityKey, func(ownerSubject string) (string, error) {
+		conv, err := svc.convRepo.CreateOrGetConversation(ownerSubject, contacts.Fingerprint(hs.AcceptorIdentityKey), hs.AcceptorRef)
+		if err != nil {
+			return "", err
+		}
+		return conv.ConversationID, nil
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		// Not ours (or cleaned up): nothing to sign an answer with.
+		return ErrUnknownInvite
+	case errors.Is(err, ErrNotOutstanding):
+		if inv, err = svc.store.Lookup(hs.InviteID); err != nil {
+			return ErrUnknownInvite
+		}
+		return svc.sendAck(ctx, inv.OwnerSubject, &hs, "")
+	case err != nil:
+		return err
+	}
+	return svc.sendAck(ctx, inv.OwnerSubject, &hs, inv.ConversationID)
+}
+
+// sendAck answers hs with an ack signed by the inviter's identity key. A
+// non-empty conversationID accepts the handshake and names the conversation.
+func (svc *Service) sendAck(ctx context.Context, inviterSubject string, hs *HandshakeEnvelopeV1, conversationID string) error {
+	identity, err := svc.identities(inviterSubject)
+	if err != nil {
+		return err
+	}
+	ack := &HandshakeAckEnvelopeV1{
+		V:              1,
+		Type:           HandshakeAckEnvelopeType,
+		InviteID:       hs.InviteID,
+		Accepted:       conversationID != "",
+		ConversationID: conversationID,
+		RecipientRef:   hs.AcceptorRef,
+		CreatedAtUnix:  time.Now().UTC().Unix(),
+	}
+	b, err := ack.signingBytes()
+	if err != nil {
+		return err
+	}
+	ack.Signature = ed25519.Sign(identity, b)
+	wire, err := json.Marshal(ack)
+	if err != nil {
+		return err
+	}
+	return svc.tx.Send(ctx, wire)
+}
+
+// OnHandshakeAck handles the inviter's ack on the acceptor side. The ack must
+// be signed by the inviter key from the token; an accepted ack creates the
+// acceptor's conversation under the inviter's conversation ID, a rejected one
+// just settles the acceptance.
+func (svc *Service) OnHandshakeAck(ctx context.Context, b []byte) error {
+	_ = ctx
+
+	var ack HandshakeAckEnvelopeV1
+	if err := json.Unmarshal(b, &ack); err != nil {
+		return ErrBadHandshake
+	}
+	if ack.V != 1 || ack.Type != HandshakeAckEnvelopeType || ack.InviteID == "" || len(ack.Signature) == 0 {
+		return ErrBadHandshake
+	}
+	if ack.Accepted && (ack.ConversationID == "" || len(ack.ConversationID) > maxConversationIDLen) {
+		return ErrBadHandshake
+	}
+	acc, err := svc.store.PendingAcceptance(ack.InviteID)
+	if err != nil {
+		return ErrUnknownInvite
+	}
+	signed, err := ack.signingBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(acc.InviterIdentityKey), signed, ack.Signature) {
+		return ErrBadHandshake
+	}
+
+	_, err = svc.store.ResolveAcceptance(ack.InviteID, ack.Accepted, func(acc *Acceptance) (string, error) {
+		conv, err := svc.convRepo.AdoptConversation(acc.AcceptorSubject, ack.ConversationID, contacts.Fingerprint(acc.InviterIdentityKey), acc.InviterRef)
+		if err != nil {
+			return "", err
+		}
+		return conv.ConversationID, nil
+	})
+	if errors.Is(err, ErrNotOutstanding) {
+		// Duplicate ack: already settled.
+		return nil
+	}
+	return err
+}
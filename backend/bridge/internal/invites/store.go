@@ -0,0 +1,270 @@
+package invites
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// Invite states as reported to clients.
+const (
+	StatePending  = "pending"
+	StateAccepted = "accepted"
+	StateRevoked  = "revoked"
+	StateExpired  = "expired"
+)
+
+var (
+	ErrNotFound       = errors.New("invite not found")
+	ErrNotOutstanding = errors.New("invite is not outstanding")
+)
+
+// Invite is the inviter-side record of an issued token.
+// The token itself is never stored; only its ID is needed to accept or revoke.
+type Invite struct {
+	InviteID       string `json:"invite_id"`
+	OwnerSubject   string `json:"owner_subject"` // jwt.sub (internal)
+	CreatedAtUnix  int64  `json:"created_at_unix"`
+	ExpiresAtUnix  int64  `json:"expires_at_unix"`
+	RevokedAtUnix  int64  `json:"revoked_at_unix,omitempty"`
+	AcceptedAtUnix int64  `json:"accepted_at_unix,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"` // set on acceptance
+	AcceptorKey    []byte `json:"acceptor_key,omitempty"`    // set on acceptance; lets a resent handshake be confirmed again
+}
+
+// State derives the client-facing state at now.
+func (i *Invite) State(now time.Time) string {
+	switch {
+	case i.AcceptedAtUnix > 0:
+		return StateAccepted
+	case i.RevokedAtUnix > 0:
+		return StateRevoked
+	case now.Unix() >= i.ExpiresAtUnix:
+		return StateExpired
+	default:
+		return StatePending
+	}
+}
+
+// Store persists invite records as one JSON file, rewritten atomically, and
+// the acceptor-side acceptances as another.
+type Store struct {
+	mu      sync.Mutex
+	path    string
+	invites map[string]*Invite
+	accepts *acceptances
+}
+
+func NewStore(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("dir required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	s := &Store{
+		path:    filepath.Join(dir, "invites.json"),
+		invites: map[string]*Invite{},
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &s.invites); err != nil {
+			return nil, err
+		}
+	}
+	if s.accepts, err = openAcceptances(dir); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Create records a new pending invite for ownerSubject.
+func (s *Store) Create(ownerSubject string, ttl time.Duration) (*Invite, error) {
+	if ownerSubject == "" {
+		return nil, fmt.Errorf("ownerSubject required")
+	}
+	id, err := store.NewOpaqueID("inv")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	inv := &Invite{
+		InviteID:      id,
+		OwnerSubject:  ownerSubject,
+		CreatedAtUnix: now.Unix(),
+		ExpiresAtUnix: now.Add(ttl).Unix(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.invites[id] = inv
+	if err := s.persist(); err != nil {
+		delete(s.invites, id)
+		return nil, err
+	}
+	cp := *inv
+	return &cp, nil
+}
+
+// Get returns one of ownerSubject's invites.
+func (s *Store) Get(ownerSubject, inviteID string) (*Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invites[inviteID]
+	if !ok || inv.OwnerSubject != ownerSubject {
+		return nil, ErrNotFound
+	}
+	cp := *inv
+	return &cp, nil
+}
+
+// List returns ownerSubject's invites, newest first.
+func (s *Store) List(ownerSubject string) []Invite {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Invite
+	for _, inv := range s.invites {
+		if inv.OwnerSubject == ownerSubject {
+			out = append(out, *inv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAtUnix > out[j].CreatedAtUnix })
+	return out
+}
+
+// Revoke invalidates a pending invite. Accepted invites cannot be revoked.
+func (s *Store) Revoke(ownerSubject, inviteID string) (*Invite, error) {
+	return s.transition(inviteID, func(inv *Invite, now time.Time) error {
+		if inv.OwnerSubject != ownerSubject {
+			return ErrNotFound
+		}
+		if inv.State(now) != StatePending {
+			return ErrNotOutstanding
+		}
+		inv.RevokedAtUnix = now.Unix()
+		return nil
+	})
+}
+
+// Accept marks a pending invite accepted exactly once, by acceptorKey.
+// conversationID is the inviter-side conversation created for the acceptor.
+// A repeat by the same acceptor returns the accepted invite unchanged, so a
+// handshake resent after a lost confirmation is confirmed again.
+func (s *Store) Accept(inviteID string, acceptorKey []byte, conversationID func(ownerSubject string) (string, error)) (*Invite, error) {
+	return s.transition(inviteID, func(inv *Invite, now time.Time) error {
+		if inv.State(now) == StateAccepted && string(inv.AcceptorKey) == string(acceptorKey) {
+			return nil
+		}
+		if inv.State(now) != StatePending {
+			return ErrNotOutstanding
+		}
+		convID, err := conversationID(inv.OwnerSubject)
+		if err != nil {
+			return err
+		}
+		inv.AcceptedAtUnix = now.Unix()
+		inv.ConversationID = convID
+		inv.AcceptorKey = append([]byte(nil), acceptorKey...)
+		return nil
+	})
+}
+
+// Lookup returns an invite by ID regardless of owner, for handshake replies.
+func (s *Store) Lookup(inviteID string) (*Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inv, ok := s.invites[inviteID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	cp := *inv
+	return &cp, nil
+}
+
+// Cleanup drops invites and acceptances that expired more than retain ago.
+func (s *Store) Cleanup(retain time.Duration) error {
+	cutoff := time.Now().UTC().Add(-retain).Unix()
+	if err := s.accepts.cleanup(cutoff); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for id, inv := range s.invites {
+		if inv.ExpiresAtUnix < cutoff {
+			delete(s.invites, id)
+			removed++
+		}
+	}
+	if removed == 0 {
+		return nil
+	}
+	return s.persist()
+}
+
+// StartCleanup runs Cleanup(retain) every interval until ctx is done.
+func (s *Store) StartCleanup(ctx context.Context, every, retain time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Cleanup(retain); err != nil {
+					logging.Error(ctx, "invite cleanup failed", logging.Err(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// transition applies fn under the lock and persists on success.
+func (s *Store) transition(inviteID string, fn func(*Invite, time.Time) error) (*Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.invites[inviteID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	next := *prev
+	if err := fn(&next, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	s.invites[inviteID] = &next
+	if err := s.persist(); err != nil {
+		s.invites[inviteID] = prev
+		return nil, err
+	}
+	cp := next
+	return &cp, nil
+}
+
+// persist atomically rewrites the invites file. Caller holds s.mu.
+func (s *Store) persist() error {
+	b, err := json.Marshal(s.invites)
+	if err != nil {
+		return err
+	}
+	return store.WriteFileAtomic(s.path, b, 0o600)
+}
@@ -0,0 +1,78 @@
+package invites
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// tokenPrefix versions the text form. Tokens are plain ASCII so they can be
+// pasted as text or rendered directly as a QR code.
+const tokenPrefix = "pxi1"
+
+var (
+	ErrMalformedToken = errors.New("malformed invite token")
+	ErrBadSignature   = errors.New("invite token signature invalid")
+	ErrTokenExpired   = errors.New("invite token expired")
+)
+
+// TokenClaims is the signed payload of an invite token.
+// It carries the inviter's reception identity: enough for the acceptor to
+// create a conversation and address the handshake back to the inviter.
+type TokenClaims struct {
+	V                   int    `json:"v"`
+	InviteID            string `json:"iid"`
+	InviterIdentityKey  []byte `json:"ipk"`            // ed25519 public key
+	InviterReceptionRef []byte `json:"rref,omitempty"` // opaque transport reference
+	IssuedAtUnix        int64  `json:"iat"`
+	ExpiresAtUnix       int64  `json:"exp"`
+}
+
+// SignToken encodes claims as pxi1.<b64url(payload)>.<b64url(sig)>.
+func SignToken(claims *TokenClaims, priv ed25519.PrivateKey) (string, error) {
+	if claims == nil || claims.InviteID == "" || claims.ExpiresAtUnix <= 0 {
+		return "", ErrMalformedToken
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := tokenPrefix + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sig := ed25519.Sign(priv, []byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// ParseToken verifies a token against the inviter key it embeds and checks expiry.
+// The token is self-certifying: it proves the issuer holds InviterIdentityKey.
+func ParseToken(token string, now time.Time) (*TokenClaims, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 || parts[0] != tokenPrefix {
+		return nil, ErrMalformedToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformedToken
+	}
+
+	var claims TokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrMalformedToken
+	}
+	if claims.V != 1 || claims.InviteID == "" || len(claims.InviterIdentityKey) != ed25519.PublicKeySize {
+		return nil, ErrMalformedToken
+	}
+	if !ed25519.Verify(ed25519.PublicKey(claims.InviterIdentityKey), []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, ErrBadSignature
+	}
+	if now.Unix() >= claims.ExpiresAtUnix {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
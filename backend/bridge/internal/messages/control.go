@@ -0,0 +1,169 @@
+package messages
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+)
+
+// Control envelope operations (group membership).
+const (
+	ControlOpCreate       = "group_create"
+	ControlOpMemberAdd    = "member_add"
+	ControlOpMemberRemove = "member_remove"
+)
+
+const controlEnvelopeType = "control"
+
+var (
+	ErrBadControlSignature = errors.New("control envelope signature invalid")
+)
+
+// ControlEnvelopeV1 carries a signed group membership change.
+// Every control envelope holds the FULL membership snapshot plus its version,
+// so members converge regardless of delivery order (highest version wins).
+// IMPORTANT: WrappedKey is sealed per recipient and must never be logged.
+type ControlEnvelopeV1 struct {
+	V    int    `json:"v"`
+	Type string `json:"type"` // always "control"
+	Op   string `json:"op"`
+
+	ConversationID    string          `json:"conversation_id"`
+	MembershipVersion uint64          `json:"membership_version"`
+	KeyEpoch          uint64          `json:"key_epoch"`
+	Members           []ControlMember `json:"members"`
+
+	// SignerPublicKey is the group owner's identity key. Receivers trust it
+	// only from the group_create that introduces an unknown group; later
+	// envelopes are verified against the key recorded then.
+	SignerPublicKey []byte `json:"signer_public_key"`
+
+	// Per-recipient fields. WrappedKey is the group key sealed to
+	// RecipientIdentityKey; it is empty for removed members.
+	RecipientRefEncrypted []byte `json:"recipient_ref_encrypted,omitempty"`
+	RecipientIdentityKey  []byte `json:"recipient_identity_key"`
+	WrappedKey            []byte `json:"wrapped_key,omitempty"`
+
+	CreatedAtUnix int64  `json:"created_at_unix"`
+	Signature     []byte `json:"signature,omitempty"`
+}
+
+// ControlMember is the part of a member record that leaves the owner's
+// bridge: no fingerprint, identity key or transport reference.
+type ControlMember struct {
+	MemberID     string `json:"member_id"`
+	Role         string `json:"role"`
+	JoinedAtUnix int64  `json:"joined_at_unix"`
+}
+
+// projectMembers strips members down to what control envelopes carry.
+func projectMembers(members []conversations.Member) []ControlMember {
+	out := make([]ControlMember, 0, len(members))
+	for _, m := range members {
+		out = append(out, ControlMember{MemberID: m.MemberID, Role: m.Role, JoinedAtUnix: m.JoinedAtUnix})
+	}
+	return out
+}
+
+// receivedMembers is the receiver-side membership record of a snapshot.
+func receivedMembers(members []ControlMember) []conversations.Member {
+	out := make([]conversations.Member, 0, len(members))
+	for _, m := range members {
+		out = append(out, conversations.Member{MemberID: m.MemberID, Role: m.Role, JoinedAtUnix: m.JoinedAtUnix})
+	}
+	return out
+}
+
+func (c *ControlEnvelopeV1) Validate() error {
+	if c.V != 1 {
+		return errors.New("bad control envelope version")
+	}
+	if c.Type != controlEnvelopeType {
+		return errors.New("bad control envelope type")
+	}
+	switch c.Op {
+	case ControlOpCreate, ControlOpMemberAdd, ControlOpMemberRemove:
+	default:
+		return errors.New("unknown control op")
+	}
+	if c.ConversationID == "" {
+		return errors.New("missing conversation_id")
+	}
+	if c.MembershipVersion == 0 || c.KeyEpoch == 0 {
+		return errors.New("missing membership_version or key_epoch")
+	}
+	if len(c.SignerPublicKey) != ed25519.PublicKeySize || len(c.RecipientIdentityKey) != ed25519.PublicKeySize {
+		return errors.New("missing signer or recipient identity key")
+	}
+	if c.CreatedAtUnix <= 0 {
+		return errors.New("missing created_at_unix")
+	}
+	return nil
+}
+
+// signingBytes is the canonical signed form: the envelope with Signature cleared.
+func (c *ControlEnvelopeV1) signingBytes() ([]byte, error) {
+	unsigned := *c
+	unsigned.Signature = nil
+	return json.Marshal(&unsigned)
+}
+
+// Sign sets Signature using the group signer key.
+func (c *ControlEnvelopeV1) Sign(priv ed25519.PrivateKey) error {
+	b, err := c.signingBytes()
+	if err != nil {
+		return err
+	}
+	c.Signature = ed25519.Sign(priv, b)
+	return nil
+}
+
+// Verify checks Signature against the group's recorded signer public key.
+func (c *ControlEnvelopeV1) Verify(pub []byte) error {
+	if len(pub) != ed25519.PublicKeySize || len(c.Signature) == 0 {
+		return ErrBadControlSignature
+	}
+	b, err := c.signingBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(pub), b, c.Signature) {
+		return ErrBadControlSignature
+	}
+	return nil
+}
+
+func EncodeControlEnvelope(c *ControlEnvelopeV1) ([]byte, error) {
+	if c == nil {
+		return nil, errors.New("nil control envelope")
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return json.Marshal(c)
+}
+
+func DecodeControlEnvelope(b []byte) (*ControlEnvelopeV1, error) {
+	var c ControlEnvelopeV1
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// envelopeType peeks at the wire type without decoding the whole payload.
+// Message envelopes carry no type and return "".
+func envelopeType(b []byte) string {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(b, &probe); err != nil {
+		return ""
+	}
+	return probe.Type
+}
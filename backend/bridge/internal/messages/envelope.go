@@ -1,10 +1,13 @@
 package messages
 
 import (
+	"crypto/ed25519"
 	"encoding/json"
 	"errors"
 )
 
+var ErrBadEnvelopeSignature = errors.New("envelope signature invalid")
+
 // EnvelopeV1 is the Phase-1 internal envelope container.
 // IMPORTANT:
 // - The "ciphertext" field is what will be stored at rest.
@@ -17,10 +20,21 @@ type EnvelopeV1 struct {
 	// Optional opaque sender reference. Never used for lookup.
 	SenderRefEncrypted []byte `json:"sender_ref_encrypted,omitempty"`
 
+	// Group fan-out only: opaque recipient reference and the group key epoch
+	// the ciphertext was sealed under. Both empty for direct conversations.
+	RecipientRefEncrypted []byte `json:"recipient_ref_encrypted,omitempty"`
+	KeyEpoch              uint64 `json:"key_epoch,omitempty"`
+
 	// Ciphertext of the message payload (NOT plaintext). Stored at rest.
 	Ciphertext []byte `json:"ciphertext"`
 
 	CreatedAtUnix int64 `json:"created_at_unix"`
+
+	// Sender's identity public key and signature over the envelope (with
+	// Signature cleared). Receivers compare the key with the one the
+	// conversation was opened with to detect identity key changes.
+	SenderIdentityKey []byte `json:"sender_identity_key,omitempty"`
+	Signature         []byte `json:"signature,omitempty"`
 }
 
 func (e *EnvelopeV1) Validate() error {
@@ -39,6 +53,37 @@ func (e *EnvelopeV1) Validate() error {
 	return nil
 }
 
+// Sign sets SenderIdentityKey and Signature from the sender's identity key.
+func (e *EnvelopeV1) Sign(priv ed25519.PrivateKey) error {
+	e.SenderIdentityKey = append([]byte(nil), priv.Public().(ed25519.PublicKey)...)
+	unsigned := *e
+	unsigned.Signature = nil
+	b, err := json.Marshal(&unsigned)
+	if err != nil {
+		return err
+	}
+	e.Signature = ed25519.Sign(priv, b)
+	return nil
+}
+
+// VerifySender checks Signature against SenderIdentityKey. It only proves the
+// sender holds that key; whether the key is the expected one is up to the caller.
+func (e *EnvelopeV1) VerifySender() error {
+	if len(e.SenderIdentityKey) != ed25519.PublicKeySize || len(e.Signature) == 0 {
+		return ErrBadEnvelopeSignature
+	}
+	unsigned := *e
+	unsigned.Signature = nil
+	b, err := json.Marshal(&unsigned)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(ed25519.PublicKey(e.SenderIdentityKey), b, e.Signature) {
+		return ErrBadEnvelopeSignature
+	}
+	return nil
+}
+
 func EncodeEnvelope(e *EnvelopeV1) ([]byte, error) {
 	if e == nil {
 		return nil, errors.New("nil envelope")
@@ -0,0 +1,118 @@
+package messages
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+)
+
+var (
+	ErrGroupKeyUnavailable = errors.New("group key unavailable for epoch")
+	ErrGroupKeyExists      = errors.New("group key already exists for epoch")
+)
+
+// groupKeyring holds per-conversation group keys by epoch. Keys are never logged.
+// Older epochs are kept so history sealed before a rekey stays readable.
+// With a sealed file attached (see PersistGroupKeys) every change is saved
+// before it takes effect, so keys survive restarts.
+type groupKeyring struct {
+	mu   sync.Mutex
+	keys map[string]map[uint64]*[32]byte // conversationID -> epoch -> key
+	file *store.SealedFile               // nil: memory only
+}
+
+func newGroupKeyring() *groupKeyring {
+	return &groupKeyring{keys: make(map[string]map[uint64]*[32]byte)}
+}
+
+// attach loads the keys saved in f and saves every later change there.
+func (k *groupKeyring) attach(f *store.SealedFile) error {
+	var saved map[string]map[uint64][]byte
+	if _, err := f.Load(&saved); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for convID, byEpoch := range saved {
+		for epoch, key := range byEpoch {
+			if len(key) != 32 {
+				continue
+			}
+			k.setLocked(convID, epoch, key)
+		}
+	}
+	k.file = f
+	return nil
+}
+
+// create generates the key for a new epoch. It never replaces an existing key.
+func (k *groupKeyring) create(conversationID string, epoch uint64) (*[32]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := k.put(conversationID, epoch, key); err != nil {
+		return nil, err
+	}
+	gk, _ := k.lookup(conversationID, epoch)
+	return gk, nil
+}
+
+// lookup returns the key for (conversation, epoch) without generating one.
+func (k *groupKeyring) lookup(conversationID string, epoch uint64) (*[32]byte, bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	key, ok := k.keys[conversationID][epoch]
+	return key, ok
+}
+
+// put installs a key (created locally or received via a control envelope).
+// An existing key for the epoch is never replaced.
+func (k *groupKeyring) put(conversationID string, epoch uint64, key []byte) error {
+	if len(key) != 32 {
+		return errors.New("group key must be 32 bytes")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.keys[conversationID][epoch]; ok {
+		return ErrGroupKeyExists
+	}
+	k.setLocked(conversationID, epoch, key)
+	if err := k.saveLocked(); err != nil {
+		delete(k.keys[conversationID], epoch)
+		return err
+	}
+	return nil
+}
+
+func (k *groupKeyring) setLocked(conversationID string, epoch uint64, key []byte) {
+	byEpoch := k.keys[conversationID]
+	if byEpoch == nil {
+		byEpoch = make(map[uint64]*[32]byte)
+		k.keys[conversationID] = byEpoch
+	}
+	var kk [32]byte
+	copy(kk[:], key)
+	byEpoch[epoch] = &kk
+}
+
+// saveLocked writes every key to the attached file. Caller holds k.mu.
+func (k *groupKeyring) saveLocked() error {
+	if k.file == nil {
+		return nil
+	}
+	out := make(map[string]map[uint64][]byte, len(k.keys))
+	for convID, byEpoch := range k.keys {
+		m := make(map[uint64][]byte, len(byEpoch))
+		for epoch, key := range byEpoch {
+			m[epoch] = key[:]
+		}
+		out[convID] = m
+	}
+	return k.file.Save(out)
+}
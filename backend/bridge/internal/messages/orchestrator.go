@@ -1,20 +1,30 @@
 package messages
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
+	"sync"
 	"time"
 
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/contacts"
 	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
 	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
 )
 
 var (
 	ErrUnknownConversation = errors.New("unknown conversation")
+	ErrUnknownEnvelopeType = errors.New("unknown envelope type")
+	ErrGroupOwnerOnly      = errors.New("only the group owner can send to a group")
+	ErrNoMemberIdentity    = errors.New("group member has no identity key")
+	ErrNotGroupSender      = errors.New("sender is not a group member")
+	ErrStaleKeyEpoch       = errors.New("group envelope key epoch is not current")
 )
 
 // Orchestrator coordinates Phase-1 message send/receive.
@@ -29,9 +39,36 @@ type Orchestrator struct {
 	// NOTE: This must not be logged.
 	key [32]byte
 
+	// Group conversations: per-epoch group keys. Process-local; never logged.
+	groupKeys *groupKeyring
+
+	// identities resolves an owner's identity key, which signs message and
+	// control envelopes. Defaults to one process-local key for every owner.
+	// identityOwner maps a public identity key back to its local owner, to
+	// route group control envelopes; the default knows no owners.
+	identities    IdentitySource
+	identityOwner IdentityOwner
+
+	// onPeerKey, if set, sees the sender key of each direct envelope received.
+	onPeerKey PeerKeyObserver
+
+	// Typed envelopes owned by other subsystems (e.g. invite handshakes).
+	handlersMu sync.RWMutex
+	handlers   map[string]transport.ReceiveHandler
+
 	maxEnvelopeBytes int
 }
 
+// IdentitySource returns ownerSubject's long-term ed25519 identity key.
+type IdentitySource func(ownerSubject string) (ed25519.PrivateKey, error)
+
+// IdentityOwner returns the local subject whose identity key is pub.
+type IdentityOwner func(pub []byte) (ownerSubject string, ok bool)
+
+// PeerKeyObserver is told the (verified) sender identity key of an envelope
+// received in ownerSubject's direct conversation with peerFingerprint.
+type PeerKeyObserver func(ctx context.Context, ownerSubject, peerFingerprint string, senderKey []byte)
+
 func NewOrchestrator(convRepo *conversations.Repo, store *Store, tx transport.Adapter, maxEnvelopeBytes int) (*Orchestrator, error) {
 	if convRepo == nil || store == nil || tx == nil {
 		return nil, errors.New("convRepo, store, tx required")
@@ -43,15 +80,62 @@ func NewOrchestrator(convRepo *conversations.Repo, store *Store, tx transport.Ad
 		convRepo:         convRepo,
 		store:            store,
 		tx:               tx,
+		groupKeys:        newGroupKeyring(),
+		handlers:         make(map[string]transport.ReceiveHandler),
 		maxEnvelopeBytes: maxEnvelopeBytes,
 	}
 	// Build-only key (random per process). Do not persist.
 	_, _ = rand.Read(o.key[:])
+	_, signKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	o.identities = func(string) (ed25519.PrivateKey, error) { return signKey, nil }
+	o.identityOwner = func([]byte) (string, bool) { return "", false }
 	return o, nil
 }
 
+// SetIdentities replaces the process-local signer with per-owner persistent
+// identity keys, so envelopes stay verifiable across restarts and identify
+// the user rather than the bridge. owner is src's reverse lookup. Call before
+// serving.
+func (o *Orchestrator) SetIdentities(src IdentitySource, owner IdentityOwner) error {
+	if src == nil || owner == nil {
+		return errors.New("identity source and owner lookup required")
+	}
+	o.identities = src
+	o.identityOwner = owner
+	return nil
+}
+
+// PersistGroupKeys keeps group epoch keys in a file sealed with key (the
+// contact book key), loading any saved there. Without it keys are lost on
+// restart, and with them every group's history. Call before serving.
+func (o *Orchestrator) PersistGroupKeys(path string, key []byte) error {
+	f, err := store.NewSealedFile(path, key, "privxx-bridge/group-keys")
+	if err != nil {
+		return err
+	}
+	return o.groupKeys.attach(f)
+}
+
+// ObservePeerKeys installs f to see sender keys on received direct envelopes
+// (identity key-change detection). Call before serving.
+func (o *Orchestrator) ObservePeerKeys(f PeerKeyObserver) {
+	o.onPeerKey = f
+}
+
+// HandleEnvelopeType routes received envelopes whose "type" is typ to h.
+// Replacement semantics: a later registration for the same type wins.
+func (o *Orchestrator) HandleEnvelopeType(typ string, h transport.ReceiveHandler) {
+	o.handlersMu.Lock()
+	defer o.handlersMu.Unlock()
+	o.handlers[typ] = h
+}
+
 // SendText stores ciphertext and attempts transport inject.
-// Returns user-facing state: "Sent" on success path (even if delivery is delayed by mixnet).
+// Returns the stored item's envelope fingerprint, the ID the sender acks and
+// looks the message up by (delivery may still be delayed by the mixnet).
 func (o *Orchestrator) SendText(ctx context.Context, ownerSubject string, conversationID string, plaintext []byte) (string, error) {
 	if ownerSubject == "" {
 		return "", errors.New("ownerSubject required")
@@ -71,6 +155,9 @@ func (o *Orchestrator) SendText(ctx context.Context, ownerSubject string, conver
 	if conv.OwnerSubject != ownerSubject {
 		return "", ErrUnknownConversation
 	}
+	if conv.IsGroup() {
+		return o.sendGroupText(ctx, conv, plaintext)
+	}
 
 	// 2) Encrypt plaintext (transient only)
 	ciphertext, err := encryptBuild(&o.key, plaintext)
@@ -78,13 +165,20 @@ func (o *Orchestrator) SendText(ctx context.Context, ownerSubject string, conver
 		return "", err
 	}
 
-	// 3) Build envelope (ciphertext only)
+	// 3) Build envelope (ciphertext only), signed by the owner's identity
 	env := &EnvelopeV1{
 		V:              1,
 		ConversationID: conversationID,
 		Ciphertext:     ciphertext,
 		CreatedAtUnix:  nowUnix(),
 	}
+	identity, err := o.identities(ownerSubject)
+	if err != nil {
+		return "", err
+	}
+	if err := env.Sign(identity); err != nil {
+		return "", err
+	}
 
 	// 4) Encode envelope + enforce max size
 	encoded, err := EncodeEnvelope(env)
@@ -98,7 +192,7 @@ func (o *Orchestrator) SendText(ctx context.Context, ownerSubject string, conver
 	// 5) Persist ciphertext only (as base64 string)
 	b64 := base64.StdEncoding.EncodeToString(encoded)
 	fp := hashEnvelope(encoded)
-	_, err = o.store.PutAvailable(ownerSubject, conversationID, b64, &fp)
+	id, err := o.store.PutAvailable(ownerSubject, conversationID, b64, &fp)
 	if err != nil {
 		return "", err
 	}
@@ -108,22 +202,38 @@ func (o *Orchestrator) SendText(ctx context.Context, ownerSubject string, conver
 		return "", err
 	}
 
-	return "Sent", nil
+	return id, nil
 }
 
 // OnReceiveEnvelope handles a received envelope (ciphertext bytes).
 // It may decrypt transiently ONLY to route; it MUST persist ciphertext only.
 func (o *Orchestrator) OnReceiveEnvelope(ctx context.Context, envelopeCiphertext []byte) error {
-	_ = ctx
 	if len(envelopeCiphertext) == 0 {
 		return errors.New("empty envelope")
 	}
+	switch typ := envelopeType(envelopeCiphertext); typ {
+	case "":
+		// Message envelope (handled below).
+	case controlEnvelopeType:
+		return o.onReceiveControl(envelopeCiphertext)
+	default:
+		o.handlersMu.RLock()
+		h := o.handlers[typ]
+		o.handlersMu.RUnlock()
+		if h == nil {
+			return ErrUnknownEnvelopeType
+		}
+		return h(ctx, envelopeCiphertext)
+	}
 
-	// 1) Decode + validate envelope
+	// 1) Decode + validate envelope; the sender must hold the key it names
 	env, err := DecodeEnvelope(envelopeCiphertext)
 	if err != nil {
 		return err
 	}
+	if err := env.VerifySender(); err != nil {
+		return err
+	}
 
 	// 2) Load conversation (must exist)
 	conv, err := o.convRepo.GetConversation(env.ConversationID)
@@ -133,6 +243,23 @@ func (o *Orchestrator) OnReceiveEnvelope(ctx context.Context, envelopeCiphertext
 	if conv.OwnerSubject == "" {
 		return errors.New("conversation missing owner")
 	}
+	peerFingerprint := conv.PeerFingerprint
+	if conv.IsGroup() {
+		// Only current members (or the owner who signs for the group) may
+		// post, and only under the current epoch: a removed member still
+		// holds the old key, so older epochs are refused.
+		fp, ok := groupSender(conv, env.SenderIdentityKey)
+		if !ok {
+			return ErrNotGroupSender
+		}
+		if env.KeyEpoch != conv.KeyEpoch {
+			return ErrStaleKeyEpoch
+		}
+		peerFingerprint = fp
+	}
+	if o.onPeerKey != nil {
+		o.onPeerKey(ctx, conv.OwnerSubject, peerFingerprint, env.SenderIdentityKey)
+	}
 
 	// 3) Persist ciphertext-only (base64 of encoded envelope)
 	b64 := base64.StdEncoding.EncodeToString(envelopeCiphertext)
@@ -145,6 +272,44 @@ func (o *Orchestrator) OnReceiveEnvelope(ctx context.Context, envelopeCiphertext
 	return nil
 }
 
+// groupSender returns the recorded fingerprint of the group member (or group
+// signer) holding senderKey.
+func groupSender(conv *conversations.Conversation, senderKey []byte) (string, bool) {
+	fp := contacts.Fingerprint(senderKey)
+	if bytes.Equal(conv.SignerPublicKey, senderKey) {
+		return fp, true
+	}
+	for _, m := range conv.Members {
+		if bytes.Equal(m.IdentityPublicKey, senderKey) || (m.Fingerprint != "" && m.Fingerprint == fp) {
+			return m.Fingerprint, true
+		}
+	}
+	return "", false
+}
+
+// OpenItem decrypts a stored item transiently and returns its plaintext.
+// Used only by legacy adapters whose response shapes carry message bodies;
+// the plaintext must not be persisted or logged.
+func (o *Orchestrator) OpenItem(it Item) ([]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(it.PayloadCiphertextB64)
+	if err != nil {
+		return nil, err
+	}
+	env, err := DecodeEnvelope(raw)
+	if err != nil {
+		return nil, err
+	}
+	key := &o.key
+	if env.KeyEpoch > 0 {
+		gk, ok := o.groupKeys.lookup(env.ConversationID, env.KeyEpoch)
+		if !ok {
+			return nil, ErrGroupKeyUnavailable
+		}
+		key = gk
+	}
+	return decryptBuild(key, env.Ciphertext)
+}
+
 // hashEnvelope is optional internal fingerprinting for future dedupe.
 func hashEnvelope(b []byte) string {
 	h := sha256.Sum256(b)
@@ -0,0 +1,275 @@
+package messages
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
+)
+
+// CreateGroup creates a group conversation and distributes the epoch-1 key
+// to every member via control envelopes signed by the owner's identity key.
+func (o *Orchestrator) CreateGroup(ctx context.Context, ownerSubject string, members []conversations.Member) (*conversations.Conversation, error) {
+	identity, err := o.identities(ownerSubject)
+	if err != nil {
+		return nil, err
+	}
+	signer := identity.Public().(ed25519.PublicKey)
+	conv, err := o.convRepo.CreateGroup(ownerSubject, members, signer)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := o.groupKeys.create(conv.ConversationID, conv.KeyEpoch); err != nil {
+		return conv, err
+	}
+	if err := o.broadcastControl(ctx, conv, ControlOpCreate, conv.Members, nil); err != nil {
+		return conv, err
+	}
+	return conv, nil
+}
+
+// AddMember adds a member and sends the new membership snapshot (with the
+// current epoch key) to every member, including the one just added.
+func (o *Orchestrator) AddMember(ctx context.Context, ownerSubject, conversationID string, m conversations.Member) (*conversations.Conversation, error) {
+	conv, err := o.convRepo.AddMember(ownerSubject, conversationID, m)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.broadcastControl(ctx, conv, ControlOpMemberAdd, conv.Members, nil); err != nil {
+		return conv, err
+	}
+	return conv, nil
+}
+
+// RemoveMember removes a member and rekeys the group.
+// Remaining members receive the new epoch key; the removed member only
+// receives the membership snapshot so its client can stop displaying the group.
+func (o *Orchestrator) RemoveMember(ctx context.Context, ownerSubject, conversationID, memberID string) (*conversations.Conversation, error) {
+	conv, removed, err := o.convRepo.RemoveMember(ownerSubject, conversationID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := o.groupKeys.create(conv.ConversationID, conv.KeyEpoch); err != nil {
+		return conv, err
+	}
+	if err := o.broadcastControl(ctx, conv, ControlOpMemberRemove, conv.Members, removed); err != nil {
+		return conv, err
+	}
+	return conv, nil
+}
+
+// sendGroupText seals plaintext once under the current group key and fans the
+// envelope out to each member. Ciphertext is persisted once for the owner.
+func (o *Orchestrator) sendGroupText(ctx context.Context, conv *conversations.Conversation, plaintext []byte) (string, error) {
+	groupKey, ok := o.groupKeys.lookup(conv.ConversationID, conv.KeyEpoch)
+	if !ok {
+		return "", ErrGroupKeyUnavailable
+	}
+	ciphertext, err := encryptBuild(groupKey, plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	identity, err := o.identities(conv.OwnerSubject)
+	if err != nil {
+		return "", err
+	}
+	// Joined groups hold the membership projection only (no transport
+	// references), so only the owner can fan out.
+	if !bytes.Equal(identity.Public().(ed25519.PublicKey), conv.SignerPublicKey) {
+		return "", ErrGroupOwnerOnly
+	}
+	base := &EnvelopeV1{
+		V:              1,
+		ConversationID: conv.ConversationID,
+		Ciphertext:     ciphertext,
+		CreatedAtUnix:  nowUnix(),
+		KeyEpoch:       conv.KeyEpoch,
+	}
+	if err := base.Sign(identity); err != nil {
+		return "", err
+	}
+	stored, err := EncodeEnvelope(base)
+	if err != nil {
+		return "", err
+	}
+	if len(stored) > o.maxEnvelopeBytes {
+		return "", transport.ErrEnvelopeTooLarge
+	}
+
+	b64 := base64.StdEncoding.EncodeToString(stored)
+	fp := hashEnvelope(stored)
+	id, err := o.store.PutAvailable(conv.OwnerSubject, conv.ConversationID, b64, &fp)
+	if err != nil {
+		return "", err
+	}
+
+	err = o.fanOut(ctx, conv.Members, func(m conversations.Member) ([]byte, error) {
+		env := *base
+		env.RecipientRefEncrypted = m.RefEncrypted
+		if err := env.Sign(identity); err != nil {
+			return nil, err
+		}
+		return EncodeEnvelope(&env)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// broadcastControl signs and sends one control envelope per recipient.
+// Current members get the current epoch key wrapped for them; removed (if any)
+// gets the snapshot without a key.
+func (o *Orchestrator) broadcastControl(ctx context.Context, conv *conversations.Conversation, op string, recipients []conversations.Member, removed *conversations.Member) error {
+	groupKey, ok := o.groupKeys.lookup(conv.ConversationID, conv.KeyEpoch)
+	if !ok {
+		return ErrGroupKeyUnavailable
+	}
+	identity, err := o.identities(conv.OwnerSubject)
+	if err != nil {
+		return err
+	}
+
+	members := projectMembers(conv.Members)
+	build := func(m conversations.Member, withKey bool) ([]byte, error) {
+		if len(m.IdentityPublicKey) == 0 {
+			return nil, ErrNoMemberIdentity
+		}
+		env := &ControlEnvelopeV1{
+			V:                     1,
+			Type:                  controlEnvelopeType,
+			Op:                    op,
+			ConversationID:        conv.ConversationID,
+			MembershipVersion:     conv.MembershipVersion,
+			KeyEpoch:              conv.KeyEpoch,
+			Members:               members,
+			SignerPublicKey:       conv.SignerPublicKey,
+			RecipientRefEncrypted: m.RefEncrypted,
+			RecipientIdentityKey:  m.IdentityPublicKey,
+			CreatedAtUnix:         nowUnix(),
+		}
+		if withKey {
+			wrapped, err := sealTo(m.IdentityPublicKey, groupKey[:])
+			if err != nil {
+				return nil, err
+			}
+			env.WrappedKey = wrapped
+		}
+		if err := env.Sign(identity); err != nil {
+			return nil, err
+		}
+		return EncodeControlEnvelope(env)
+	}
+
+	err = o.fanOut(ctx, recipients, func(m conversations.Member) ([]byte, error) {
+		return build(m, true)
+	})
+	if removed != nil {
+		encoded, berr := build(*removed, false)
+		if berr == nil {
+			berr = o.sendOne(ctx, encoded)
+		}
+		if err == nil {
+			err = berr
+		}
+	}
+	return err
+}
+
+// fanOut encodes and sends one envelope per member.
+// Every member is attempted; the returned error reports how many sends failed.
+func (o *Orchestrator) fanOut(ctx context.Context, members []conversations.Member, encode func(conversations.Member) ([]byte, error)) error {
+	failed := 0
+	var firstErr error
+	for _, m := range members {
+		encoded, err := encode(m)
+		if err == nil {
+			err = o.sendOne(ctx, encoded)
+		}
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("fan-out: %d of %d sends failed: %w", failed, len(members), firstErr)
+	}
+	return nil
+}
+
+func (o *Orchestrator) sendOne(ctx context.Context, encoded []byte) error {
+	if len(encoded) > o.maxEnvelopeBytes {
+		return transport.ErrEnvelopeTooLarge
+	}
+	return o.tx.Send(ctx, encoded)
+}
+
+// onReceiveControl verifies a membership control envelope and applies the
+// snapshot if it is newer than local state. A group_create for an unknown
+// group creates the recipient's record, trusting the signer key it carries;
+// anything else is verified against the signer recorded for the group.
+func (o *Orchestrator) onReceiveControl(b []byte) error {
+	env, err := DecodeControlEnvelope(b)
+	if err != nil {
+		return err
+	}
+	ownerSubject, ok := o.identityOwner(env.RecipientIdentityKey)
+	if !ok {
+		return ErrUnknownConversation
+	}
+
+	conv, err := o.convRepo.GetConversation(env.ConversationID)
+	switch {
+	case err == nil:
+		if conv.OwnerSubject != ownerSubject {
+			return ErrUnknownConversation
+		}
+		if !conv.IsGroup() {
+			return conversations.ErrNotGroup
+		}
+		if err := env.Verify(conv.SignerPublicKey); err != nil {
+			return err
+		}
+	case env.Op == ControlOpCreate:
+		if err := env.Verify(env.SignerPublicKey); err != nil {
+			return err
+		}
+		conv, err = o.convRepo.JoinGroup(ownerSubject, env.ConversationID, env.SignerPublicKey,
+			env.MembershipVersion, env.KeyEpoch, receivedMembers(env.Members))
+		if err != nil {
+			return err
+		}
+	default:
+		return ErrUnknownConversation
+	}
+
+	if len(env.WrappedKey) > 0 {
+		identity, err := o.identities(ownerSubject)
+		if err != nil {
+			return err
+		}
+		key, err := openSealed(identity, env.WrappedKey)
+		if err != nil {
+			return err
+		}
+		if err := o.groupKeys.put(env.ConversationID, env.KeyEpoch, key); err != nil && !errors.Is(err, ErrGroupKeyExists) {
+			return err
+		}
+	}
+
+	_, err = o.convRepo.ApplyMembership(env.ConversationID, env.MembershipVersion, env.KeyEpoch, receivedMembers(env.Members))
+	if errors.Is(err, conversations.ErrStaleMembership) {
+		// Already converged (duplicate, out-of-order delivery, or the
+		// snapshot that just created the record).
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package messages
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+
+	"filippo.io/edwards25519"
+	"golang.org/x/crypto/curve25519"
+)
+
+// Group keys are sealed to each recipient's ed25519 identity key: the key is
+// mapped to its X25519 form, an ephemeral X25519 key agrees a shared secret
+// with it, and the wrapped key is ephemeral public key || XChaCha20-Poly1305
+// ciphertext under SHA-256(label || shared || ephemeral || recipient).
+const sealLabel = "privxx-seal-v1"
+
+var errSealedKey = errors.New("sealed key invalid")
+
+// x25519FromEd25519Public maps an identity key to its X25519 form. Only
+// canonical encodings of points outside the small-order subgroup are
+// accepted, so a crafted recipient key cannot force a predictable secret.
+func x25519FromEd25519Public(pub []byte) ([]byte, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, errSealedKey
+	}
+	p, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil || !bytes.Equal(p.Bytes(), pub) {
+		return nil, errSealedKey
+	}
+	if new(edwards25519.Point).MultByCofactor(p).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, errSealedKey
+	}
+	return p.BytesMontgomery(), nil
+}
+
+// x25519FromEd25519Private is the X25519 scalar matching the identity key:
+// the clamped low half of SHA-512(seed), as in RFC 8032.
+func x25519FromEd25519Private(priv ed25519.PrivateKey) []byte {
+	h := sha512.Sum512(priv.Seed())
+	s := h[:32]
+	s[0] &= 248
+	s[31] &= 127
+	s[31] |= 64
+	return s
+}
+
+func sealKey(shared, ephemeral, recipient []byte) *[32]byte {
+	h := sha256.New()
+	h.Write([]byte(sealLabel))
+	h.Write(shared)
+	h.Write(ephemeral)
+	h.Write(recipient)
+	var k [32]byte
+	copy(k[:], h.Sum(nil))
+	return &k
+}
+
+// sealTo encrypts plaintext so only the holder of recipient's identity key can open it.
+func sealTo(recipient []byte, plaintext []byte) ([]byte, error) {
+	rx, err := x25519FromEd25519Public(recipient)
+	if err != nil {
+		return nil, err
+	}
+	eph := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(eph); err != nil {
+		return nil, err
+	}
+	ephPub, err := curve25519.X25519(eph, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(eph, rx)
+	if err != nil {
+		return nil, err
+	}
+	ct, err := encryptBuild(sealKey(shared, ephPub, rx), plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return append(ephPub, ct...), nil
+}
+
+// openSealed reverses sealTo with the recipient's identity key.
+func openSealed(priv ed25519.PrivateKey, sealed []byte) ([]byte, error) {
+	if len(sealed) <= curve25519.PointSize {
+		return nil, errSealedKey
+	}
+	scalar := x25519FromEd25519Private(priv)
+	rx, err := curve25519.X25519(scalar, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	ephPub := sealed[:curve25519.PointSize]
+	shared, err := curve25519.X25519(scalar, ephPub)
+	if err != nil {
+		return nil, errSealedKey
+	}
+	return decryptBuild(sealKey(shared, ephPub, rx), sealed[curve25519.PointSize:])
+}
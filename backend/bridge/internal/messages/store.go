@@ -11,9 +11,14 @@ import (
 	"sort"
 	"sync"
 	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound = errors.New("not found")
+	ErrClosed   = errors.New("message store closed")
+)
 
 // Item is the Phase-1 canonical stored message item (ciphertext only).
 type Item struct {
@@ -48,6 +53,8 @@ type Store struct {
 	dir       string
 	logPath   string
 	indexPath string
+	onSync    func(time.Duration) // observes each append's fsync; may be nil
+	closed    bool
 }
 
 func NewStore(dir string) (*Store, error) {
@@ -105,18 +112,17 @@ func (s *Store) readIndex() (*Index, error) {
 }
 
 func (s *Store) writeIndex(idx *Index) error {
-	tmp := s.indexPath + ".tmp"
 	b, err := json.Marshal(idx)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(tmp, b, 0o640); err != nil {
-		return err
-	}
-	return os.Rename(tmp, s.indexPath)
+	return store.WriteFileAtomic(s.indexPath, b, 0o640)
 }
 
 func (s *Store) appendRecord(it *Item) (int64, error) {
+	if s.closed {
+		return 0, ErrClosed
+	}
 	fh, err := os.OpenFile(s.logPath, os.O_WRONLY|os.O_APPEND, 0o640)
 	if err != nil {
 		return 0, err
@@ -135,9 +141,64 @@ func (s *Store) appendRecord(it *Item) (int64, error) {
 	if err := w.Flush(); err != nil {
 		return 0, err
 	}
+	start := time.Now()
+	if err := fh.Sync(); err != nil {
+		return 0, err
+	}
+	if s.onSync != nil {
+		s.onSync(time.Since(start))
+	}
 	return off, nil
 }
 
+// Close waits for any write in progress, syncs the directory and makes
+// later writes fail with ErrClosed. Every append is already fsynced, so
+// nothing acknowledged is lost after Close returns.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return store.SyncDir(s.dir)
+}
+
+// ObserveSync calls f with the duration of every log fsync.
+func (s *Store) ObserveSync(f func(time.Duration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onSync = f
+}
+
+// Stats are the store's on-disk sizes and message count.
+type Stats struct {
+	LogBytes   int64
+	IndexBytes int64
+	Messages   int
+}
+
+func (s *Store) Stats() (Stats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var st Stats
+	fi, err := os.Stat(s.logPath)
+	if err != nil {
+		return st, err
+	}
+	st.LogBytes = fi.Size()
+	if fi, err = os.Stat(s.indexPath); err != nil {
+		return st, err
+	}
+	st.IndexBytes = fi.Size()
+	idx, err := s.readIndex()
+	if err != nil {
+		return st, err
+	}
+	st.Messages = len(idx.Fingerprint)
+	return st, nil
+}
+
 func (s *Store) readRecordAt(offset int64, dst any) error {
 	fh, err := os.Open(s.logPath)
 	if err != nil {
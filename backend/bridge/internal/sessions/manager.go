@@ -1,36 +1,58 @@
 package sessions
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 )
 
 var (
-	ErrSessionNotFound  = errors.New("session not found")
-	ErrSessionExpired   = errors.New("session expired")
-	ErrSessionRevoked   = errors.New("session revoked")
-	ErrSessionForbidden = errors.New("session forbidden for caller")
-	ErrInvalidPurpose   = errors.New("invalid session purpose")
+	ErrSessionNotFound   = errors.New("session not found")
+	ErrSessionExpired    = errors.New("session expired")
+	ErrSessionRevoked    = errors.New("session revoked")
+	ErrSessionForbidden  = errors.New("session forbidden for caller")
+	ErrInvalidPurpose    = errors.New("invalid session purpose")
+	ErrSessionMaxAge     = errors.New("session reached its maximum lifetime")
+	ErrPurposeRestricted = errors.New("purpose not available to caller")
 )
 
 // Manager owns all active Phase-1 sessions.
 // Phase-1 storage: in-memory with TTL (replaceable later).
+// Refresh slides a session's expiry by ttl, never past IssuedAt + maxLifetime.
 type Manager struct {
-	mu       sync.RWMutex
-	sessions map[string]*Session
-	ttl      time.Duration
+	mu          sync.RWMutex
+	sessions    map[string]*Session
+	ttl         time.Duration
+	maxLifetime time.Duration
+	seenProofs  map[string]int64 // sessionID|jti -> unix time after which the entry can go
+	started     time.Time        // proofs issued earlier are refused: seenProofs starts empty
 }
 
+// NewManager uses a default maximum lifetime of 4×ttl.
 func NewManager(ttl time.Duration) *Manager {
 	return &Manager{
-		sessions: make(map[string]*Session),
-		ttl:      ttl,
+		sessions:    make(map[string]*Session),
+		seenProofs:  make(map[string]int64),
+		started:     time.Now().UTC(),
+		ttl:         ttl,
+		maxLifetime: 4 * ttl,
 	}
 }
 
+// SetMaxLifetime sets the absolute cap on refreshed sessions (>= ttl).
+func (m *Manager) SetMaxLifetime(d time.Duration) {
+	if d < m.ttl {
+		d = m.ttl
+	}
+	m.mu.Lock()
+	m.maxLifetime = d
+	m.mu.Unlock()
+}
+
 // Issue creates a new session bound to authSubject.
 // conversationID may be nil ONLY for inbox-scoped message_receive.
 func (m *Manager) Issue(
@@ -38,21 +60,42 @@ func (m *Manager) Issue(
 	purpose Purpose,
 	conversationID *string,
 ) (*Session, error) {
+	return m.IssueScoped(authSubject, purpose, IssueScope{ConversationID: conversationID})
+}
 
+// IssueScope carries everything a session can be bound to besides its subject.
+type IssueScope struct {
+	ConversationID *string
+	Domain         string        // ScopeDomain purposes
+	TTL            time.Duration // 0 = Manager default; never above the purpose's MaxTTL
+	ProofKey       *ProofKey     // non-nil = requests need a proof (see VerifyProof)
+	Privileged     bool          // caller may hold Restricted purposes
+}
+
+// IssueScoped creates a session after checking sc against the purpose's rule
+// (see purposeRules).
+func (m *Manager) IssueScoped(authSubject string, purpose Purpose, sc IssueScope) (*Session, error) {
 	if authSubject == "" {
 		return nil, fmt.Errorf("authSubject required")
 	}
 
-	switch purpose {
-	case PurposeMessageSend:
-		if conversationID == nil {
-			return nil, fmt.Errorf("message_send requires conversation_id")
-		}
-	case PurposeMessageReceive:
-		// conversationID optional (nil = inbox-scoped)
-	default:
+	rule, ok := LookupPurpose(purpose)
+	if !ok {
 		return nil, ErrInvalidPurpose
 	}
+	if rule.Restricted && !sc.Privileged {
+		return nil, ErrPurposeRestricted
+	}
+	domain, err := rule.check(sc)
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.ProofKey != nil {
+		if _, err := sc.ProofKey.PublicKey(); err != nil {
+			return nil, err
+		}
+	}
 
 	sid, err := newOpaqueID("sess")
 	if err != nil {
@@ -64,16 +107,21 @@ func (m *Manager) Issue(
 		SessionID:      sid,
 		AuthSubject:    authSubject,
 		Purpose:        purpose,
-		ConversationID: conversationID,
-		ExpiresAtUnix:  now.Add(m.ttl).Unix(),
+		ConversationID: sc.ConversationID,
+		Domain:         domain,
+		IssuedAtUnix:   now.Unix(),
+		ExpiresAtUnix:  now.Add(rule.ttl(sc.TTL, m.ttl)).Unix(),
 		Revoked:        false,
+		MaxUses:        rule.MaxUses,
+		ProofKey:       sc.ProofKey,
 	}
 
+	cp := *s
 	m.mu.Lock()
 	m.sessions[sid] = s
 	m.mu.Unlock()
 
-	return s, nil
+	return &cp, nil
 }
 
 // Validate verifies:
@@ -82,6 +130,9 @@ func (m *Manager) Issue(
 // 3) not revoked
 // 4) bound to caller authSubject
 // 5) purpose matches expected
+//
+// It returns a copy taken under the lock; later uses, refreshes and revokes
+// do not show through it.
 func (m *Manager) Validate(
 	sessionID string,
 	authSubject string,
@@ -89,9 +140,9 @@ func (m *Manager) Validate(
 ) (*Session, error) {
 
 	m.mu.RLock()
-	s, ok := m.sessions[sessionID]
-	m.mu.RUnlock()
+	defer m.mu.RUnlock()
 
+	s, ok := m.sessions[sessionID]
 	if !ok {
 		return nil, ErrSessionNotFound
 	}
@@ -112,7 +163,40 @@ func (m *Manager) Validate(
 		return nil, ErrInvalidPurpose
 	}
 
-	return s, nil
+	if s.MaxUses > 0 && s.Uses >= s.MaxUses {
+		return nil, ErrSessionUsed
+	}
+
+	cp := *s
+	return &cp, nil
+}
+
+// Consume records one use of a validated session. Call it once all other
+// checks passed. For sessions with MaxUses it fails with ErrSessionUsed when
+// the limit is already reached, so concurrent requests cannot overspend it;
+// the use that reaches the limit revokes the session.
+func (m *Manager) Consume(sessionID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return ErrSessionNotFound
+	}
+	if s.Revoked {
+		return ErrSessionRevoked
+	}
+	if s.MaxUses == 0 {
+		return nil
+	}
+	if s.Uses >= s.MaxUses {
+		return ErrSessionUsed
+	}
+	s.Uses++
+	if s.Uses >= s.MaxUses {
+		s.Revoked = true
+	}
+	return nil
 }
 
 // Revoke invalidates a session immediately.
@@ -133,7 +217,110 @@ func (m *Manager) Revoke(sessionID string, authSubject string) error {
 	return nil
 }
 
-// Cleanup removes expired sessions.
+// Refresh extends a live session to now+ttl, capped at IssuedAt+maxLifetime
+// (or the purpose's MaxTTL, if shorter).
+// Expired or revoked sessions cannot be refreshed; issue a new one instead.
+func (m *Manager) Refresh(sessionID string, authSubject string) (*Session, error) {
+	now := time.Now().UTC()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	if s.AuthSubject != authSubject {
+		return nil, ErrSessionForbidden
+	}
+	if s.Revoked {
+		return nil, ErrSessionRevoked
+	}
+	if now.Unix() > s.ExpiresAtUnix {
+		return nil, ErrSessionExpired
+	}
+
+	issued := s.IssuedAtUnix
+	if issued == 0 {
+		// Sessions restored from before IssuedAt existed.
+		issued = s.ExpiresAtUnix - int64(m.ttl/time.Second)
+	}
+	// A purpose's MaxTTL bounds the whole lifetime, not just one slide.
+	rule, _ := LookupPurpose(s.Purpose)
+	lifetime := m.maxLifetime
+	if rule.MaxTTL > 0 && rule.MaxTTL < lifetime {
+		lifetime = rule.MaxTTL
+	}
+	limit := issued + int64(lifetime/time.Second)
+	if s.ExpiresAtUnix >= limit {
+		return nil, ErrSessionMaxAge
+	}
+	exp := now.Add(rule.ttl(0, m.ttl)).Unix()
+	if exp > limit {
+		exp = limit
+	}
+	if exp > s.ExpiresAtUnix {
+		s.ExpiresAtUnix = exp
+	}
+	cp := *s
+	return &cp, nil
+}
+
+// List returns copies of authSubject's live sessions, oldest first.
+func (m *Manager) List(authSubject string) []Session {
+	now := time.Now().UTC().Unix()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var out []Session
+	for _, s := range m.sessions {
+		if s.AuthSubject != authSubject || s.Revoked || now > s.ExpiresAtUnix {
+			continue
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].IssuedAtUnix != out[j].IssuedAtUnix {
+			return out[i].IssuedAtUnix < out[j].IssuedAtUnix
+		}
+		return out[i].SessionID < out[j].SessionID
+	})
+	return out
+}
+
+// RevokeAll revokes every session bound to authSubject and returns the count.
+func (m *Manager) RevokeAll(authSubject string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, s := range m.sessions {
+		if s.AuthSubject == authSubject && !s.Revoked {
+			s.Revoked = true
+			n++
+		}
+	}
+	return n
+}
+
+// StartCleanup runs Cleanup every interval until ctx is done.
+func (m *Manager) StartCleanup(ctx context.Context, every time.Duration) {
+	go func() {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.Cleanup()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Cleanup removes expired sessions and proof IDs past their replay window.
 // Safe to call periodically.
 func (m *Manager) Cleanup() {
 	now := time.Now().UTC().Unix()
@@ -146,6 +333,11 @@ func (m *Manager) Cleanup() {
 			delete(m.sessions, id)
 		}
 	}
+	for k, until := range m.seenProofs {
+		if now > until {
+			delete(m.seenProofs, k)
+		}
+	}
 }
 
 // ---- helpers ----
@@ -157,3 +349,41 @@ func newOpaqueID(prefix string) (string, error) {
 	}
 	return fmt.Sprintf("%s_%x", prefix, b[:]), nil
 }
+
+// Snapshot returns copies of all live (unexpired, unrevoked) sessions.
+func (m *Manager) Snapshot() []Session {
+	now := time.Now().UTC().Unix()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]Session, 0, len(m.sessions))
+	for _, s := range m.sessions {
+		if s.Revoked || now > s.ExpiresAtUnix {
+			continue
+		}
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].SessionID < out[j].SessionID })
+	return out
+}
+
+// Restore loads sessions from a snapshot, keeping each one's original expiry.
+// Expired or revoked entries are dropped. Returns the number restored.
+func (m *Manager) Restore(snap []Session) int {
+	now := time.Now().UTC().Unix()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for i := range snap {
+		s := snap[i]
+		if s.SessionID == "" || s.AuthSubject == "" || s.Revoked || now > s.ExpiresAtUnix {
+			continue
+		}
+		m.sessions[s.SessionID] = &s
+		n++
+	}
+	return n
+}
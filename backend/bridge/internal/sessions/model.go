@@ -3,18 +3,28 @@ package sessions
 // Purpose defines the single allowed capability of a session.
 type Purpose string
 
+// Registered purposes; their scope rules live in purpose.go.
 const (
-	PurposeMessageSend    Purpose = "message_send"
-	PurposeMessageReceive Purpose = "message_receive"
+	PurposeMessageSend      Purpose = "message_send"
+	PurposeMessageReceive   Purpose = "message_receive"
+	PurposeBrowseFetch      Purpose = "browse_fetch"
+	PurposeConnect          Purpose = "connect"
+	PurposeAttachmentUpload Purpose = "attachment_upload"
+	PurposeAdminDiagnostics Purpose = "admin_diagnostics"
 )
 
 // Session is a short-lived, purpose-scoped authorization window.
 // Sessions are always bound to an auth_subject (jwt.sub).
 type Session struct {
-	SessionID      string  `json:"session_id"`
-	AuthSubject    string  `json:"auth_subject"`              // jwt.sub (mandatory binding)
-	Purpose        Purpose `json:"purpose"`                   // exactly one purpose
-	ConversationID *string `json:"conversation_id,omitempty"` // nil = inbox-scoped receive
-	ExpiresAtUnix  int64   `json:"expires_at_unix"`
-	Revoked        bool    `json:"revoked"`
+	SessionID      string    `json:"session_id"`
+	AuthSubject    string    `json:"auth_subject"`              // jwt.sub (mandatory binding)
+	Purpose        Purpose   `json:"purpose"`                   // exactly one purpose
+	ConversationID *string   `json:"conversation_id,omitempty"` // nil = inbox-scoped receive
+	Domain         string    `json:"domain,omitempty"`          // ScopeDomain purposes only
+	IssuedAtUnix   int64     `json:"issued_at_unix"`            // caps refresh (Manager max lifetime)
+	ExpiresAtUnix  int64     `json:"expires_at_unix"`
+	Revoked        bool      `json:"revoked"`
+	MaxUses        int       `json:"max_uses,omitempty"` // 0 = unlimited
+	Uses           int       `json:"uses,omitempty"`
+	ProofKey       *ProofKey `json:"proof_key,omitempty"` // nil = bearer session; else requests need a proof (see VerifyProof)
 }
@@ -0,0 +1,124 @@
+package sessions
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"time"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrProofKeyInvalid = errors.New("invalid session proof key")
+	ErrProofRequired   = errors.New("session is key-bound and the request carries no proof")
+	ErrProofInvalid    = errors.New("invalid session proof")
+	ErrProofStale      = errors.New("session proof outside the accepted time window")
+	ErrProofReplayed   = errors.New("session proof already used")
+)
+
+// ProofType is the required "typ" header of a session proof.
+const ProofType = "dpop+jwt"
+
+// ProofWindow bounds how far a proof's iat may be from the server clock.
+// Proof IDs (jti) are remembered for this long, so a proof is usable once.
+// They are kept in memory only, so proofs issued before the process started
+// are also refused: an earlier process may have accepted them.
+const ProofWindow = 60 * time.Second
+
+// ProofKey is the public half of a client's ephemeral P-256 key, as a JWK.
+// A session issued with a ProofKey only accepts requests that carry a proof
+// signed by the matching private key (DPoP-style proof of possession).
+type ProofKey struct {
+	Kty string `json:"kty"` // "EC"
+	Crv string `json:"crv"` // "P-256"
+	X   string `json:"x"`   // base64url, 32 bytes
+	Y   string `json:"y"`   // base64url, 32 bytes
+}
+
+// PublicKey decodes and validates the JWK.
+func (k *ProofKey) PublicKey() (*ecdsa.PublicKey, error) {
+	if k == nil || k.Kty != "EC" || k.Crv != "P-256" {
+		return nil, ErrProofKeyInvalid
+	}
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, ErrProofKeyInvalid
+	}
+	// crypto/ecdh rejects points that are not on the curve.
+	if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+		return nil, ErrProofKeyInvalid
+	}
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+// Thumbprint is the RFC 7638 JWK thumbprint (base64url SHA-256).
+func (k *ProofKey) Thumbprint() string {
+	sum := sha256.Sum256([]byte(`{"crv":"` + k.Crv + `","kty":"` + k.Kty + `","x":"` + k.X + `","y":"` + k.Y + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// ProofClaims are signed by the client for every request on a bound session.
+// Of the registered claims only iat and jti are used.
+type ProofClaims struct {
+	Method   string `json:"htm"` // HTTP method
+	Path     string `json:"htu"` // request path, no query
+	BodyHash string `json:"bh"`  // base64url SHA-256 of the raw request body
+	jwt.RegisteredClaims
+}
+
+// BodyHash returns the proof "bh" value for a request body.
+func BodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyProof checks a proof against a session returned by Validate.
+// Sessions without a ProofKey need no proof. On success the proof's jti is
+// recorded, so replaying the same proof fails with ErrProofReplayed.
+func (m *Manager) VerifyProof(s *Session, proof, method, path, bodyHash string) error {
+	if s.ProofKey == nil {
+		return nil
+	}
+	if proof == "" {
+		return ErrProofRequired
+	}
+	pub, err := s.ProofKey.PublicKey()
+	if err != nil {
+		return ErrProofInvalid
+	}
+
+	var c ProofClaims
+	tok, err := jwt.NewParser(jwt.WithValidMethods([]string{"ES256"})).ParseWithClaims(proof, &c, func(*jwt.Token) (any, error) {
+		return pub, nil
+	})
+	if err != nil || !tok.Valid {
+		return ErrProofInvalid
+	}
+	if typ, _ := tok.Header["typ"].(string); typ != ProofType {
+		return ErrProofInvalid
+	}
+	if c.Method != method || c.Path != path || c.BodyHash != bodyHash ||
+		c.ID == "" || len(c.ID) > 128 || c.IssuedAt == nil {
+		return ErrProofInvalid
+	}
+	now := time.Now().UTC()
+	iat := c.IssuedAt.Time
+	if iat.Before(now.Add(-ProofWindow)) || iat.After(now.Add(ProofWindow)) || iat.Before(m.started) {
+		return ErrProofStale
+	}
+
+	key := s.SessionID + "|" + c.ID
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, seen := m.seenProofs[key]; seen {
+		return ErrProofReplayed
+	}
+	m.seenProofs[key] = iat.Add(ProofWindow).Unix()
+	return nil
+}
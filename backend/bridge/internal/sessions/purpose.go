@@ -0,0 +1,123 @@
+package sessions
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	ErrScopeConversationRequired   = errors.New("purpose requires a conversation_id")
+	ErrScopeConversationNotAllowed = errors.New("purpose does not take a conversation_id")
+	ErrScopeDomainRequired         = errors.New("purpose requires a domain")
+	ErrScopeDomainNotAllowed       = errors.New("purpose does not take a domain")
+	ErrScopeDomainInvalid          = errors.New("invalid domain")
+	ErrSessionUsed                 = errors.New("session use limit reached")
+)
+
+// Scope says which binding a purpose takes besides the auth subject.
+type Scope int
+
+const (
+	ScopeNone                 Scope = iota // no conversation, no domain
+	ScopeConversation                      // conversation_id required
+	ScopeOptionalConversation              // conversation_id optional (nil = inbox)
+	ScopeDomain                            // domain required
+)
+
+// PurposeRule declares how sessions for one purpose are issued and used.
+type PurposeRule struct {
+	Purpose Purpose
+	Scope   Scope
+	// MaxTTL caps both the issued TTL and the refreshed lifetime (0 = Manager defaults).
+	MaxTTL time.Duration
+	// MaxUses ends the session after that many successful uses (0 = unlimited, 1 = one-time).
+	MaxUses int
+	// Restricted purposes are only issued to callers the issuer vouches for.
+	Restricted bool
+}
+
+// purposeRules is the registry. Adding a purpose means adding a rule here.
+var purposeRules = map[Purpose]PurposeRule{
+	PurposeMessageSend:      {Purpose: PurposeMessageSend, Scope: ScopeConversation},
+	PurposeMessageReceive:   {Purpose: PurposeMessageReceive, Scope: ScopeOptionalConversation},
+	PurposeBrowseFetch:      {Purpose: PurposeBrowseFetch, Scope: ScopeDomain, MaxTTL: 5 * time.Minute},
+	PurposeConnect:          {Purpose: PurposeConnect, Scope: ScopeDomain, MaxTTL: 2 * time.Minute, MaxUses: 1},
+	PurposeAttachmentUpload: {Purpose: PurposeAttachmentUpload, Scope: ScopeConversation, MaxTTL: 5 * time.Minute, MaxUses: 1},
+	PurposeAdminDiagnostics: {Purpose: PurposeAdminDiagnostics, Scope: ScopeNone, MaxTTL: time.Minute, MaxUses: 1, Restricted: true},
+}
+
+// LookupPurpose returns the rule for p.
+func LookupPurpose(p Purpose) (PurposeRule, bool) {
+	r, ok := purposeRules[p]
+	return r, ok
+}
+
+// Purposes lists the registered purposes, sorted.
+func Purposes() []Purpose {
+	out := make([]Purpose, 0, len(purposeRules))
+	for p := range purposeRules {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// NormalizeDomain lowercases a hostname and strips a trailing dot. Ports,
+// schemes, paths and IP literals are rejected: a domain scope names a host.
+func NormalizeDomain(d string) (string, error) {
+	d = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")
+	if d == "" || len(d) > 253 || net.ParseIP(d) != nil {
+		return "", ErrScopeDomainInvalid
+	}
+	for _, label := range strings.Split(d, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return "", ErrScopeDomainInvalid
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return "", ErrScopeDomainInvalid
+			}
+		}
+	}
+	return d, nil
+}
+
+// check validates the scope against the rule and returns the normalized domain.
+func (r PurposeRule) check(sc IssueScope) (string, error) {
+	hasConv := sc.ConversationID != nil && strings.TrimSpace(*sc.ConversationID) != ""
+	switch r.Scope {
+	case ScopeConversation:
+		if !hasConv {
+			return "", ErrScopeConversationRequired
+		}
+	case ScopeNone, ScopeDomain:
+		if hasConv {
+			return "", ErrScopeConversationNotAllowed
+		}
+	}
+	if r.Scope != ScopeDomain {
+		if strings.TrimSpace(sc.Domain) != "" {
+			return "", ErrScopeDomainNotAllowed
+		}
+		return "", nil
+	}
+	if strings.TrimSpace(sc.Domain) == "" {
+		return "", ErrScopeDomainRequired
+	}
+	return NormalizeDomain(sc.Domain)
+}
+
+// ttl is the issued TTL: the request (or the Manager default), capped by MaxTTL.
+func (r PurposeRule) ttl(requested, def time.Duration) time.Duration {
+	t := def
+	if requested > 0 && requested < t {
+		t = requested
+	}
+	if r.MaxTTL > 0 && t > r.MaxTTL {
+		t = r.MaxTTL
+	}
+	return t
+}
@@ -89,15 +89,11 @@ func (f *FileKV) readIndex() (*Index, error) {
 }
 
 func (f *FileKV) writeIndex(idx *Index) error {
-	tmp := f.indexPath + ".tmp"
 	b, err := json.Marshal(idx)
 	if err != nil {
 		return err
 	}
-	if err := os.WriteFile(tmp, b, 0o640); err != nil {
-		return err
-	}
-	return os.Rename(tmp, f.indexPath)
+	return WriteFileAtomic(f.indexPath, b, 0o640)
 }
 
 // AppendRecord appends a JSON record to the log and returns its starting byte offset.
@@ -120,6 +116,9 @@ func (f *FileKV) AppendRecord(v any) (int64, error) {
 	if err := w.Flush(); err != nil {
 		return 0, err
 	}
+	if err := fh.Sync(); err != nil {
+		return 0, err
+	}
 	return off, nil
 }
 
@@ -144,7 +143,8 @@ func (f *FileKV) ReadRecordAt(offset int64, dst any) error {
 
 // ---- Conversation-specific helpers ----
 
-// GetConversationIDByFingerprint returns conversation_id for fingerprint.
+// GetConversationIDByFingerprint returns conversation_id for a fingerprint
+// index key (owner-scoped by the conversations repo).
 func (f *FileKV) GetConversationIDByFingerprint(fingerprint string) (string, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -160,7 +160,7 @@ func (f *FileKV) GetConversationIDByFingerprint(fingerprint string) (string, err
 	return id, nil
 }
 
-// PutFingerprintIndex stores fingerprint -> conversation_id.
+// PutFingerprintIndex stores fingerprint index key -> conversation_id.
 func (f *FileKV) PutFingerprintIndex(fingerprint, conversationID string) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
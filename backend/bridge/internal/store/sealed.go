@@ -0,0 +1,75 @@
+package store
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var ErrSealedOpen = errors.New("sealed file cannot be opened with configured key")
+
+// SealedFile is a whole-state JSON snapshot sealed with XChaCha20-Poly1305
+// and atomically replaced on every Save. The label is bound as associated
+// data, so one snapshot cannot be swapped in for another.
+type SealedFile struct {
+	path  string
+	key   []byte
+	label []byte
+}
+
+func NewSealedFile(path string, key []byte, label string) (*SealedFile, error) {
+	if len(key) != chacha20poly1305.KeySize {
+		return nil, fmt.Errorf("sealed file key must be %d bytes", chacha20poly1305.KeySize)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	return &SealedFile{path: path, key: append([]byte(nil), key...), label: []byte(label)}, nil
+}
+
+// Load decodes the snapshot into v. It reports false if no snapshot exists.
+func (f *SealedFile) Load(v any) (bool, error) {
+	b, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && len(b) == 0) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	aead, err := chacha20poly1305.NewX(f.key)
+	if err != nil {
+		return false, err
+	}
+	if len(b) < chacha20poly1305.NonceSizeX {
+		return false, ErrSealedOpen
+	}
+	pt, err := aead.Open(nil, b[:chacha20poly1305.NonceSizeX], b[chacha20poly1305.NonceSizeX:], f.label)
+	if err != nil {
+		return false, ErrSealedOpen
+	}
+	return true, json.Unmarshal(pt, v)
+}
+
+// Save seals v and atomically replaces the snapshot.
+func (f *SealedFile) Save(v any) error {
+	pt, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.NewX(f.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	out := aead.Seal(nonce, nonce, pt, f.label)
+
+	return WriteFileAtomic(f.path, out, 0o600)
+}
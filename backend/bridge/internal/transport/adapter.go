@@ -22,4 +22,9 @@ type Adapter interface {
 
 	// Stop ends receiving.
 	Stop() error
+
+	// ReceptionRef returns the opaque reference peers use to address
+	// envelopes to this bridge (embedded in invite tokens and handshakes).
+	// Empty means the transport has no reception identity.
+	ReceptionRef() []byte
 }
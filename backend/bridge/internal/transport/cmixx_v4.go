@@ -72,6 +72,15 @@ func (a *CmixxV4Adapter) Stop() error {
 	return nil
 }
 
+// ReceptionRef is the marshalled xxDK reception ID of the cMix client.
+func (a *CmixxV4Adapter) ReceptionRef() []byte {
+	rid := a.c.GetStorage().GetReceptionID()
+	if rid == nil {
+		return nil
+	}
+	return rid.Marshal()
+}
+
 func (a *CmixxV4Adapter) Send(ctx context.Context, envelope []byte) error {
 	_ = ctx
 
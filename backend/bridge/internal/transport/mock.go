@@ -2,6 +2,7 @@ package transport
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
 	"sync"
 )
@@ -20,13 +21,21 @@ type MockAdapter struct {
 	maxEnvelopeBytes int
 	handler          ReceiveHandler
 	started          bool
+	receptionRef     []byte
 }
 
 func NewMockAdapter(maxEnvelopeBytes int) *MockAdapter {
 	if maxEnvelopeBytes <= 0 {
 		maxEnvelopeBytes = 4096 // Phase-1 default cap
 	}
-	return &MockAdapter{maxEnvelopeBytes: maxEnvelopeBytes}
+	// Mock reception identity: random per process, like a fresh xxDK identity.
+	ref := make([]byte, 32)
+	_, _ = rand.Read(ref)
+	return &MockAdapter{maxEnvelopeBytes: maxEnvelopeBytes, receptionRef: ref}
+}
+
+func (m *MockAdapter) ReceptionRef() []byte {
+	return append([]byte(nil), m.receptionRef...)
 }
 
 func (m *MockAdapter) Send(ctx context.Context, envelope []byte) error {
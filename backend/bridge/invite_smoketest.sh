@@ -0,0 +1,17 @@
+#!/usr/bin/env bash
+# Invite smoke test: two bridge stacks over an in-memory transport; an
+# accepted invite must give both sides the same conversation and carry a
+# message each way. See cmd/invite-smoke. Builds it unless SMOKE_BIN points
+# at a binary.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+SMOKE="${SMOKE_BIN:-}"
+if [ -z "$SMOKE" ]; then
+  WORK=$(mktemp -d)
+  trap 'rm -rf "$WORK"' EXIT
+  SMOKE="$WORK/invite-smoke"
+  go build -o "$SMOKE" ./cmd/invite-smoke
+fi
+
+"$SMOKE"
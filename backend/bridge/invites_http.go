@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/invites"
+)
+
+/*
+Invite routes:
+- POST /invites/create  (signed, expiring token; text form doubles as QR payload)
+- POST /invites/list
+- POST /invites/revoke  (pending invites only)
+- POST /invites/accept  (records a pending acceptance + sends handshake)
+The inviter's side of the conversation is created when the handshake envelope
+arrives over the transport and the inviter answers with a signed ack; the
+acceptor's side is created only when an accepted ack arrives (see
+invites.Service.OnHandshake and OnHandshakeAck, wired in newPhase1Deps).
+/invites/list reports both the caller's invites and its acceptances.
+*/
+func (s *server) registerInviteRoutes(svc *invites.Service) {
+	type inviteView struct {
+		InviteID       string `json:"inviteId"`
+		State          string `json:"state"`
+		CreatedAtUnix  int64  `json:"createdAtUnix"`
+		ExpiresAtUnix  int64  `json:"expiresAtUnix"`
+		ConversationID string `json:"conversationId,omitempty"`
+	}
+	view := func(inv *invites.Invite, now time.Time) inviteView {
+		return inviteView{
+			InviteID:       inv.InviteID,
+			State:          inv.State(now),
+			CreatedAtUnix:  inv.CreatedAtUnix,
+			ExpiresAtUnix:  inv.ExpiresAtUnix,
+			ConversationID: inv.ConversationID,
+		}
+	}
+
+	// ---- POST /invites/create ----
+	type createReq struct {
+		TTLSeconds int64 `json:"ttlSeconds,omitempty"` // default 24h, capped at 7d
+	}
+	type createResp struct {
+		Invite     inviteView `json:"invite"`
+		Token      string     `json:"token"`
+		ServerTime string     `json:"serverTime"`
+	}
+	s.handle("/invites/create", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req createReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.TTLSeconds < 0 {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_ttlSeconds"})
+			return
+		}
+		inv, token, err := svc.Create(ownerSubject, time.Duration(req.TTLSeconds)*time.Second)
+		if err != nil {
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "invite_create_failed"})
+			return
+		}
+		now := time.Now().UTC()
+		writeJSONP1(w, http.StatusOK, createResp{
+			Invite:     view(inv, now),
+			Token:      token,
+			ServerTime: now.Format(time.RFC3339),
+		})
+	}))
+
+	type acceptanceView struct {
+		InviteID       string `json:"inviteId"`
+		State          string `json:"state"` // pending | accepted | rejected
+		CreatedAtUnix  int64  `json:"createdAtUnix"`
+		ConversationID string `json:"conversationId,omitempty"`
+	}
+	acceptanceOf := func(acc *invites.Acceptance) acceptanceView {
+		return acceptanceView{
+			InviteID:       acc.InviteID,
+			State:          acc.State,
+			CreatedAtUnix:  acc.CreatedAtUnix,
+			ConversationID: acc.ConversationID,
+		}
+	}
+
+	// ---- POST /invites/list ----
+	type listResp struct {
+		Invites     []inviteView     `json:"invites"`
+		Acceptances []acceptanceView `json:"acceptances"`
+		ServerTime  string           `json:"serverTime"`
+	}
+	s.handle("/invites/list", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		now := time.Now().UTC()
+		resp := listResp{Invites: []inviteView{}, Acceptances: []acceptanceView{}, ServerTime: now.Format(time.RFC3339)}
+		for _, inv := range svc.List(ownerSubject) {
+			resp.Invites = append(resp.Invites, view(&inv, now))
+		}
+		for _, acc := range svc.Acceptances(ownerSubject) {
+			resp.Acceptances = append(resp.Acceptances, acceptanceOf(&acc))
+		}
+		writeJSONP1(w, http.StatusOK, resp)
+	}))
+
+	// ---- POST /invites/revoke ----
+	type revokeReq struct {
+		InviteID string `json:"inviteId"`
+	}
+	s.handle("/invites/revoke", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req revokeReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		inv, err := svc.Revoke(ownerSubject, req.InviteID)
+		if err != nil {
+			writeInviteError(w, "invite_revoke_failed", err)
+			return
+		}
+		now := time.Now().UTC()
+		writeJSONP1(w, http.StatusOK, map[string]any{"invite": view(inv, now), "serverTime": now.Format(time.RFC3339)})
+	}))
+
+	// ---- POST /invites/accept ----
+	type acceptReq struct {
+		Token string `json:"token"`
+	}
+	type acceptResp struct {
+		Acceptance acceptanceView `json:"acceptance"`
+		ServerTime string         `json:"serverTime"`
+	}
+	s.handle("/invites/accept", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		acceptorSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req acceptReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		acc, err := svc.Accept(r.Context(), acceptorSubject, req.Token)
+		if err != nil {
+			writeInviteError(w, "invite_accept_failed", err)
+			return
+		}
+		writeJSONP1(w, http.StatusAccepted, acceptResp{
+			Acceptance: acceptanceOf(acc),
+			ServerTime: time.Now().UTC().Format(time.RFC3339),
+		})
+	}))
+}
+
+// writeInviteError maps invites errors to HTTP status codes.
+// Token details are never echoed back.
+func writeInviteError(w http.ResponseWriter, code string, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, invites.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, invites.ErrNotOutstanding), errors.Is(err, invites.ErrSelfInvite),
+		errors.Is(err, invites.ErrAcceptanceExists):
+		status = http.StatusConflict
+	case errors.Is(err, invites.ErrTokenExpired):
+		status = http.StatusGone
+	case errors.Is(err, invites.ErrMalformedToken), errors.Is(err, invites.ErrBadSignature),
+		errors.Is(err, invites.ErrNoReception):
+		status = http.StatusBadRequest
+	default:
+		writeJSONP1(w, http.StatusBadGateway, map[string]any{"error": code})
+		return
+	}
+	writeJSONP1(w, status, map[string]any{"error": code, "detail": err.Error()})
+}
@@ -1,11 +1,19 @@
 package main
 
+import (
+	"encoding/json"
+	"net/http"
+)
+
 // JWTClaims is the minimal set of claims we use.
 // Keep it small and stable.
 type JWTClaims struct {
-	Sub   string `json:"sub"`
-	Email string `json:"email,omitempty"`
-	Aud   string `json:"aud,omitempty"`
+	Sub       string `json:"sub"`
+	Email     string `json:"email,omitempty"`
+	Aud       string `json:"aud,omitempty"`
+	Iss       string `json:"iss,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Method    string `json:"-"` // TokenVerifier.Name() that accepted the token
 }
 
 // JWTError is our structured auth error payload.
@@ -14,3 +22,13 @@ type JWTError struct {
 	Code    string `json:"code,omitempty"`    // "missing_token", "invalid_token", etc.
 	Message string `json:"message,omitempty"` // human readable
 }
+
+// writeJWTError writes a JSON auth error with the given status.
+func writeJWTError(w http.ResponseWriter, code int, e *JWTError) {
+	if e == nil {
+		e = &JWTError{Error: "unauthorized", Code: "invalid_token", Message: "Unauthorized"}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(e)
+}
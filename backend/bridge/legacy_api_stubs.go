@@ -3,52 +3,29 @@ package main
 import (
 	"encoding/json"
 	"net/http"
-	"os"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
 )
 
-// Minimal auth middleware used by Phase-5 routes.
-// Require Bearer token unless BRIDGE_API_ONLY=true (then allow).
-func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
+// localAuthMiddleware verifies the Bearer token with the configured verifier
+// chain (no rate limiting) and attaches the Principal. Used by API-only mode, where
+// Supabase endpoint settings are not loaded so only local verifiers apply.
+func localAuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if strings.EqualFold(os.Getenv("BRIDGE_API_ONLY"), "true") {
-			next(w, r)
-			return
-		}
-
-		authz := r.Header.Get("Authorization")
-		if !strings.HasPrefix(authz, "Bearer ") {
-			writeJWTError(w, http.StatusUnauthorized, &JWTError{
-				Error:   "unauthorized",
-				Code:    "missing_token",
-				Message: "Missing Authorization Bearer token",
-			})
-			return
-		}
-		token := strings.TrimSpace(strings.TrimPrefix(authz, "Bearer "))
-
-		claims, jerr := verifyJWTLocal(token, os.Getenv("SUPABASE_ANON_KEY"))
+		claims, jerr := validateJWT(r)
 		if jerr != nil || claims == nil || claims.Sub == "" {
 			writeJWTError(w, http.StatusUnauthorized, jerr)
 			return
 		}
 
-		next(w, r)
-	}
-}
-
-func writeJWTError(w http.ResponseWriter, code int, e *JWTError) {
-	if e == nil {
-		e = &JWTError{Error: "unauthorized", Code: "invalid_token", Message: "Unauthorized"}
+		next(w, withPrincipal(r, principalFromClaims(claims)))
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(e)
 }
 
-// ---- Phase-5 compatibility responses ----
+// ---- Phase-5 compatibility responses (API-only mode) ----
 
 type healthResp struct {
 	Status    string `json:"status"`
@@ -56,7 +33,7 @@ type healthResp struct {
 	XXDKReady bool   `json:"xxdkReady"`
 }
 
-func handleHealth(w http.ResponseWriter, r *http.Request) {
+func stubHealth(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(healthResp{
 		Status:    "ok",
@@ -108,7 +85,7 @@ func isUnlockedNow() (bool, time.Time) {
 	return false, time.Time{}
 }
 
-func handleUnlockStatus(w http.ResponseWriter, r *http.Request) {
+func stubUnlockStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	ok, exp := isUnlockedNow()
@@ -128,7 +105,7 @@ func handleUnlockStatus(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func handleUnlock(w http.ResponseWriter, r *http.Request) {
+func stubUnlock(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != http.MethodPost {
@@ -162,7 +139,7 @@ func handleUnlock(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func handleLock(w http.ResponseWriter, r *http.Request) {
+func stubLock(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	if r.Method != http.MethodPost {
@@ -190,11 +167,8 @@ type connectAck struct {
 	ServerTime string `json:"serverTime"`
 }
 
-func handleConnect(w http.ResponseWriter, r *http.Request) {
-	reqID := strings.TrimSpace(r.Header.Get("X-Request-Id"))
-	if reqID == "" {
-		reqID = strings.TrimSpace(r.URL.Query().Get("requestId"))
-	}
+func stubConnect(w http.ResponseWriter, r *http.Request) {
+	reqID := logging.RequestID(r.Context())
 	sessID := strings.TrimSpace(r.Header.Get("X-Session-Id"))
 	if sessID == "" {
 		sessID = "stub-session"
@@ -212,14 +186,14 @@ func handleConnect(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func handleStatus(w http.ResponseWriter, r *http.Request) {
+func stubStatus(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
 		"state": "idle",
 	})
 }
 
-func handleDisconnect(w http.ResponseWriter, r *http.Request) {
+func stubDisconnect(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(map[string]any{
 		"state": "idle",
@@ -22,20 +22,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"net/url"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
-)
 
-// Canonical origin for production
-const CanonicalOrigin = "https://privxx.app"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/backend"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+	"github.com/Bulldog-Master/privxx/backend/core/metrics"
+)
 
 // Session state
 type SessionState string
@@ -123,9 +127,9 @@ var identityManager = &IdentityManager{
 	ttl:      15 * time.Minute,
 }
 
-// UnlockRequest is the payload for POST /unlock
+// UnlockRequest is the payload for POST /unlock; the password is checked by core.
 type UnlockRequest struct {
-	// Future: could include passphrase or biometric confirmation
+	Password string `json:"password"`
 }
 
 // UnlockResponse is returned from POST /unlock
@@ -179,13 +183,16 @@ func (im *IdentityManager) getSession(userID string) (*IdentitySession, bool) {
 	return session, true
 }
 
-// unlock creates or refreshes an unlocked session for a user
-func (im *IdentityManager) unlock(userID string) *IdentitySession {
+// unlock creates or refreshes the session mirroring core's unlock, which
+// expires at expiresAt; the local TTL caps it (zero means TTL from now).
+func (im *IdentityManager) unlock(userID string, expiresAt time.Time) *IdentitySession {
 	im.mu.Lock()
 	defer im.mu.Unlock()
 
 	now := time.Now()
-	expiresAt := now.Add(im.ttl)
+	if limit := now.Add(im.ttl); expiresAt.IsZero() || expiresAt.After(limit) {
+		expiresAt = limit
+	}
 
 	session, exists := im.sessions[userID]
 	if exists {
@@ -193,11 +200,12 @@ func (im *IdentityManager) unlock(userID string) *IdentitySession {
 		session.UnlockedAt = now
 		session.ExpiresAt = expiresAt
 		session.LastActivity = now
-		log.Printf("[IDENTITY] Session refreshed for user %s (expires %s)", userID, expiresAt.Format(time.RFC3339))
+		logging.Info(context.Background(), "identity session refreshed",
+			logging.Pseudonym("subject", userID), logging.Public("expires", expiresAt))
 	} else {
 		// Create new session
 		// TODO: In real implementation, create or retrieve XX identity here
-		xxIdentityID := fmt.Sprintf("xx-id-%s-%d", userID[:8], now.UnixNano())
+		xxIdentityID := fmt.Sprintf("xx-id-%s-%d", userID[:min(8, len(userID))], now.UnixNano())
 
 		session = &IdentitySession{
 			UserID:       userID,
@@ -207,8 +215,8 @@ func (im *IdentityManager) unlock(userID string) *IdentitySession {
 			LastActivity: now,
 		}
 		im.sessions[userID] = session
-		log.Printf("[IDENTITY] New session created for user %s (XX ID: %s, expires %s)",
-			userID, xxIdentityID, expiresAt.Format(time.RFC3339))
+		logging.Info(context.Background(), "identity session created",
+			logging.Pseudonym("subject", userID), logging.Public("expires", expiresAt))
 	}
 
 	return session
@@ -222,7 +230,7 @@ func (im *IdentityManager) lock(userID string) bool {
 	_, exists := im.sessions[userID]
 	if exists {
 		delete(im.sessions, userID)
-		log.Printf("[IDENTITY] Session locked for user %s", userID)
+		logging.Info(context.Background(), "identity session locked", logging.Pseudonym("subject", userID))
 		return true
 	}
 	return false
@@ -241,6 +249,21 @@ func (im *IdentityManager) touchActivity(userID string) {
 	}
 }
 
+// active counts unexpired unlocks (the identity sessions metric).
+func (im *IdentityManager) active() int {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+
+	now := time.Now()
+	n := 0
+	for _, session := range im.sessions {
+		if now.Before(session.ExpiresAt) {
+			n++
+		}
+	}
+	return n
+}
+
 // cleanup removes expired sessions
 func (im *IdentityManager) cleanup() {
 	im.mu.Lock()
@@ -250,29 +273,27 @@ func (im *IdentityManager) cleanup() {
 	for userID, session := range im.sessions {
 		if now.After(session.ExpiresAt) {
 			delete(im.sessions, userID)
-			log.Printf("[IDENTITY] Expired session cleaned up for user %s", userID)
+			logging.Debug(context.Background(), "identity session expired", logging.Pseudonym("subject", userID))
 		}
 	}
 }
 
-// startCleanupRoutine periodically cleans up expired sessions
-func (im *IdentityManager) startCleanupRoutine() {
+// startCleanupRoutine periodically cleans up expired sessions until ctx is done
+func (im *IdentityManager) startCleanupRoutine(ctx context.Context) {
 	go func() {
 		ticker := time.NewTicker(1 * time.Minute)
 		defer ticker.Stop()
-		for range ticker.C {
-			im.cleanup()
+		for {
+			select {
+			case <-ticker.C:
+				im.cleanup()
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 }
 
-// JWTClaims represents the user data returned from Supabase /auth/v1/user
-type JWTClaims struct {
-	Sub   string `json:"id"`    // User ID (mapped from "id" in response)
-	Email string `json:"email"` // User email
-	Aud   string `json:"aud"`   // Audience
-}
-
 // SupabaseUserResponse represents the full response from /auth/v1/user
 type SupabaseUserResponse struct {
 	ID               string `json:"id"`
@@ -289,18 +310,28 @@ type RateLimitConfig struct {
 	LockoutDuration time.Duration // How long to block after exceeding limit
 }
 
-// RateLimitEntry tracks attempts for a single IP
+// RateLimitEntry tracks attempts for a single IP, or a token bucket and daily
+// quota usage for a policy limiter key (see ratelimit_policy.go)
 type RateLimitEntry struct {
 	Attempts     int
 	FirstAttempt time.Time
 	LockedUntil  time.Time
+
+	Tokens      float64
+	LastRefill  time.Time
+	DayStart    time.Time
+	DayRequests int
+	DayBytes    int64
 }
 
-// RateLimiter manages IP-based rate limiting
+// RateLimiter manages IP-based rate limiting of failed auth, or, when policy
+// is set, per-route token buckets keyed by subject and IP
 type RateLimiter struct {
 	mu      sync.RWMutex
 	entries map[string]*RateLimitEntry
 	config  RateLimitConfig
+	name    string
+	policy  *RoutePolicy
 }
 
 // Default rate limit: 10 failed attempts per 15 minutes, 30 minute lockout
@@ -313,28 +344,6 @@ var rateLimiter = &RateLimiter{
 	},
 }
 
-// getClientIP extracts the client IP from the request
-func getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (for reverse proxies)
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		// Take the first IP in the chain
-		if idx := strings.Index(xff, ","); idx != -1 {
-			return strings.TrimSpace(xff[:idx])
-		}
-		return strings.TrimSpace(xff)
-	}
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return strings.TrimSpace(xri)
-	}
-	// Fall back to RemoteAddr
-	ip := r.RemoteAddr
-	if idx := strings.LastIndex(ip, ":"); idx != -1 {
-		return ip[:idx]
-	}
-	return ip
-}
-
 // isRateLimited checks if an IP is currently rate limited
 func (rl *RateLimiter) isRateLimited(ip string) (bool, time.Duration) {
 	rl.mu.RLock()
@@ -386,8 +395,11 @@ func (rl *RateLimiter) recordFailedAttempt(ip string) (blocked bool, remaining t
 	// Check if we should lock out
 	if entry.Attempts >= rl.config.MaxAttempts {
 		entry.LockedUntil = now.Add(rl.config.LockoutDuration)
-		log.Printf("[RATE-LIMIT] IP %s locked out until %s (%d attempts)",
-			ip, entry.LockedUntil.Format(time.RFC3339), entry.Attempts)
+		bridgeMetrics.lockouts.Inc()
+		logging.Warn(context.Background(), "client locked out",
+			logging.Pseudonym("client", ip),
+			logging.Public("until", entry.LockedUntil),
+			logging.Public("attempts", entry.Attempts))
 		return true, rl.config.LockoutDuration
 	}
 
@@ -408,76 +420,33 @@ func (rl *RateLimiter) cleanup() {
 
 	now := time.Now()
 	for ip, entry := range rl.entries {
+		if rl.policy != nil {
+			if rl.policyEntryIdle(entry, now) {
+				delete(rl.entries, ip)
+			}
+			continue
+		}
 		// Remove if window expired and not locked
-		windowExpired := now.Sub(entry.FirstAttempt) > rl.config.WindowDuration
-		lockExpired := entry.LockedUntil.IsZero() || now.After(entry.LockedUntil)
-
-		if windowExpired && lockExpired {
+		if rl.entryExpired(entry, now) {
 			delete(rl.entries, ip)
 		}
 	}
 }
 
-// startCleanupRoutine starts a goroutine to periodically clean up expired entries
-func (rl *RateLimiter) startCleanupRoutine() {
+// startCleanupRoutine starts a goroutine to periodically clean up expired entries until ctx is done
+func (rl *RateLimiter) startCleanupRoutine(ctx context.Context) {
 	go func() {
 		ticker := time.NewTicker(5 * time.Minute)
 		defer ticker.Stop()
-		for range ticker.C {
-			rl.cleanup()
-		}
-	}()
-}
-
-// AllowedOrigins for CORS (production + development + Lovable preview)
-var allowedOrigins = []string{
-	"https://privxx.app",
-	"https://www.privxx.app",
-	"https://privxx.lovable.app", // Explicit Lovable preview domain
-}
-
-// AllowedOriginSuffixes for Lovable preview domains
-var allowedOriginSuffixes = []string{
-	".lovable.app",
-	".lovableproject.com",
-}
-
-// isAllowedOrigin checks if the origin is permitted
-func isAllowedOrigin(origin string) bool {
-	if origin == "" {
-		return false
-	}
-
-	// Check environment for development mode
-	if os.Getenv("ENVIRONMENT") == "development" {
-		return true
-	}
-
-	// Check exact matches
-	for _, allowed := range allowedOrigins {
-		if origin == allowed {
-			return true
-		}
-	}
-
-	// Check suffix matches (Lovable preview domains)
-	for _, suffix := range allowedOriginSuffixes {
-		// Parse origin to get hostname
-		if parsed, err := url.Parse(origin); err == nil {
-			if strings.HasSuffix(parsed.Host, suffix) && strings.HasPrefix(origin, "https://") {
-				return true
+		for {
+			select {
+			case <-ticker.C:
+				rl.cleanup()
+			case <-ctx.Done():
+				return
 			}
 		}
-	}
-
-	return false
-}
-
-// JWTError response for auth failures
-type JWTError struct {
-	Error   string `json:"error"`
-	Code    string `json:"code"`
-	Message string `json:"message,omitempty"`
+	}()
 }
 
 // Backend configuration (provided at runtime via environment variables)
@@ -486,16 +455,12 @@ var (
 	supabaseAnonKey string
 )
 
-func init() {
-	supabaseURL = strings.TrimSpace(os.Getenv("SUPABASE_URL"))
+// loadSupabaseConfig reads the Supabase settings used by endpoint verification.
+// Both are optional: without them the endpoint verifier is left out of the
+// chain and loadTokenVerifier fails only if no other verifier is configured.
+func loadSupabaseConfig() {
+	supabaseURL = strings.TrimRight(strings.TrimSpace(os.Getenv("SUPABASE_URL")), "/")
 	supabaseAnonKey = strings.TrimSpace(os.Getenv("SUPABASE_ANON_KEY"))
-
-	if supabaseURL == "" {
-		log.Fatal("[CONFIG] SUPABASE_URL is required")
-	}
-	if supabaseAnonKey == "" {
-		log.Fatal("[CONFIG] SUPABASE_ANON_KEY is required")
-	}
 }
 
 // HTTP client with timeout for Supabase API calls
@@ -505,13 +470,13 @@ var httpClient = &http.Client{
 
 // validateJWTViaEndpoint validates a JWT by calling Supabase /auth/v1/user
 // This is the endpoint-based verification approach that doesn't require the JWT secret
-func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
+func validateJWTViaEndpoint(ctx context.Context, token string) (*JWTClaims, *JWTError) {
 	// Build request to Supabase auth endpoint
 	authURL := supabaseURL + "/auth/v1/user"
 
-	req, err := http.NewRequest("GET", authURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", authURL, nil)
 	if err != nil {
-		log.Printf("[JWT] Failed to create request: %v", err)
+		logging.Error(ctx, "supabase request build failed", logging.Err(err))
 		return nil, &JWTError{
 			Error:   "server_error",
 			Code:    "request_failed",
@@ -527,7 +492,7 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 	// Make the request
 	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.Printf("[JWT] Supabase API request failed: %v", err)
+		logging.Warn(ctx, "supabase request failed", logging.Err(err))
 		return nil, &JWTError{
 			Error:   "server_error",
 			Code:    "supabase_unreachable",
@@ -539,7 +504,7 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("[JWT] Failed to read response: %v", err)
+		logging.Warn(ctx, "supabase response read failed", logging.Err(err))
 		return nil, &JWTError{
 			Error:   "server_error",
 			Code:    "response_error",
@@ -549,7 +514,8 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 
 	// Handle non-200 responses
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("[JWT] Supabase returned %d: %s", resp.StatusCode, string(body))
+		// The body can echo the token or account details; log the status only.
+		logging.Warn(ctx, "supabase rejected token", logging.Public("status", resp.StatusCode))
 
 		if resp.StatusCode == http.StatusUnauthorized {
 			return nil, &JWTError{
@@ -569,7 +535,7 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 	// Parse user response
 	var user SupabaseUserResponse
 	if err := json.Unmarshal(body, &user); err != nil {
-		log.Printf("[JWT] Failed to parse user response: %v", err)
+		logging.Warn(ctx, "supabase user response unparseable", logging.Err(err))
 		return nil, &JWTError{
 			Error:   "server_error",
 			Code:    "parse_error",
@@ -579,7 +545,7 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 
 	// Validate user ID exists
 	if user.ID == "" {
-		log.Printf("[JWT] User response missing ID")
+		logging.Warn(ctx, "supabase user response missing id")
 		return nil, &JWTError{
 			Error:   "unauthorized",
 			Code:    "invalid_user",
@@ -587,7 +553,7 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 		}
 	}
 
-	log.Printf("[JWT] Token verified via Supabase endpoint for user: %s (%s)", user.ID, user.Email)
+	logging.Debug(ctx, "token verified via supabase endpoint", logging.Pseudonym("subject", user.ID))
 
 	return &JWTClaims{
 		Sub:   user.ID,
@@ -597,7 +563,7 @@ func validateJWTViaEndpoint(token string) (*JWTClaims, *JWTError) {
 }
 
 // validateJWT validates the JWT token from the Authorization header
-// Uses Supabase /auth/v1/user endpoint for verification (no local secret required)
+// using the configured TokenVerifier chain (see auth_verifier.go).
 func validateJWT(r *http.Request) (*JWTClaims, *JWTError) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
@@ -626,15 +592,7 @@ func validateJWT(r *http.Request) (*JWTClaims, *JWTError) {
 		}
 	}
 
-	// Verify token via Supabase endpoint
-	return validateJWTViaEndpoint(token)
-}
-
-// writeJWTError writes a JSON error response for JWT validation failures
-func writeJWTError(w http.ResponseWriter, jwtErr *JWTError) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusUnauthorized)
-	json.NewEncoder(w).Encode(jwtErr)
+	return tokenVerifier.Verify(r.Context(), token)
 }
 
 // authMiddleware validates JWT for protected routes with rate limiting
@@ -644,7 +602,9 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 
 		// Check if IP is rate limited
 		if limited, remaining := rateLimiter.isRateLimited(clientIP); limited {
-			log.Printf("[RATE-LIMIT] Blocked request from %s (locked for %v)", clientIP, remaining)
+			logging.Warn(r.Context(), "rate limited",
+				logging.Pseudonym("client", clientIP), logging.Public("remaining", remaining))
+			bridgeMetrics.rateLimited.Inc("auth", "lockout")
 			w.Header().Set("Content-Type", "application/json")
 			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(remaining.Seconds())))
 			w.WriteHeader(http.StatusTooManyRequests)
@@ -665,56 +625,21 @@ func authMiddleware(next http.HandlerFunc) http.HandlerFunc {
 				jwtErr.Message = fmt.Sprintf("Account locked due to too many failed attempts. Try again in %d seconds.", int(remaining.Seconds()))
 				jwtErr.Code = "rate_limited"
 			}
-			writeJWTError(w, jwtErr)
+			writeJWTError(w, http.StatusUnauthorized, jwtErr)
 			return
 		}
 
 		// Clear rate limit on successful auth
 		rateLimiter.recordSuccess(clientIP)
 
-		// Add user ID to request context via header for downstream handlers
-		r.Header.Set("X-User-Id", claims.Sub)
-		next(w, r)
-	}
-}
-
-// corsMiddleware enforces canonical origin CORS policy
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-
-		// Set CORS headers based on origin validation
-		if isAllowedOrigin(origin) {
-			w.Header().Set("Access-Control-Allow-Origin", origin)
-			w.Header().Set("Access-Control-Allow-Credentials", "true")
-			w.Header().Set("Vary", "Origin")
-		} else if origin != "" {
-			// Log rejected origins for debugging (no sensitive data)
-			log.Printf("[CORS] Rejected origin: %s", origin)
-			// Return canonical origin (will fail CORS for unauthorized)
-			w.Header().Set("Access-Control-Allow-Origin", CanonicalOrigin)
-		} else {
-			// No origin header (direct request, not browser CORS)
-			w.Header().Set("Access-Control-Allow-Origin", CanonicalOrigin)
-		}
-
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Correlation-Id, X-Client-Info")
-		w.Header().Set("Access-Control-Max-Age", "86400") // Cache preflight for 24 hours
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusNoContent)
-			return
-		}
-
-		next(w, r)
+		next(w, withPrincipal(r, principalFromClaims(claims)))
 	}
 }
 
 // unlockRequiredMiddleware ensures user has an active unlocked session
 func unlockRequiredMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Header.Get("X-User-Id")
+		userID := authSubject(r)
 		if userID == "" {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusUnauthorized)
@@ -743,93 +668,6 @@ func unlockRequiredMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// handleUnlock unlocks the user's identity session
-func handleUnlock(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	userID := r.Header.Get("X-User-Id")
-	if userID == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(UnlockResponse{
-			Success: false,
-			Error:   "User ID not found",
-		})
-		return
-	}
-
-	session := identityManager.unlock(userID)
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(UnlockResponse{
-		Success:   true,
-		ExpiresAt: session.ExpiresAt,
-		TTL:       int(time.Until(session.ExpiresAt).Seconds()),
-	})
-}
-
-// handleLock immediately locks the user's identity session
-func handleLock(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	userID := r.Header.Get("X-User-Id")
-	if userID == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(LockResponse{
-			Success: false,
-			Error:   "User ID not found",
-		})
-		return
-	}
-
-	identityManager.lock(userID)
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(LockResponse{
-		Success: true,
-	})
-}
-
-// handleUnlockStatus returns the current unlock status
-func handleUnlockStatus(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "GET" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	userID := r.Header.Get("X-User-Id")
-	if userID == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "User ID not found",
-		})
-		return
-	}
-
-	session, unlocked := identityManager.getSession(userID)
-
-	w.Header().Set("Content-Type", "application/json")
-	if unlocked {
-		json.NewEncoder(w).Encode(UnlockStatusResponse{
-			Unlocked:     true,
-			ExpiresAt:    session.ExpiresAt,
-			TTLRemaining: int(time.Until(session.ExpiresAt).Seconds()),
-		})
-	} else {
-		json.NewEncoder(w).Encode(UnlockStatusResponse{
-			Unlocked: false,
-		})
-	}
-}
-
 // handleHealth returns bridge health status
 func handleHealth(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -840,143 +678,18 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 	resp := HealthResponse{
 		Status:    "ok",
 		Version:   "0.4.0",
-		XXDKReady: false, // TODO: Check actual xxDK status
+		XXDKReady: false,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 
-	// Option B: backend-owned xxDK readiness (health)
-	// Best-effort only: /health must still work if backend is down.
-	backendAddr := os.Getenv("BACKEND_ADDR")
-	if backendAddr == "" {
-		backendAddr = "127.0.0.1"
-	}
-	backendPort := os.Getenv("BACKEND_PORT")
-	if backendPort == "" {
-		backendPort = "8790"
-	}
-
-	backendURL := fmt.Sprintf("http://%s:%s/health", backendAddr, backendPort)
-	if req, err := http.NewRequest("GET", backendURL, nil); err == nil {
-		if r2, err := httpClient.Do(req); err == nil {
-			defer r2.Body.Close()
-			if r2.StatusCode == http.StatusOK {
-				var tmp struct {
-					XXDKReady bool `json:"xxdkReady"`
-				}
-				if json.NewDecoder(r2.Body).Decode(&tmp) == nil {
-					resp.XXDKReady = tmp.XXDKReady
-				}
-			}
-		}
-	}
+	// Option B: backend-owned xxDK readiness, as last seen by the health prober.
+	// /health never waits on the backend.
+	resp.XXDKReady = backendClient.XXDKReady()
 
 	json.NewEncoder(w).Encode(resp)
 }
 
-// handleConnect processes Phase D connect_intent and returns connect_ack
-func handleConnect(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	var intent ConnectIntent
-	if err := json.NewDecoder(r.Body).Decode(&intent); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ConnectAck{
-			V:          PhaseDSchemaVersion,
-			Type:       "connect_ack",
-			RequestID:  "",
-			SessionID:  "",
-			Ack:        false,
-			Status:     "error",
-			ErrorCode:  "INVALID_MESSAGE",
-			ServerTime: time.Now().UTC().Format(time.RFC3339),
-		})
-		return
-	}
-
-	// Validate intent type
-	if intent.Type != "connect_intent" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ConnectAck{
-			V:          PhaseDSchemaVersion,
-			Type:       "connect_ack",
-			RequestID:  intent.RequestID,
-			SessionID:  intent.SessionID,
-			Ack:        false,
-			Status:     "error",
-			ErrorCode:  "INVALID_MESSAGE",
-			ServerTime: time.Now().UTC().Format(time.RFC3339),
-		})
-		return
-	}
-
-	// Validate required fields
-	if intent.TargetURL == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(ConnectAck{
-			V:          PhaseDSchemaVersion,
-			Type:       "connect_ack",
-			RequestID:  intent.RequestID,
-			SessionID:  intent.SessionID,
-			Ack:        false,
-			Status:     "error",
-			ErrorCode:  "INVALID_URL",
-			ServerTime: time.Now().UTC().Format(time.RFC3339),
-		})
-		return
-	}
-
-	// Update session state
-	session.mu.Lock()
-	session.State = StateConnecting
-	session.TargetURL = intent.TargetURL
-	session.SessionID = intent.SessionID
-	now := time.Now()
-	session.StartedAt = &now
-	session.mu.Unlock()
-
-	// Privacy-preserving logging: only log domain, not full URL with parameters
-	if parsedURL, err := url.Parse(intent.TargetURL); err == nil {
-		log.Printf("[CONNECT] Domain: %s, RequestID: %s", parsedURL.Host, intent.RequestID)
-	} else {
-		log.Printf("[CONNECT] Request received (URL parse error), RequestID: %s", intent.RequestID)
-	}
-
-	// TODO: Replace with real xxDK/cMixx integration
-	// For now, simulate synchronous connection (Phase D)
-	// In real implementation:
-	// 1. Send connect_intent via cMixx
-	// 2. Wait for connect_ack from server
-	// 3. Return the ack to frontend
-
-	// Simulated cMixx handshake delay (in production, this would be async)
-	time.Sleep(500 * time.Millisecond)
-
-	session.mu.Lock()
-	session.State = StateSecure
-	session.mu.Unlock()
-
-	log.Printf("[SECURE] Session: %s, RequestID: %s", intent.SessionID, intent.RequestID)
-
-	// Return successful connect_ack
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(ConnectAck{
-		V:          PhaseDSchemaVersion,
-		Type:       "connect_ack",
-		RequestID:  intent.RequestID,
-		SessionID:  intent.SessionID,
-		Ack:        true,
-		Status:     "connected",
-		ServerTime: time.Now().UTC().Format(time.RFC3339),
-	})
-}
-
 // handleStatus returns current session status
 func handleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
@@ -996,61 +709,69 @@ func handleStatus(w http.ResponseWriter, r *http.Request) {
 	}
 	session.mu.RUnlock()
 
-	// Option B: backend-owned xxDK readiness (local-only).
-	// Best-effort only: /status must still work if backend is down.
-	backendAddr := os.Getenv("BACKEND_ADDR")
-	if backendAddr == "" {
-		backendAddr = "127.0.0.1"
-	}
-	backendPort := os.Getenv("BACKEND_PORT")
-	if backendPort == "" {
-		backendPort = "8790"
-	}
-
-	backendURL := fmt.Sprintf("http://%s:%s/health", backendAddr, backendPort)
-	req, err := http.NewRequest("GET", backendURL, nil)
-	if err == nil {
-		r2, err := httpClient.Do(req)
-		if err == nil {
-			defer r2.Body.Close()
-			if r2.StatusCode == http.StatusOK {
-				body, _ := io.ReadAll(r2.Body)
-				var tmp struct {
-					XXDKReady bool `json:"xxdkReady"`
-				}
-				if json.Unmarshal(body, &tmp) == nil {
-					resp.XXDKReady = tmp.XXDKReady
-				}
-			}
-		}
-	}
+	// Option B: backend-owned xxDK readiness (cached by the health prober).
+	resp.XXDKReady = backendClient.XXDKReady()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-// handleDisconnect resets the session
-func handleDisconnect(w http.ResponseWriter, r *http.Request) {
-	if r.Method != "POST" {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+// fatal logs err as the reason the bridge cannot start and exits.
+func fatal(msg logging.Msg, err error) {
+	logging.Error(context.Background(), msg, logging.Err(err))
+	os.Exit(1)
+}
+
+func main() {
+	printRoutes := flag.Bool("routes", false, "print the route table for BRIDGE_MODE and exit")
+	flag.Parse()
+	if _, err := logging.Setup("bridge"); err != nil {
+		log.Fatalf("[CONFIG] %v", err)
+	}
+	ctx := context.Background()
+
+	mode, err := parseMode()
+	if err != nil {
+		fatal("config invalid", err)
+	}
+
+	if *printRoutes {
+		// Route registration has no side effects, so empty dependencies are fine here.
+		srv, err := newServer(mode, &phase1Deps{})
+		if err != nil {
+			fatal("config invalid", err)
+		}
+		for _, r := range srv.Routes() {
+			fmt.Println(r)
+		}
 		return
 	}
 
-	session.mu.Lock()
-	session.State = StateIdle
-	session.TargetURL = ""
-	session.SessionID = ""
-	session.Error = ""
-	session.StartedAt = nil
-	session.mu.Unlock()
+	if err := loadTrustedProxies(); err != nil {
+		fatal("config invalid", err)
+	}
+	loadSupabaseConfig()
+	if tokenVerifier, err = loadTokenVerifier(mode != modeAPIOnly); err != nil {
+		fatal("config invalid", err)
+	}
+	logging.Info(ctx, "token verifier", logging.Public("verifier", logging.Label(tokenVerifier.Name())))
 
-	log.Printf("[DISCONNECT] Session reset")
+	// Background routines stop when bg is cancelled, after HTTP has drained.
+	bg, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if endpointCache != nil {
+		endpointCache.startCleanupRoutine(bg)
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"success": true})
-}
+	backendCfg, err := backend.LoadConfig()
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	backendClient = backend.NewFromConfig(backendCfg)
+	backendClient.StartProber(bg)
+	logging.Info(ctx, "backend configured", logging.Public("url", logging.Label(backendClient.URL())),
+		logging.Public("probe_interval", backendCfg.ProbeInterval))
 
-func main() {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8090"
@@ -1069,49 +790,144 @@ func main() {
 		}
 	}
 
+	policies, err := loadRateLimitPolicies()
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	routeLimiter = newRouteLimits(policies)
+
+	cp, err := loadCORSPolicy()
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	if corsPolicy, err = compileCORS(cp); err != nil {
+		fatal("config invalid", err)
+	}
+
+	// Reload lockouts and sessions from the last run, if persistence is enabled
+	state, err := loadStatePersistence()
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	var stateFlushed <-chan struct{}
+	if state != nil {
+		if err := state.restore(); err != nil {
+			fatal("state restore failed", err)
+		}
+		stateFlushed = state.start(bg)
+	}
+
+	if v := os.Getenv("PHASE1_SESSION_MAX_LIFETIME_MINUTES"); v != "" {
+		if d, err := time.ParseDuration(v + "m"); err == nil {
+			phase1SessionMgr.SetMaxLifetime(d)
+		}
+	}
+	for _, sub := range strings.Split(os.Getenv("BRIDGE_ADMIN_SUBJECTS"), ",") {
+		if sub = strings.TrimSpace(sub); sub != "" {
+			adminSubjects[sub] = true
+		}
+	}
+
 	// Start cleanup routines
-	rateLimiter.startCleanupRoutine()
-	identityManager.startCleanupRoutine()
-
-	log.Printf("Rate limiter initialized: %d attempts per %v, %v lockout",
-		rateLimiter.config.MaxAttempts,
-		rateLimiter.config.WindowDuration,
-		rateLimiter.config.LockoutDuration)
-	log.Printf("Identity manager initialized: %v TTL", identityManager.ttl)
-
-	// /health is public (no auth required)
-	http.HandleFunc("/health", corsMiddleware(handleHealth))
-
-	// Unlock/lock endpoints require auth but not unlock status
-	http.HandleFunc("/unlock", corsMiddleware(authMiddleware(handleUnlock)))
-	http.HandleFunc("/unlock/status", corsMiddleware(authMiddleware(handleUnlockStatus)))
-	http.HandleFunc("/lock", corsMiddleware(authMiddleware(handleLock)))
-
-	// Protected routes require both auth AND unlocked session
-	http.HandleFunc("/connect", corsMiddleware(authMiddleware(unlockRequiredMiddleware(handleConnect))))
-	http.HandleFunc("/status", corsMiddleware(authMiddleware(handleStatus))) // Status doesn't require unlock
-	http.HandleFunc("/disconnect", corsMiddleware(authMiddleware(unlockRequiredMiddleware(handleDisconnect))))
-	http.HandleFunc("/message/send", corsMiddleware(devBypassAuthAndUnlock(handleMessageSend)))
-	http.HandleFunc("/message/inbox", corsMiddleware(devBypassAuthAndUnlock(handleMessageInbox)))
-	http.HandleFunc("/browse/preview", corsMiddleware(devBypassAuthAndUnlock(handleBrowsePreview)))
-	http.HandleFunc("/browse/fetch", corsMiddleware(devBypassAuthAndUnlock(handleBrowseFetch)))
+	rateLimiter.startCleanupRoutine(bg)
+	routeLimiter.startCleanupRoutine(bg)
+	identityManager.startCleanupRoutine(bg)
+	phase1SessionMgr.StartCleanup(bg, time.Minute)
+
+	logging.Info(ctx, "rate limiter initialized",
+		logging.Public("max_attempts", rateLimiter.config.MaxAttempts),
+		logging.Public("window", rateLimiter.config.WindowDuration),
+		logging.Public("lockout", rateLimiter.config.LockoutDuration))
+	logging.Info(ctx, "identity manager initialized", logging.Public("ttl", identityManager.ttl))
+
+	var deps *phase1Deps
+	if mode == modePhase1 {
+		if deps, err = loadPhase1Deps(); err != nil {
+			fatal("phase1 config invalid", err)
+		}
+	}
+	srv, err := newServer(mode, deps)
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	if deps != nil {
+		if err := deps.start(bg); err != nil {
+			fatal("transport start failed", err)
+		}
+	}
+
+	mAddr, err := metricsAddr()
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	var ms *http.Server
+	if mAddr != "" {
+		if ms, err = metrics.NewServer(mAddr, bridgeMetrics.reg); err != nil {
+			fatal("config invalid", err)
+		}
+		go func() {
+			if err := ms.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logging.Error(ctx, "metrics listener stopped", logging.Err(err))
+			}
+		}()
+		logging.Info(ctx, "metrics listening", logging.Public("addr", logging.Label(mAddr)))
+	}
 
 	listenAddr := fmt.Sprintf("%s:%s", bindAddr, port)
 
-	log.Printf("Privxx Bridge v0.4.0 starting on %s", listenAddr)
-	log.Printf("Endpoints: /health, /unlock, /unlock/status, /lock, /connect, /status, /disconnect")
-	log.Printf("CORS: Canonical origin %s", CanonicalOrigin)
-	log.Printf("Allowed origins: %v", allowedOrigins)
-	log.Printf("Allowed suffixes: %v", allowedOriginSuffixes)
-	log.Printf("---")
+	logging.Info(ctx, "privxx bridge starting", logging.Public("version", logging.Label("v0.4.0")),
+		logging.Public("addr", logging.Label(listenAddr)), logging.Public("mode", logging.Label(mode)))
+	logging.Info(ctx, "endpoints", logging.Public("routes", logging.Label(strings.Join(srv.Routes(), ", "))))
+	logging.Info(ctx, "cors origins", logging.Public("origins", logging.Label(corsPolicy.summary())))
 
 	if os.Getenv("ENVIRONMENT") == "development" {
-		log.Printf("WARNING: Running in development mode (all origins allowed)")
+		logging.Warn(ctx, "running in development mode")
 	}
 
-	log.Printf("NOTE: xxDK integration is simulated. Replace TODO sections with real xxDK calls.")
+	logging.Info(ctx, "xxDK integration is simulated; replace TODO sections with real xxDK calls")
 
-	if err := http.ListenAndServe(listenAddr, nil); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	// SHUTDOWN_TIMEOUT_SECONDS: how long SIGTERM/SIGINT waits for in-flight requests.
+	drain, err := envSeconds("SHUTDOWN_TIMEOUT_SECONDS", 15*time.Second)
+	if err != nil {
+		fatal("config invalid", err)
+	}
+	httpSrv := &http.Server{Addr: listenAddr, Handler: srv, ReadHeaderTimeout: 10 * time.Second}
+	sig, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+	errc := make(chan error, 1)
+	go func() { errc <- httpSrv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		fatal("server failed", err)
+	case <-sig.Done():
+	}
+	stopSignals() // a second signal kills the process
+
+	// Stop order: drain HTTP so no new work arrives, stop the transport and
+	// close the message store, then stop background routines; state
+	// persistence flushes once more as it exits.
+	logging.Info(ctx, "shutdown: draining", logging.Public("timeout", drain))
+	dctx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	if err := httpSrv.Shutdown(dctx); err != nil {
+		logging.Warn(ctx, "shutdown: drain incomplete", logging.Err(err))
+	}
+	if ms != nil {
+		_ = ms.Shutdown(dctx)
+	}
+	failed := false
+	if deps != nil {
+		if err := deps.stop(); err != nil {
+			logging.Error(ctx, "shutdown: stopping phase1 services failed", logging.Err(err))
+			failed = true
+		}
+	}
+	stopBackground()
+	if stateFlushed != nil {
+		<-stateFlushed
+	}
+	if failed {
+		os.Exit(1)
 	}
+	logging.Info(ctx, "shutdown: stopped")
 }
@@ -0,0 +1,111 @@
+#!/usr/bin/env bash
+# Compatibility matrix for the messaging API: every legacy request shape must
+# still produce its legacy response shape, backed by the same Orchestrator/Store
+# as /v1, and must be refused (401) without a Phase-1 session. Each case fails
+# the script if the shape regresses.
+set -euo pipefail
+
+: "${BASE:?set BASE like http://127.0.0.1:8090}"
+: "${JWT:?set JWT}"
+: "${PEER_FP:=fp_dummy_local}"
+
+AUTH=(-H "Authorization: Bearer $JWT" -H "Content-Type: application/json")
+
+echo "== ensure conversation =="
+CONV=$(curl -sS -X POST "$BASE/conversation/create" "${AUTH[@]}" \
+  -d "{\"peerFingerprint\":\"$PEER_FP\"}" | jq -er '.conversationId')
+echo "CONV=$CONV"
+
+issue() { # purpose [conversationId]
+  local conv="null"
+  [ -n "${2:-}" ] && conv="\"$2\""
+  curl -sS -X POST "$BASE/session/issue" "${AUTH[@]}" \
+    -d "{\"purpose\":\"$1\",\"conversationId\":$conv}" | jq -er '.sessionId'
+}
+
+PT_B64=$(printf 'compat %s\n' "$(date -u +%FT%TZ)" | base64 -w0)
+
+echo
+echo "== [v1] POST /v1/message/send -> {status,serverTime} =="
+curl -sS -X POST "$BASE/v1/message/send" "${AUTH[@]}" \
+  -d "{\"sessionId\":\"$(issue message_send "$CONV")\",\"conversationId\":\"$CONV\",\"plaintextB64\":\"$PT_B64\"}" \
+  | jq -e '.status == "Sent" and (.envelopeFingerprint | length > 0) and (.serverTime | type == "string")'
+
+echo
+echo "== [v1] POST /v1/message/inbox -> {items[],serverTime} =="
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" \
+  -d "{\"sessionId\":\"$(issue message_receive)\",\"limit\":5}" \
+  | jq -e '(.items | type == "array") and (.items[0] | has("envelopeFingerprint"))'
+
+echo
+echo "== [phase1 alias] POST /message/send (same shape as /v1) =="
+curl -sS -X POST "$BASE/message/send" "${AUTH[@]}" \
+  -d "{\"sessionId\":\"$(issue message_send "$CONV")\",\"conversationId\":\"$CONV\",\"plaintextB64\":\"$PT_B64\"}" \
+  | jq -e '.status == "Sent"'
+
+echo
+echo "== [phase1 alias] POST /message/thread =="
+curl -sS -X POST "$BASE/message/thread" "${AUTH[@]}" \
+  -d "{\"sessionId\":\"$(issue message_receive "$CONV")\",\"conversationId\":\"$CONV\",\"limit\":5}" \
+  | jq -e '.items | type == "array"'
+
+# Legacy shapes name their Phase-1 session in X-Session-Id.
+sess() { echo "X-Session-Id: $(issue "$@")"; }
+
+echo
+echo "== [core proxy] POST /message/send {v,type:message_send} -> message_send_ack =="
+ACK=$(curl -sS -D /tmp/compat_hdr -X POST "$BASE/message/send?conversationId=$CONV" "${AUTH[@]}" \
+  -H "$(sess message_send "$CONV")" \
+  -d '{"v":1,"type":"message_send","requestId":"compat-1","message":"hello"}')
+echo "$ACK" | jq -e '.v == 1 and .type == "message_send_ack" and .requestId == "compat-1" and .ok == true and (.messageId | length > 0)'
+grep -qi '^Deprecation: true' /tmp/compat_hdr
+MID=$(echo "$ACK" | jq -r .messageId)
+
+echo
+echo "== [core proxy] POST /message/send without session -> 401 =="
+curl -sS -o /dev/null -w '%{http_code}' -X POST "$BASE/message/send?conversationId=$CONV" "${AUTH[@]}" \
+  -d '{"v":1,"type":"message_send","requestId":"compat-1b","message":"hello"}' | grep -qx 401
+
+echo
+echo "== [core proxy] GET /message/inbox -> message_inbox, messageId is the stored ID =="
+curl -sS "$BASE/message/inbox" -H "Authorization: Bearer $JWT" -H "X-Request-Id: compat-2" \
+  -H "$(sess message_receive)" \
+  | jq -e --arg id "$MID" '.v == 1 and .type == "message_inbox" and .ok == true and (.messages | type == "array") and any(.messages[]; .id == $id and .sender == "")'
+
+echo
+echo "== [core proxy] GET /message/inbox without session -> 401 =="
+curl -sS -o /dev/null -w '%{http_code}' "$BASE/message/inbox" -H "Authorization: Bearer $JWT" | grep -qx 401
+
+echo
+echo "== [core proxy] POST /message/send without conversationId -> ok:false =="
+curl -sS -X POST "$BASE/message/send" "${AUTH[@]}" \
+  -d '{"v":1,"type":"message_send","requestId":"compat-3","message":"hello"}' \
+  | jq -e '.type == "message_send_ack" and .ok == false'
+
+echo
+echo "== [in-memory] POST /messages/send {recipient,message} -> {msg_id,status} =="
+MID2=$(curl -sS -X POST "$BASE/messages/send" "${AUTH[@]}" -H "$(sess message_send "$CONV")" \
+  -d "{\"recipient\":\"$PEER_FP\",\"message\":\"hello\"}" \
+  | jq -er 'select(.status == "queued") | .msg_id')
+
+echo
+echo "== [in-memory] POST /messages/send without session -> 401 =="
+curl -sS -o /dev/null -w '%{http_code}' -X POST "$BASE/messages/send" "${AUTH[@]}" \
+  -d "{\"recipient\":\"$PEER_FP\",\"message\":\"hello\"}" | grep -qx 401
+
+echo
+echo "== [in-memory] POST /messages/send missing fields -> MISSING_FIELDS =="
+curl -sS -X POST "$BASE/messages/send" "${AUTH[@]}" -d '{"recipient":""}' \
+  | jq -e '.code == "MISSING_FIELDS"'
+
+echo
+echo "== [in-memory] GET /messages/inbox -> {messages[]}, msg_id is the stored ID =="
+curl -sS "$BASE/messages/inbox" -H "Authorization: Bearer $JWT" -H "$(sess message_receive)" \
+  | jq -e --arg id "$MID2" 'any(.messages[]; .id == $id and .from == "" and has("createdAt"))'
+
+echo
+echo "== [in-memory] GET /messages/inbox without session -> 401 =="
+curl -sS -o /dev/null -w '%{http_code}' "$BASE/messages/inbox" -H "Authorization: Bearer $JWT" | grep -qx 401
+
+echo
+echo "compat matrix OK"
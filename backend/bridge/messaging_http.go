@@ -0,0 +1,329 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/messages"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/sessions"
+)
+
+/*
+Messaging routes (canonical, backed by messages.Orchestrator + messages.Store):
+- POST /v1/message/send
+- POST /v1/message/inbox     (inbox scope fetch)
+- POST /v1/message/thread    (conversation-scoped fetch)
+- POST /v1/message/ack       (consume/ack)
+
+Unversioned aliases (same shapes, Phase-1 clients):
+- POST /message/send, /message/inbox, /message/thread, /message/ack
+
+Legacy shapes are adapters onto the same handlers (see messaging_legacy.go):
+- POST /message/send  with {"v":1,"type":"message_send",...}  (core proxy shape)
+- GET  /message/inbox                                         (core proxy shape)
+- POST /messages/send, GET /messages/inbox                    (in-memory shape)
+Their bodies have no sessionId field, so they name the Phase-1 session in
+X-Session-Id and pass the same purpose, scope and proof checks.
+
+Sessions issued with a proofKey also require a DPoP proof header on every
+call (see requirePhase1Session); withProofBody hashes the body for it.
+
+This is the only place message routes are registered.
+*/
+func (s *server) registerMessagingRoutes(d *phase1Deps, sessMgr *sessions.Manager) {
+	msgStore, orch := d.msgStore, d.orch
+
+	// ---- POST /v1/message/send ----
+	type sendRequestP1 struct {
+		SessionID      string `json:"sessionId"`
+		ConversationID string `json:"conversationId"`
+		PlaintextB64   string `json:"plaintextB64"`
+	}
+	type sendResponseP1 struct {
+		Status              string `json:"status"`
+		EnvelopeFingerprint string `json:"envelopeFingerprint"`
+		ServerTime          string `json:"serverTime"`
+	}
+
+	send := func(w http.ResponseWriter, r *http.Request) {
+		noStore(w)
+		if r.Method != http.MethodPost {
+			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
+			return
+		}
+
+		ownerSubject, ok := mustAuthSubject(r)
+		if !ok {
+			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
+		var req sendRequestP1
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.SessionID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+			return
+		}
+		if req.ConversationID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "conversationId_required"})
+			return
+		}
+		if req.PlaintextB64 == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "plaintextB64_required"})
+			return
+		}
+
+		// Validate session (purpose-scoped)
+		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageSend), ConversationID: req.ConversationID}
+		if err := requirePhase1Session(sessMgr, r, key, req.SessionID); err != nil {
+			writePhase1SessionError(w, err)
+			return
+		}
+
+		pt, err := base64.StdEncoding.DecodeString(req.PlaintextB64)
+		if err != nil || len(pt) == 0 {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_plaintextB64"})
+			return
+		}
+
+		fp, err := orch.SendText(r.Context(), ownerSubject, req.ConversationID, pt)
+		if err != nil {
+			if errors.Is(err, messages.ErrGroupOwnerOnly) {
+				writeJSONP1(w, http.StatusForbidden, map[string]any{"error": "forbidden", "detail": "group_owner_only"})
+				return
+			}
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "send_failed", "detail": err.Error()})
+			return
+		}
+
+		writeJSONP1(w, http.StatusOK, sendResponseP1{Status: "Sent", EnvelopeFingerprint: fp, ServerTime: time.Now().UTC().Format(time.RFC3339)})
+	}
+
+	// ---- POST /v1/message/inbox (inbox scope) ----
+	type inboxRequestP1 struct {
+		SessionID string `json:"sessionId"`
+		Limit     int    `json:"limit,omitempty"`
+	}
+	type inboxItemP1 struct {
+		ConversationID       string `json:"conversationId"`
+		PayloadCiphertextB64 string `json:"payloadCiphertextB64"`
+		EnvelopeFingerprint  string `json:"envelopeFingerprint,omitempty"`
+		CreatedAtUnix        int64  `json:"createdAtUnix"`
+		ExpiresAtUnix        int64  `json:"expiresAtUnix,omitempty"`
+		State                string `json:"state"`
+	}
+	type inboxResponseP1 struct {
+		Items      []inboxItemP1 `json:"items"`
+		ServerTime string        `json:"serverTime"`
+	}
+
+	inbox := func(w http.ResponseWriter, r *http.Request) {
+		noStore(w)
+		if r.Method != http.MethodPost {
+			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
+			return
+		}
+
+		ownerSubject, ok := mustAuthSubject(r)
+		if !ok {
+			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
+		var req inboxRequestP1
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.SessionID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+			return
+		}
+
+		// inbox scope requires conversationId="" in session key
+		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: ""}
+		if err := requirePhase1Session(sessMgr, r, key, req.SessionID); err != nil {
+			writePhase1SessionError(w, err)
+			return
+		}
+
+		items, err := msgStore.FetchInbox(ownerSubject, req.Limit)
+		if err != nil {
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "fetch_failed", "detail": err.Error()})
+			return
+		}
+
+		resp := inboxResponseP1{ServerTime: time.Now().UTC().Format(time.RFC3339)}
+		for _, it := range items {
+			resp.Items = append(resp.Items, inboxItemP1{
+				ConversationID:       it.ConversationID,
+				PayloadCiphertextB64: it.PayloadCiphertextB64,
+				EnvelopeFingerprint:  it.EnvelopeFingerprint,
+				CreatedAtUnix:        it.CreatedAtUnix,
+				ExpiresAtUnix:        it.ExpiresAtUnix,
+				State:                it.State,
+			})
+		}
+		writeJSONP1(w, http.StatusOK, resp)
+	}
+
+	// ---- POST /v1/message/thread (conversation scope) ----
+	type threadRequestP1 struct {
+		SessionID      string `json:"sessionId"`
+		ConversationID string `json:"conversationId"`
+		Limit          int    `json:"limit,omitempty"`
+
+		IncludeConsumed *bool `json:"includeConsumed,omitempty"`
+	}
+	type threadResponseP1 struct {
+		Items      []inboxItemP1 `json:"items"`
+		ServerTime string        `json:"serverTime"`
+	}
+
+	thread := func(w http.ResponseWriter, r *http.Request) {
+		noStore(w)
+		if r.Method != http.MethodPost {
+			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
+			return
+		}
+
+		ownerSubject, ok := mustAuthSubject(r)
+		if !ok {
+			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
+		var req threadRequestP1
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.SessionID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+			return
+		}
+		if req.ConversationID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "conversationId_required"})
+			return
+		}
+
+		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: req.ConversationID}
+		if err := requirePhase1Session(sessMgr, r, key, req.SessionID); err != nil {
+			writePhase1SessionError(w, err)
+			return
+		}
+
+		includeConsumed := true
+		if req.IncludeConsumed != nil {
+			includeConsumed = *req.IncludeConsumed
+		}
+
+		items, err := msgStore.FetchThread(ownerSubject, req.ConversationID, req.Limit, includeConsumed)
+		if err != nil {
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "fetch_failed", "detail": err.Error()})
+			return
+		}
+
+		resp := threadResponseP1{ServerTime: time.Now().UTC().Format(time.RFC3339)}
+		for _, it := range items {
+			resp.Items = append(resp.Items, inboxItemP1{
+				ConversationID:       it.ConversationID,
+				PayloadCiphertextB64: it.PayloadCiphertextB64,
+				EnvelopeFingerprint:  it.EnvelopeFingerprint,
+				CreatedAtUnix:        it.CreatedAtUnix,
+				ExpiresAtUnix:        it.ExpiresAtUnix,
+				State:                it.State,
+			})
+		}
+		writeJSONP1(w, http.StatusOK, resp)
+	}
+
+	// ---- POST /v1/message/ack (consume) ----
+	type ackRequestP1 struct {
+		SessionID            string   `json:"sessionId"`
+		ConversationID       string   `json:"conversationId,omitempty"` // "" allowed only with inbox-scoped receive session
+		EnvelopeFingerprints []string `json:"envelopeFingerprints"`
+	}
+	type ackResponseP1 struct {
+		Status     string `json:"status"`
+		Acked      int    `json:"acked"`
+		ServerTime string `json:"serverTime"`
+	}
+
+	ack := func(w http.ResponseWriter, r *http.Request) {
+		noStore(w)
+		if r.Method != http.MethodPost {
+			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
+			return
+		}
+
+		ownerSubject, ok := mustAuthSubject(r)
+		if !ok {
+			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+
+		var req ackRequestP1
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		if req.SessionID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+			return
+		}
+		if len(req.EnvelopeFingerprints) == 0 {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "envelopeFingerprints_required"})
+			return
+		}
+
+		// Validate correct receive session scope:
+		// - If conversationId provided => must be conversation-scoped receive session
+		// - If conversationId empty    => must be inbox-scoped receive session
+		if strings.TrimSpace(req.ConversationID) != "" {
+			key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: req.ConversationID}
+			if err := requirePhase1Session(sessMgr, r, key, req.SessionID); err != nil {
+				writePhase1SessionError(w, err)
+				return
+			}
+		} else {
+			key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: ""}
+			if err := requirePhase1Session(sessMgr, r, key, req.SessionID); err != nil {
+				writePhase1SessionError(w, err)
+				return
+			}
+		}
+
+		acked, err := msgStore.AckAvailable(ownerSubject, req.ConversationID, req.EnvelopeFingerprints)
+		if err != nil {
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "ack_failed", "detail": err.Error()})
+			return
+		}
+
+		writeJSONP1(w, http.StatusOK, ackResponseP1{
+			Status:     "ok",
+			Acked:      acked,
+			ServerTime: time.Now().UTC().Format(time.RFC3339),
+		})
+	}
+
+	s.handle("/v1/message/send", s.auth(withProofBody(send)))
+	s.handle("/v1/message/inbox", s.auth(withProofBody(inbox)))
+	s.handle("/v1/message/thread", s.auth(withProofBody(thread)))
+	s.handle("/v1/message/ack", s.auth(withProofBody(ack)))
+
+	legacy := newLegacyMessaging(d.convRepo, msgStore, orch, sessMgr)
+	s.handle("/message/send", s.auth(withProofBody(legacy.dispatchSend(send))))
+	s.handle("/message/inbox", s.auth(withProofBody(legacy.dispatchInbox(inbox))))
+	s.handle("/message/thread", s.auth(withProofBody(thread)))
+	s.handle("/message/ack", s.auth(withProofBody(ack)))
+	s.handle("/messages/send", s.auth(withProofBody(legacy.handleMemSend)))
+	s.handle("/messages/inbox", s.auth(withProofBody(legacy.handleMemInbox)))
+}
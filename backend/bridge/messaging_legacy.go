@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/messages"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/sessions"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// legacyMessaging adapts the two pre-/v1 request/response shapes onto the
+// Orchestrator + Store. Nothing here keeps its own message state.
+//
+// The old bodies carry no sessionId, so callers name a Phase-1 purpose
+// session in X-Session-Id. It passes requirePhase1Session with the purpose,
+// scope and proof checks of the /v1 route the shape maps to; without one the
+// request gets 401. Every response is marked deprecated.
+type legacyMessaging struct {
+	convRepo *conversations.Repo
+	msgStore *messages.Store
+	orch     *messages.Orchestrator
+	sessMgr  *sessions.Manager
+}
+
+func newLegacyMessaging(convRepo *conversations.Repo, msgStore *messages.Store, orch *messages.Orchestrator, sessMgr *sessions.Manager) *legacyMessaging {
+	return &legacyMessaging{convRepo: convRepo, msgStore: msgStore, orch: orch, sessMgr: sessMgr}
+}
+
+// legacySessionID returns the X-Session-Id of r, or answers 401 and returns "".
+func legacySessionID(w http.ResponseWriter, r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get(purposeSessionHeader))
+	if id == "" {
+		writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "sessionId_required"})
+	}
+	return id
+}
+
+// requireSession checks r's X-Session-Id for purpose p scoped to conversationID
+// ("" for inbox scope) and spends one use. It answers the request and returns
+// false when the session is missing or rejected.
+func (l *legacyMessaging) requireSession(w http.ResponseWriter, r *http.Request, ownerSubject string, p phase1SessionPurpose, conversationID string) bool {
+	sessionID := legacySessionID(w, r)
+	if sessionID == "" {
+		return false
+	}
+	key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(p), ConversationID: conversationID}
+	if err := requirePhase1Session(l.sessMgr, r, key, sessionID); err != nil {
+		writePhase1SessionError(w, err)
+		return false
+	}
+	return true
+}
+
+func markDeprecated(w http.ResponseWriter, successor string) {
+	w.Header().Set("Deprecation", "true")
+	w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+}
+
+// ============================
+// Core proxy shape (/message/send, GET /message/inbox)
+// ============================
+
+type legacyCoreSendReq struct {
+	V         int    `json:"v"`
+	Type      string `json:"type"` // message_send
+	RequestID string `json:"requestId"`
+	Message   string `json:"message"`
+}
+
+type legacyCoreSendAck struct {
+	V         int    `json:"v"`
+	Type      string `json:"type"` // message_send_ack
+	RequestID string `json:"requestId"`
+	Ok        bool   `json:"ok"`
+	MessageID string `json:"messageId,omitempty"`
+}
+
+type legacyCoreMessage struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationId"`
+	Sender         string    `json:"sender"`
+	Body           string    `json:"body"`
+	Timestamp      time.Time `json:"timestamp"`
+	Consumed       bool      `json:"consumed"`
+}
+
+type legacyCoreInboxResp struct {
+	V         int                 `json:"v"`
+	Type      string              `json:"type"` // message_inbox
+	RequestID string              `json:"requestId"`
+	Ok        bool                `json:"ok"`
+	Messages  []legacyCoreMessage `json:"messages"`
+	// Unreadable counts stored items that could not be opened (e.g. a group
+	// key that never arrived); they are left out of Messages.
+	Unreadable int `json:"unreadable,omitempty"`
+}
+
+// dispatchSend routes /message/send bodies typed "message_send" to the core
+// proxy adapter and everything else to the canonical handler.
+func (l *legacyMessaging) dispatchSend(canonical http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			canonical(w, r)
+			return
+		}
+		raw, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_request_body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+
+		var probe struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(raw, &probe) == nil && probe.Type == "message_send" {
+			l.handleCoreSend(w, r)
+			return
+		}
+		canonical(w, r)
+	}
+}
+
+// dispatchInbox routes GET /message/inbox to the core proxy adapter.
+func (l *legacyMessaging) dispatchInbox(canonical http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			l.handleCoreInbox(w, r)
+			return
+		}
+		canonical(w, r)
+	}
+}
+
+// POST /message/send?conversationId=... {"v":1,"type":"message_send","requestId":"...","message":"..."}
+// The old "conv-demo" fallback is gone: conversationId must name an owned
+// conversation. Needs a message_send session scoped to it (like /v1/message/send).
+func (l *legacyMessaging) handleCoreSend(w http.ResponseWriter, r *http.Request) {
+	markDeprecated(w, "/v1/message/send")
+
+	ownerSubject, ok := mustAuthSubject(r)
+	if !ok {
+		writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+		return
+	}
+	var req legacyCoreSendReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.V != 1 || req.Message == "" {
+		writeJSONP1(w, http.StatusBadRequest, legacyCoreSendAck{V: 1, Type: "message_send_ack", RequestID: req.RequestID, Ok: false})
+		return
+	}
+	convID := strings.TrimSpace(r.URL.Query().Get("conversationId"))
+	if convID == "" {
+		writeJSONP1(w, http.StatusBadRequest, legacyCoreSendAck{V: 1, Type: "message_send_ack", RequestID: req.RequestID, Ok: false})
+		return
+	}
+	if !l.requireSession(w, r, ownerSubject, purposeMessageSend, convID) {
+		return
+	}
+
+	msgID, status := l.send(r, ownerSubject, convID, req.Message)
+	writeJSONP1(w, status, legacyCoreSendAck{
+		V:         1,
+		Type:      "message_send_ack",
+		RequestID: req.RequestID,
+		Ok:        status == http.StatusOK,
+		MessageID: msgID,
+	})
+}
+
+// GET /message/inbox
+// Needs an inbox-scoped message_receive session (like /v1/message/inbox).
+func (l *legacyMessaging) handleCoreInbox(w http.ResponseWriter, r *http.Request) {
+	markDeprecated(w, "/v1/message/inbox")
+
+	ownerSubject, ok := mustAuthSubject(r)
+	if !ok {
+		writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+		return
+	}
+	if !l.requireSession(w, r, ownerSubject, purposeMessageReceive, "") {
+		return
+	}
+	reqID := logging.RequestID(r.Context())
+
+	items, err := l.msgStore.FetchInbox(ownerSubject, 0)
+	if err != nil {
+		writeJSONP1(w, http.StatusInternalServerError, legacyCoreInboxResp{V: 1, Type: "message_inbox", RequestID: reqID, Ok: false})
+		return
+	}
+	resp := legacyCoreInboxResp{V: 1, Type: "message_inbox", RequestID: reqID, Ok: true, Messages: []legacyCoreMessage{}}
+	for _, it := range items {
+		body, err := l.orch.OpenItem(it)
+		if err != nil {
+			resp.Unreadable++
+			continue
+		}
+		resp.Messages = append(resp.Messages, legacyCoreMessage{
+			ID:             it.EnvelopeFingerprint,
+			ConversationID: it.ConversationID,
+			Sender:         "", // the store does not record senders
+			Body:           string(body),
+			Timestamp:      time.Unix(it.CreatedAtUnix, 0).UTC(),
+			Consumed:       it.State == "consumed",
+		})
+	}
+	logUnreadable(r, resp.Unreadable)
+	writeJSONP1(w, http.StatusOK, resp)
+}
+
+// ============================
+// In-memory shape (/messages/send, /messages/inbox)
+// ============================
+
+// Message is the legacy inbox item shape.
+type Message struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type inboxResponse struct {
+	Messages   []Message `json:"messages"`
+	Unreadable int       `json:"unreadable,omitempty"` // see legacyCoreInboxResp
+}
+
+type sendRequest struct {
+	Recipient string `json:"recipient"`
+	Message   string `json:"message"`
+}
+
+type sendResponse struct {
+	MsgID  string `json:"msg_id"`
+	Status string `json:"status"`
+}
+
+// POST /messages/send {"recipient":"<peer fingerprint>","message":"..."}
+// The recipient resolves to the sender's existing direct conversation with
+// that peer (create it with /conversation/create, which also returns the ID
+// to scope the message_send session to). Unknown recipients get 404.
+func (l *legacyMessaging) handleMemSend(w http.ResponseWriter, r *http.Request) {
+	markDeprecated(w, "/v1/message/send")
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ownerSubject, ok := mustAuthSubject(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"error":   "unauthorized",
+			"code":    "missing_user",
+			"message": "User not found in context",
+		})
+		return
+	}
+
+	var req sendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":   "bad_request",
+			"code":    "INVALID_MESSAGE",
+			"message": "Invalid JSON body",
+		})
+		return
+	}
+	if req.Recipient == "" || req.Message == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":   "bad_request",
+			"code":    "MISSING_FIELDS",
+			"message": "recipient and message are required",
+		})
+		return
+	}
+
+	if legacySessionID(w, r) == "" {
+		return
+	}
+	conv, err := l.convRepo.FindDirect(ownerSubject, req.Recipient)
+	if errors.Is(err, store.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]any{
+			"error":   "not_found",
+			"code":    "UNKNOWN_RECIPIENT",
+			"message": "no conversation with this recipient",
+		})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error":   "internal_error",
+			"code":    "SEND_FAILED",
+			"message": "could not resolve recipient",
+		})
+		return
+	}
+	if !l.requireSession(w, r, ownerSubject, purposeMessageSend, conv.ConversationID) {
+		return
+	}
+	msgID, status := l.send(r, ownerSubject, conv.ConversationID, req.Message)
+	if status != http.StatusOK {
+		writeJSON(w, status, map[string]any{
+			"error":   "send_failed",
+			"code":    "SEND_FAILED",
+			"message": "message could not be sent",
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, sendResponse{MsgID: msgID, Status: "queued"})
+}
+
+// GET /messages/inbox
+// Needs an inbox-scoped message_receive session (like /v1/message/inbox).
+func (l *legacyMessaging) handleMemInbox(w http.ResponseWriter, r *http.Request) {
+	markDeprecated(w, "/v1/message/inbox")
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ownerSubject, ok := mustAuthSubject(r)
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"error":   "unauthorized",
+			"code":    "missing_user",
+			"message": "User not found in context",
+		})
+		return
+	}
+	if !l.requireSession(w, r, ownerSubject, purposeMessageReceive, "") {
+		return
+	}
+
+	items, err := l.msgStore.FetchInbox(ownerSubject, 0)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{
+			"error":   "internal_error",
+			"code":    "FETCH_FAILED",
+			"message": "inbox unavailable",
+		})
+		return
+	}
+	resp := inboxResponse{Messages: []Message{}}
+	for _, it := range items {
+		body, err := l.orch.OpenItem(it)
+		if err != nil {
+			resp.Unreadable++
+			continue
+		}
+		resp.Messages = append(resp.Messages, Message{
+			ID:        it.EnvelopeFingerprint,
+			From:      "", // the store does not record senders
+			To:        ownerSubject,
+			Body:      string(body),
+			CreatedAt: time.Unix(it.CreatedAtUnix, 0).UTC(),
+		})
+	}
+	logUnreadable(r, resp.Unreadable)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// logUnreadable reports inbox items the legacy adapters could not open.
+func logUnreadable(r *http.Request, n int) {
+	if n > 0 {
+		logging.Warn(r.Context(), "legacy inbox items unreadable", logging.Public("count", n))
+	}
+}
+
+// send is the shared legacy send path: it goes through the Orchestrator like /v1.
+// Returns the stored message's envelope fingerprint, the ID the inbox adapters
+// report and /v1/message/ack takes, and the HTTP status to report.
+func (l *legacyMessaging) send(r *http.Request, ownerSubject, conversationID, body string) (string, int) {
+	msgID, err := l.orch.SendText(r.Context(), ownerSubject, conversationID, []byte(body))
+	if err != nil {
+		if errors.Is(err, messages.ErrUnknownConversation) {
+			return "", http.StatusNotFound
+		}
+		if errors.Is(err, messages.ErrGroupOwnerOnly) {
+			return "", http.StatusForbidden
+		}
+		return "", http.StatusBadGateway
+	}
+	return msgID, http.StatusOK
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/messages"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/sessions"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
+	"github.com/Bulldog-Master/privxx/backend/core/metrics"
+)
+
+// defaultMetricsAddr is the metrics listener when METRICS_ADDR is unset.
+const defaultMetricsAddr = "127.0.0.1:9190"
+
+// metricSet holds the bridge's Prometheus metrics, served on their own
+// loopback listener (METRICS_ADDR). Labels come from fixed sets: route
+// patterns, methods, statuses, policies and reasons. Nothing identifies a
+// user, client IP or session.
+type metricSet struct {
+	reg              *metrics.Registry
+	requests         *metrics.Counter
+	duration         *metrics.Histogram
+	lockouts         *metrics.Counter
+	rateLimited      *metrics.Counter
+	phase1Sessions   *metrics.Counter
+	sendErrors       *metrics.Counter
+	fsync            *metrics.Histogram
+	authCacheLookups *metrics.Counter
+}
+
+var bridgeMetrics = newMetricSet()
+
+func newMetricSet() *metricSet {
+	reg := metrics.NewRegistry()
+	m := &metricSet{
+		reg: reg,
+		requests: reg.Counter("privxx_bridge_http_requests_total",
+			"HTTP requests by route, method and status.", "route", "method", "status"),
+		duration: reg.Histogram("privxx_bridge_http_request_duration_seconds",
+			"HTTP request latency by route and method.", metrics.DefBuckets, "route", "method"),
+		lockouts: reg.Counter("privxx_bridge_ratelimit_lockouts_total",
+			"Clients locked out after too many failed authentications."),
+		rateLimited: reg.Counter("privxx_bridge_ratelimit_rejections_total",
+			"Requests refused by a rate limit, by policy and reason.", "policy", "reason"),
+		phase1Sessions: reg.Counter("privxx_bridge_phase1_sessions_total",
+			"Phase-1 sessions by result (issued, issue_failed, validated, rejected) and reason; issued sessions are labeled with their purpose.", "result", "reason"),
+		sendErrors: reg.Counter("privxx_bridge_transport_send_errors_total",
+			"Envelopes the transport failed to send."),
+		fsync: reg.Histogram("privxx_bridge_messages_fsync_seconds",
+			"Latency of message store log fsyncs.", metrics.DefBuckets),
+		authCacheLookups: reg.Counter("privxx_bridge_auth_cache_lookups_total",
+			"Token verification cache lookups by result (hit, negative_hit, miss, shared).", "result"),
+	}
+	reg.GaugeFunc("privxx_bridge_identity_sessions", "Unexpired identity unlocks.",
+		func() float64 { return float64(identityManager.active()) })
+	reg.GaugeFunc("privxx_bridge_auth_cache_entries", "Cached token verification results.",
+		func() float64 {
+			if endpointCache == nil {
+				return 0
+			}
+			return float64(endpointCache.Stats().Entries)
+		})
+	reg.GaugeFunc("privxx_bridge_backend_up", "1 if the last core health probe succeeded.",
+		func() float64 { return boolGauge(backendClient != nil && backendClient.Status().Reachable) })
+	reg.GaugeFunc("privxx_bridge_backend_xxdk_ready", "1 if core reported xxDK ready on the last probe.",
+		func() float64 { return boolGauge(backendClient != nil && backendClient.XXDKReady()) })
+	reg.GaugeFunc("privxx_bridge_backend_circuit_open", "1 while the circuit breaker to core is not closed.",
+		func() float64 { return boolGauge(backendClient != nil && backendClient.Status().Circuit != "closed") })
+	reg.GaugeFunc("privxx_bridge_backend_consecutive_failures", "Consecutive failed core health probes.",
+		func() float64 {
+			if backendClient == nil {
+				return 0
+			}
+			return float64(backendClient.Status().Consecutive)
+		})
+	return m
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// observeRequest records one request. route is a registered pattern or
+// "unmatched" and method is bucketed, so neither can grow the label set.
+func (m *metricSet) observeRequest(r *http.Request, route string, status int, d time.Duration) {
+	if route == "" || route == "/" {
+		route = "unmatched"
+	}
+	method := metrics.MethodLabel(r.Method)
+	m.requests.Inc(route, method, strconv.Itoa(status))
+	m.duration.Observe(d.Seconds(), route, method)
+}
+
+// phase1Validation records the outcome of requirePhase1Session.
+func (m *metricSet) phase1Validation(err error) {
+	if err == nil {
+		m.phase1Sessions.Inc("validated", "ok")
+		return
+	}
+	m.phase1Sessions.Inc("rejected", phase1RejectReason(err))
+}
+
+// phase1RejectReason maps a session error to a fixed label.
+func phase1RejectReason(err error) string {
+	switch {
+	case errors.Is(err, sessions.ErrSessionNotFound):
+		return "not_found"
+	case errors.Is(err, sessions.ErrSessionExpired):
+		return "expired"
+	case errors.Is(err, sessions.ErrSessionMaxAge):
+		return "max_age"
+	case errors.Is(err, sessions.ErrSessionRevoked):
+		return "revoked"
+	case errors.Is(err, sessions.ErrSessionForbidden):
+		return "forbidden"
+	case errors.Is(err, sessions.ErrInvalidPurpose):
+		return "purpose"
+	case errors.Is(err, errPhase1SessionScope):
+		return "scope"
+	case errors.Is(err, sessions.ErrProofRequired):
+		return "proof_required"
+	case errors.Is(err, sessions.ErrProofReplayed):
+		return "proof_replayed"
+	case errors.Is(err, sessions.ErrProofStale):
+		return "proof_stale"
+	case errors.Is(err, sessions.ErrProofInvalid):
+		return "proof_invalid"
+	case errors.Is(err, sessions.ErrSessionUsed):
+		return "used"
+	default:
+		return "other"
+	}
+}
+
+// observeMessageStore reports st's sizes and fsync latency.
+func (m *metricSet) observeMessageStore(st *messages.Store) {
+	st.ObserveSync(func(d time.Duration) { m.fsync.Observe(d.Seconds()) })
+	stat := func(f func(messages.Stats) float64) func() float64 {
+		return func() float64 {
+			s, err := st.Stats()
+			if err != nil {
+				return 0
+			}
+			return f(s)
+		}
+	}
+	m.reg.GaugeFunc("privxx_bridge_messages_log_bytes", "Size of the message store log.",
+		stat(func(s messages.Stats) float64 { return float64(s.LogBytes) }))
+	m.reg.GaugeFunc("privxx_bridge_messages_index_bytes", "Size of the message store index.",
+		stat(func(s messages.Stats) float64 { return float64(s.IndexBytes) }))
+	m.reg.GaugeFunc("privxx_bridge_messages_stored", "Messages in the message store.",
+		stat(func(s messages.Stats) float64 { return float64(s.Messages) }))
+}
+
+// countingAdapter counts transport send failures.
+type countingAdapter struct {
+	transport.Adapter
+	errs *metrics.Counter
+}
+
+func (a countingAdapter) Send(ctx context.Context, envelope []byte) error {
+	err := a.Adapter.Send(ctx, envelope)
+	if err != nil {
+		a.errs.Inc()
+	}
+	return err
+}
+
+// metricsAddr reads METRICS_ADDR: unset means defaultMetricsAddr, set but
+// empty disables the listener. Non-loopback addresses are refused.
+func metricsAddr() (string, error) {
+	v, ok := os.LookupEnv("METRICS_ADDR")
+	if !ok {
+		return defaultMetricsAddr, nil
+	}
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", nil
+	}
+	if err := metrics.CheckLocal(v); err != nil {
+		return "", fmt.Errorf("METRICS_ADDR: %w", err)
+	}
+	return v, nil
+}
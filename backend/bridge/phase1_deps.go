@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/contacts"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/invites"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/messages"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// Phase-1 envelope cap (matches transport.MockAdapter default).
+const phase1MaxEnvelopeBytes = 4096
+
+// phase1Deps holds the stores and services behind the Phase-1 routes.
+type phase1Deps struct {
+	convRepo    *conversations.Repo
+	contactRepo *contacts.Repo
+	msgStore    *messages.Store
+	tx          transport.Adapter
+	orch        *messages.Orchestrator
+	invites     *invites.Service
+	inviteStore *invites.Store
+}
+
+// loadPhase1Deps builds Phase-1 dependencies from the environment:
+//   - BRIDGE_DATA_DIR      state directory (default ./data)
+//   - BRIDGE_CONTACTS_KEY  hex-encoded 32-byte key sealing the contact book (required)
+//
+// Transport is the mock adapter until the cMixx adapter is wired to a live client.
+func loadPhase1Deps() (*phase1Deps, error) {
+	dataDir := strings.TrimSpace(os.Getenv("BRIDGE_DATA_DIR"))
+	if dataDir == "" {
+		dataDir = "./data"
+	}
+	contactsKey, err := hex.DecodeString(strings.TrimSpace(os.Getenv("BRIDGE_CONTACTS_KEY")))
+	if err != nil || len(contactsKey) != 32 {
+		return nil, fmt.Errorf("BRIDGE_CONTACTS_KEY must be 32 bytes hex")
+	}
+	tx := countingAdapter{Adapter: transport.NewMockAdapter(phase1MaxEnvelopeBytes), errs: bridgeMetrics.sendErrors}
+	d, err := newPhase1Deps(dataDir, contactsKey, tx)
+	if err != nil {
+		return nil, err
+	}
+	bridgeMetrics.observeMessageStore(d.msgStore)
+	return d, nil
+}
+
+func newPhase1Deps(dataDir string, contactsKey []byte, tx transport.Adapter) (*phase1Deps, error) {
+	kv, err := store.NewFileKV(filepath.Join(dataDir, "conversations"))
+	if err != nil {
+		return nil, err
+	}
+	convRepo := conversations.NewRepo(kv)
+
+	contactRepo, err := contacts.NewRepo(filepath.Join(dataDir, "contacts"), contactsKey)
+	if err != nil {
+		return nil, err
+	}
+	msgStore, err := messages.NewStore(filepath.Join(dataDir, "messages"))
+	if err != nil {
+		return nil, err
+	}
+	orch, err := messages.NewOrchestrator(convRepo, msgStore, tx, phase1MaxEnvelopeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Identity keys are per auth subject (see contacts.Repo.LocalIdentity).
+	if err := orch.SetIdentities(contactRepo.LocalIdentity, contactRepo.SubjectForIdentity); err != nil {
+		return nil, err
+	}
+	// Group epoch keys are sealed with the contact book key.
+	if err := orch.PersistGroupKeys(filepath.Join(dataDir, "messages", "groupkeys.sealed"), contactsKey); err != nil {
+		return nil, err
+	}
+	orch.ObservePeerKeys(func(ctx context.Context, ownerSubject, peerFingerprint string, senderKey []byte) {
+		if _, changed, err := contactRepo.ObserveKey(ownerSubject, peerFingerprint, senderKey); err != nil {
+			logging.Warn(ctx, "contact key check failed", logging.Err(err))
+		} else if changed {
+			logging.Info(ctx, "contact identity key changed", logging.Pseudonym("subject", ownerSubject))
+		}
+	})
+
+	inviteStore, err := invites.NewStore(filepath.Join(dataDir, "invites"))
+	if err != nil {
+		return nil, err
+	}
+	receptionRef := tx.ReceptionRef()
+	if len(receptionRef) == 0 {
+		return nil, fmt.Errorf("transport has no reception identity; invites cannot be issued")
+	}
+	inviteSvc, err := invites.NewService(inviteStore, convRepo, contactRepo.LocalIdentity, receptionRef, tx)
+	if err != nil {
+		return nil, err
+	}
+	orch.HandleEnvelopeType(invites.HandshakeEnvelopeType, inviteSvc.OnHandshake)
+	orch.HandleEnvelopeType(invites.HandshakeAckEnvelopeType, inviteSvc.OnHandshakeAck)
+
+	if err := tx.SetReceiveHandler(orch.OnReceiveEnvelope); err != nil {
+		return nil, err
+	}
+	return &phase1Deps{
+		convRepo:    convRepo,
+		contactRepo: contactRepo,
+		msgStore:    msgStore,
+		tx:          tx,
+		orch:        orch,
+		invites:     inviteSvc,
+		inviteStore: inviteStore,
+	}, nil
+}
+
+// Invite records are kept this long after expiry so clients still see the
+// final state, then dropped by the cleanup started in start.
+const (
+	inviteCleanupEvery = time.Hour
+	inviteRetention    = 7 * 24 * time.Hour
+)
+
+// start begins transport delivery and invite cleanup, both until ctx is done;
+// call after the route table is built.
+func (d *phase1Deps) start(ctx context.Context) error {
+	d.inviteStore.StartCleanup(ctx, inviteCleanupEvery, inviteRetention)
+	return d.tx.Start(ctx)
+}
+
+// stop ends delivery, then closes the message store, in that order, so no
+// envelope is received into a closed store. Call after HTTP has drained. The
+// other stores write and fsync on every change and need no close.
+func (d *phase1Deps) stop() error {
+	txErr := d.tx.Stop()
+	if err := d.msgStore.Close(); err != nil {
+		return err
+	}
+	return txErr
+}
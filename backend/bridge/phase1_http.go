@@ -1,18 +1,20 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/contacts"
 	"github.com/Bulldog-Master/privxx/backend/bridge/internal/conversations"
-	"github.com/Bulldog-Master/privxx/backend/bridge/internal/messages"
-	"github.com/Bulldog-Master/privxx/backend/bridge/internal/transport"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
 )
 
-
 type phase1SessionPurpose string
 
 const (
@@ -24,12 +26,9 @@ type phase1SessionKey struct {
 	OwnerSubject   string
 	Purpose        string
 	ConversationID string // empty = inbox scope (allowed only for message_receive)
+	Domain         string // target host for domain-scoped purposes (browse_fetch, connect)
 }
 
-
-
-
-
 // Cache-Control: no-store (required)
 func noStore(w http.ResponseWriter) {
 	w.Header().Set("Cache-Control", "no-store")
@@ -44,65 +43,46 @@ func writeJSONP1(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-// Helper: get auth subject from existing auth middleware.
-// Primary: existing bridge convention header X-User-Id.
-// Secondary: consume what auth middleware already set in context.
+// mustAuthSubject returns the subject of the verified principal.
+// Request headers are never consulted (see server.ServeHTTP).
 func mustAuthSubject(r *http.Request) (string, bool) {
-	if s := strings.TrimSpace(r.Header.Get("X-User-Id")); s != "" {
-		return s, true
-	}
-	if v := r.Context().Value("auth_subject"); v != nil {
-		if s, ok := v.(string); ok && s != "" {
-			return s, true
-		}
-	}
-	if v := r.Context().Value("sub"); v != nil {
-		if s, ok := v.(string); ok && s != "" {
-			return s, true
-		}
-	}
-	return "", false
+	s := authSubject(r)
+	return s, s != ""
 }
 
 /*
 Phase-1 routes ONLY (canonical):
-- POST /session/issue (purpose-scoped)
+- POST /session/issue (purpose-scoped), /session/refresh, /session/revoke, /session/list
 - POST /conversation/create
-- POST /message/send
-- POST /message/inbox     (inbox scope fetch)
-- POST /message/thread    (conversation-scoped fetch)
-- POST /message/ack       (consume/ack)
+- POST /conversation/members, /conversation/members/add, /conversation/members/remove
+- /v1/message/* (and legacy aliases) via registerMessagingEndpoints
 */
-func registerPhase1Endpoints(
-	convRepo *conversations.Repo,
-	msgStore *messages.Store,
-	tx transport.Adapter,
-	orch *messages.Orchestrator,
-) {
-	_ = tx
-        sessMgr := phase1SessionMgr
-	// ---- POST /session/issue (purpose-scoped) ----
-	type sessionIssueRequest struct {
-		Purpose        string `json:"purpose"`
-		ConversationID string `json:"conversationId,omitempty"`
-	}
-	type sessionIssueResponse struct {
-		SessionID      string `json:"sessionId"`
-		Purpose        string `json:"purpose"`
-		ConversationID string `json:"conversationId,omitempty"`
-		ServerTime     string `json:"serverTime"`
-	}
+func (s *server) registerPhase1Routes(d *phase1Deps) {
+	convRepo, contactRepo, orch := d.convRepo, d.contactRepo, d.orch
+	sessMgr := phase1SessionMgr
+
+	// ---- /session/* (issue, refresh, revoke, list) ----
+	s.registerSessionRoutes(sessMgr)
+
 	// ---- POST /conversation/create ----
 	// Creates or returns a conversation by peerFingerprint (idempotent).
+	// kind="group" creates a new group from members instead (never idempotent).
 	type convCreateReq struct {
-		PeerFingerprint string `json:"peerFingerprint"`
-		PeerRefB64      string `json:"peerRefEncryptedB64,omitempty"` // opaque; optional
+		ContactID       string      `json:"contactId,omitempty"` // alternative to peerFingerprint
+		PeerFingerprint string      `json:"peerFingerprint"`
+		PeerRefB64      string      `json:"peerRefEncryptedB64,omitempty"` // opaque; optional
+		Kind            string      `json:"kind,omitempty"`                // "direct" (default) | "group"
+		Members         []memberReq `json:"members,omitempty"`             // group only
 	}
 	type convCreateResp struct {
 		ConversationID string `json:"conversationId"`
-		ServerTime     string `json:"serverTime"`
+		Warning        string `json:"warning,omitempty"` // "identity_key_changed" when created from a changed contact
+		// Group only: the group was created but some members did not get its
+		// key; re-sync via /conversation/members/add.
+		FanoutIncomplete bool   `json:"fanoutIncomplete,omitempty"`
+		ServerTime       string `json:"serverTime"`
 	}
-	http.HandleFunc("/conversation/create", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	s.handle("/conversation/create", s.auth(func(w http.ResponseWriter, r *http.Request) {
 		noStore(w)
 		if r.Method != http.MethodPost {
 			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
@@ -118,12 +98,55 @@ func registerPhase1Endpoints(
 			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
 			return
 		}
+		if req.Kind == conversations.KindGroup {
+			members := make([]conversations.Member, 0, len(req.Members))
+			for _, mr := range req.Members {
+				m, status, code, detail := memberFromReq(contactRepo, ownerSubject, mr)
+				if code != "" {
+					writeJSONP1(w, status, map[string]any{"error": code, "detail": detail})
+					return
+				}
+				members = append(members, m)
+			}
+			if len(members) == 0 {
+				writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "members_required"})
+				return
+			}
+			conv, err := orch.CreateGroup(r.Context(), ownerSubject, members)
+			if err != nil && conv == nil {
+				writeGroupError(w, r, "conversation_create_failed", err)
+				return
+			}
+			writeJSONP1(w, http.StatusOK, convCreateResp{
+				ConversationID:   conv.ConversationID,
+				FanoutIncomplete: groupFanoutIncomplete(r, "conversation_create", err),
+				ServerTime:       time.Now().UTC().Format(time.RFC3339),
+			})
+			return
+		}
+		var peerRef []byte
+		warning := ""
+		if strings.TrimSpace(req.ContactID) != "" {
+			if contactRepo == nil {
+				writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "contacts_unavailable"})
+				return
+			}
+			c, err := contactRepo.Get(ownerSubject, strings.TrimSpace(req.ContactID))
+			if err != nil {
+				writeJSONP1(w, http.StatusNotFound, map[string]any{"error": "not_found", "detail": "contact_not_found"})
+				return
+			}
+			req.PeerFingerprint = c.Fingerprint
+			peerRef = c.RefEncrypted
+			if c.IdentityChanged() {
+				warning = "identity_key_changed"
+			}
+		}
 		if strings.TrimSpace(req.PeerFingerprint) == "" {
 			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "peerFingerprint_required"})
 			return
 		}
-		var peerRef []byte
-		if strings.TrimSpace(req.PeerRefB64) != "" {
+		if peerRef == nil && strings.TrimSpace(req.PeerRefB64) != "" {
 			b, err := base64.StdEncoding.DecodeString(req.PeerRefB64)
 			if err != nil {
 				writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_peerRefEncryptedB64"})
@@ -133,283 +156,274 @@ func registerPhase1Endpoints(
 		}
 		conv, err := convRepo.CreateOrGetConversation(ownerSubject, req.PeerFingerprint, peerRef)
 		if err != nil {
-			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "conversation_create_failed", "detail": err.Error()})
+			logging.Error(r.Context(), "conversation create failed", logging.Err(err))
+			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "internal_error"})
 			return
 		}
 		writeJSONP1(w, http.StatusOK, convCreateResp{
 			ConversationID: conv.ConversationID,
+			Warning:        warning,
 			ServerTime:     time.Now().UTC().Format(time.RFC3339),
 		})
 	}))
 
-	// ---- POST /message/send ----
-	type sendRequestP1 struct {
-		SessionID      string `json:"sessionId"`
+	// ---- POST /conversation/members (list) ----
+	// Member fingerprints are internal-only: clients see opaque memberIds.
+	type memberItem struct {
+		MemberID     string `json:"memberId"`
+		Role         string `json:"role"`
+		JoinedAtUnix int64  `json:"joinedAtUnix"`
+	}
+	type membersReq struct {
 		ConversationID string `json:"conversationId"`
-		PlaintextB64   string `json:"plaintextB64"`
 	}
-	type sendResponseP1 struct {
-		Status     string `json:"status"`
-		ServerTime string `json:"serverTime"`
+	type membersResp struct {
+		ConversationID    string       `json:"conversationId"`
+		Members           []memberItem `json:"members"`
+		MembershipVersion uint64       `json:"membershipVersion"`
+		KeyEpoch          uint64       `json:"keyEpoch"`
+		FanoutIncomplete  bool         `json:"fanoutIncomplete,omitempty"` // see groupFanoutIncomplete
+		ServerTime        string       `json:"serverTime"`
+	}
+	membersResponse := func(conv *conversations.Conversation) membersResp {
+		resp := membersResp{
+			ConversationID:    conv.ConversationID,
+			Members:           []memberItem{},
+			MembershipVersion: conv.MembershipVersion,
+			KeyEpoch:          conv.KeyEpoch,
+			ServerTime:        time.Now().UTC().Format(time.RFC3339),
+		}
+		for _, m := range conv.Members {
+			resp.Members = append(resp.Members, memberItem{MemberID: m.MemberID, Role: m.Role, JoinedAtUnix: m.JoinedAtUnix})
+		}
+		return resp
 	}
 
-	http.HandleFunc("/message/send", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	s.handle("/conversation/members", s.auth(func(w http.ResponseWriter, r *http.Request) {
 		noStore(w)
 		if r.Method != http.MethodPost {
 			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
 			return
 		}
-
 		ownerSubject, ok := mustAuthSubject(r)
 		if !ok {
 			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
 			return
 		}
-
-		var req sendRequestP1
+		var req membersReq
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
 			return
 		}
-		if req.SessionID == "" {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
-			return
-		}
 		if req.ConversationID == "" {
 			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "conversationId_required"})
 			return
 		}
-		if req.PlaintextB64 == "" {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "plaintextB64_required"})
+		conv, err := convRepo.GetConversation(req.ConversationID)
+		if err != nil || conv.OwnerSubject != ownerSubject {
+			writeJSONP1(w, http.StatusNotFound, map[string]any{"error": "not_found"})
 			return
 		}
-
-		// Validate session (purpose-scoped)
-		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageSend), ConversationID: req.ConversationID}
-		if !requirePhase1Session(sessMgr, key, req.SessionID) {
-			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "invalid_session"})
+		if !conv.IsGroup() {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "not_a_group"})
 			return
 		}
-
-		pt, err := base64.StdEncoding.DecodeString(req.PlaintextB64)
-		if err != nil || len(pt) == 0 {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_plaintextB64"})
-			return
-		}
-
-		if _, err := orch.SendText(r.Context(), ownerSubject, req.ConversationID, pt); err != nil {
-			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "send_failed", "detail": err.Error()})
-			return
-		}
-
-		writeJSONP1(w, http.StatusOK, sendResponseP1{Status: "Sent", ServerTime: time.Now().UTC().Format(time.RFC3339)})
+		writeJSONP1(w, http.StatusOK, membersResponse(conv))
 	}))
 
-	// ---- POST /message/inbox (inbox scope) ----
-	type inboxRequestP1 struct {
-		SessionID string `json:"sessionId"`
-		Limit     int    `json:"limit,omitempty"`
-	}
-	type inboxItemP1 struct {
-		ConversationID       string `json:"conversationId"`
-		PayloadCiphertextB64 string `json:"payloadCiphertextB64"`
-		EnvelopeFingerprint  string `json:"envelopeFingerprint,omitempty"`
-		CreatedAtUnix        int64  `json:"createdAtUnix"`
-		ExpiresAtUnix        int64  `json:"expiresAtUnix,omitempty"`
-		State                string `json:"state"`
-	}
-	type inboxResponseP1 struct {
-		Items      []inboxItemP1 `json:"items"`
-		ServerTime string        `json:"serverTime"`
+	// ---- POST /conversation/members/add ----
+	type memberAddReq struct {
+		ConversationID string `json:"conversationId"`
+		memberReq
 	}
 
-	http.HandleFunc("/message/inbox", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	s.handle("/conversation/members/add", s.auth(func(w http.ResponseWriter, r *http.Request) {
 		noStore(w)
 		if r.Method != http.MethodPost {
 			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
 			return
 		}
-
 		ownerSubject, ok := mustAuthSubject(r)
 		if !ok {
 			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
 			return
 		}
-
-		var req inboxRequestP1
+		var req memberAddReq
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
 			return
 		}
-		if req.SessionID == "" {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+		if req.ConversationID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "conversationId_required"})
 			return
 		}
-
-		// inbox scope requires conversationId="" in session key
-		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: ""}
-		if !requirePhase1Session(sessMgr, key, req.SessionID) {
-			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "invalid_session"})
+		m, status, code, detail := memberFromReq(contactRepo, ownerSubject, req.memberReq)
+		if code != "" {
+			writeJSONP1(w, status, map[string]any{"error": code, "detail": detail})
 			return
 		}
-
-		items, err := msgStore.FetchInbox(ownerSubject, req.Limit)
-		if err != nil {
-			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "fetch_failed", "detail": err.Error()})
+		conv, err := orch.AddMember(r.Context(), ownerSubject, req.ConversationID, m)
+		if err != nil && conv == nil {
+			writeGroupError(w, r, "member_add_failed", err)
 			return
 		}
-
-		resp := inboxResponseP1{ServerTime: time.Now().UTC().Format(time.RFC3339)}
-		for _, it := range items {
-			resp.Items = append(resp.Items, inboxItemP1{
-				ConversationID:       it.ConversationID,
-				PayloadCiphertextB64: it.PayloadCiphertextB64,
-				EnvelopeFingerprint:  it.EnvelopeFingerprint,
-				CreatedAtUnix:        it.CreatedAtUnix,
-				ExpiresAtUnix:        it.ExpiresAtUnix,
-				State:                it.State,
-			})
-		}
+		resp := membersResponse(conv)
+		resp.FanoutIncomplete = groupFanoutIncomplete(r, "member_add", err)
 		writeJSONP1(w, http.StatusOK, resp)
 	}))
 
-	// ---- POST /message/thread (conversation scope) ----
-	type threadRequestP1 struct {
-		SessionID      string `json:"sessionId"`
+	// ---- POST /conversation/members/remove ----
+	// Removal rekeys the group (new key epoch) before any further send.
+	type memberRemoveReq struct {
 		ConversationID string `json:"conversationId"`
-		Limit          int    `json:"limit,omitempty"`
-
-		IncludeConsumed *bool `json:"includeConsumed,omitempty"`
-	}
-	type threadResponseP1 struct {
-		Items      []inboxItemP1 `json:"items"`
-		ServerTime string        `json:"serverTime"`
+		MemberID       string `json:"memberId"`
 	}
 
-	http.HandleFunc("/message/thread", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	s.handle("/conversation/members/remove", s.auth(func(w http.ResponseWriter, r *http.Request) {
 		noStore(w)
 		if r.Method != http.MethodPost {
 			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
 			return
 		}
-
 		ownerSubject, ok := mustAuthSubject(r)
 		if !ok {
 			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
 			return
 		}
-
-		var req threadRequestP1
+		var req memberRemoveReq
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
 			return
 		}
-		if req.SessionID == "" {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+		if req.ConversationID == "" || req.MemberID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "conversationId_and_memberId_required"})
 			return
 		}
-		if req.ConversationID == "" {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "conversationId_required"})
+		conv, err := orch.RemoveMember(r.Context(), ownerSubject, req.ConversationID, req.MemberID)
+		if err != nil && conv == nil {
+			writeGroupError(w, r, "member_remove_failed", err)
 			return
 		}
+		resp := membersResponse(conv)
+		resp.FanoutIncomplete = groupFanoutIncomplete(r, "member_remove", err)
+		writeJSONP1(w, http.StatusOK, resp)
+	}))
 
-		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: req.ConversationID}
-		if !requirePhase1Session(sessMgr, key, req.SessionID) {
-			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "invalid_session"})
-			return
-		}
+	s.registerMessagingRoutes(d, sessMgr)
+	s.registerContactRoutes(contactRepo)
+	s.registerInviteRoutes(d.invites)
+}
 
-		includeConsumed := true
-		if req.IncludeConsumed != nil {
-			includeConsumed = *req.IncludeConsumed
-		}
+// memberReq is a group member entry in a request body. A member is named
+// either by contactId or by identity key (plus optional transport reference);
+// the identity key is what the group key is sealed to.
+type memberReq struct {
+	ContactID            string `json:"contactId,omitempty"`
+	PeerFingerprint      string `json:"peerFingerprint,omitempty"`      // optional; must match the identity key
+	IdentityPublicKeyB64 string `json:"identityPublicKeyB64,omitempty"` // required without contactId
+	PeerRefB64           string `json:"peerRefEncryptedB64,omitempty"`  // opaque; optional
+	Role                 string `json:"role,omitempty"`                 // "admin" | "member"
+}
 
-		items, err := msgStore.FetchThread(ownerSubject, req.ConversationID, req.Limit, includeConsumed)
-		if err != nil {
-			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "fetch_failed", "detail": err.Error()})
-			return
+// memberFromReq validates a member entry from a request body and resolves it
+// to an identity key. Returns a status, error code and detail code on failure.
+func memberFromReq(contactRepo *contacts.Repo, ownerSubject string, req memberReq) (m conversations.Member, status int, code, detail string) {
+	m = conversations.Member{Role: strings.TrimSpace(req.Role)}
+	if m.Role != "" && m.Role != conversations.RoleAdmin && m.Role != conversations.RoleMember {
+		return m, http.StatusBadRequest, "bad_request", "invalid_role"
+	}
+	if id := strings.TrimSpace(req.ContactID); id != "" {
+		if contactRepo == nil {
+			return m, http.StatusBadRequest, "bad_request", "contacts_unavailable"
 		}
-
-		resp := threadResponseP1{ServerTime: time.Now().UTC().Format(time.RFC3339)}
-		for _, it := range items {
-			resp.Items = append(resp.Items, inboxItemP1{
-				ConversationID:       it.ConversationID,
-				PayloadCiphertextB64: it.PayloadCiphertextB64,
-				EnvelopeFingerprint:  it.EnvelopeFingerprint,
-				CreatedAtUnix:        it.CreatedAtUnix,
-				ExpiresAtUnix:        it.ExpiresAtUnix,
-				State:                it.State,
-			})
+		c, err := contactRepo.Get(ownerSubject, id)
+		if err != nil {
+			return m, http.StatusNotFound, "not_found", "contact_not_found"
 		}
-		writeJSONP1(w, http.StatusOK, resp)
-	}))
+		m.IdentityPublicKey = c.IdentityPublicKey
+		m.Fingerprint = c.Fingerprint
+		m.RefEncrypted = c.RefEncrypted
+		return m, 0, "", ""
+	}
 
-	// ---- POST /message/ack (consume) ----
-	type ackRequestP1 struct {
-		SessionID            string   `json:"sessionId"`
-		ConversationID       string   `json:"conversationId,omitempty"` // "" allowed only with inbox-scoped receive session
-		EnvelopeFingerprints []string `json:"envelopeFingerprints"`
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(req.IdentityPublicKeyB64))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return m, http.StatusBadRequest, "bad_request", "identityPublicKeyB64_required"
 	}
-	type ackResponseP1 struct {
-		Status     string `json:"status"`
-		Acked      int    `json:"acked"`
-		ServerTime string `json:"serverTime"`
+	m.IdentityPublicKey = key
+	m.Fingerprint = contacts.Fingerprint(key)
+	if fp := strings.TrimSpace(req.PeerFingerprint); fp != "" && fp != m.Fingerprint {
+		return m, http.StatusBadRequest, "bad_request", "peerFingerprint_mismatch"
 	}
-
-	http.HandleFunc("/message/ack", authMiddleware(func(w http.ResponseWriter, r *http.Request) {
-		noStore(w)
-		if r.Method != http.MethodPost {
-			writeJSONP1(w, http.StatusMethodNotAllowed, map[string]any{"error": "method_not_allowed"})
-			return
-		}
-
-		ownerSubject, ok := mustAuthSubject(r)
-		if !ok {
-			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
-			return
+	if strings.TrimSpace(req.PeerRefB64) != "" {
+		b, err := base64.StdEncoding.DecodeString(req.PeerRefB64)
+		if err != nil {
+			return m, http.StatusBadRequest, "bad_request", "invalid_peerRefEncryptedB64"
 		}
+		m.RefEncrypted = b
+	}
+	return m, 0, "", ""
+}
 
-		var req ackRequestP1
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
-			return
-		}
-		if req.SessionID == "" {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
-			return
-		}
-		if len(req.EnvelopeFingerprints) == 0 {
-			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "envelopeFingerprints_required"})
-			return
-		}
+// groupFanoutIncomplete logs the error of a group change that was recorded
+// but whose control envelopes did not reach every member, and reports
+// whether there was one. Members who missed a rekey cannot read later
+// messages, so clients must re-sync them.
+func groupFanoutIncomplete(r *http.Request, op string, err error) bool {
+	if err == nil {
+		return false
+	}
+	logging.Error(r.Context(), "group fan-out incomplete",
+		logging.Public("op", logging.Label(op)),
+		logging.Err(err))
+	return true
+}
 
-		// Validate correct receive session scope:
-		// - If conversationId provided => must be conversation-scoped receive session
-		// - If conversationId empty    => must be inbox-scoped receive session
-		if strings.TrimSpace(req.ConversationID) != "" {
-			key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: req.ConversationID}
-			if !requirePhase1Session(sessMgr, key, req.SessionID) {
-				writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "invalid_session"})
-				return
-			}
-		} else {
-			key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(purposeMessageReceive), ConversationID: ""}
-			if !requirePhase1Session(sessMgr, key, req.SessionID) {
-				writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "invalid_session"})
-				return
-			}
-		}
+// writeGroupError answers a failed group operation. Known group errors get
+// their status and a fixed detail code; anything else is logged and answered
+// with a bare internal_error so store and transport errors never reach clients.
+func writeGroupError(w http.ResponseWriter, r *http.Request, code string, err error) {
+	status := groupErrorStatus(err)
+	if status == http.StatusInternalServerError {
+		logging.Error(r.Context(), "group operation failed",
+			logging.Public("op", logging.Label(code)),
+			logging.Err(err))
+		writeJSONP1(w, status, map[string]any{"error": "internal_error"})
+		return
+	}
+	writeJSONP1(w, status, map[string]any{"error": code, "detail": groupErrorDetail(err)})
+}
 
-		acked, err := msgStore.AckAvailable(ownerSubject, req.ConversationID, req.EnvelopeFingerprints)
-		if err != nil {
-			writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "ack_failed", "detail": err.Error()})
-			return
-		}
+// groupErrorDetail maps conversations group errors to fixed detail codes.
+func groupErrorDetail(err error) string {
+	switch {
+	case errors.Is(err, conversations.ErrForbidden), errors.Is(err, store.ErrNotFound):
+		return "conversation_not_found"
+	case errors.Is(err, conversations.ErrNotGroup):
+		return "not_a_group"
+	case errors.Is(err, conversations.ErrNotMember):
+		return "not_a_member"
+	case errors.Is(err, conversations.ErrGroupFull):
+		return "group_full"
+	case errors.Is(err, conversations.ErrAlreadyMember):
+		return "already_member"
+	default:
+		return "internal_error"
+	}
+}
 
-		writeJSONP1(w, http.StatusOK, ackResponseP1{
-			Status:     "ok",
-			Acked:      acked,
-			ServerTime: time.Now().UTC().Format(time.RFC3339),
-		})
-	}))
+// groupErrorStatus maps conversations group errors to HTTP status codes.
+func groupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, conversations.ErrForbidden), errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, conversations.ErrNotGroup),
+		errors.Is(err, conversations.ErrNotMember),
+		errors.Is(err, conversations.ErrGroupFull):
+		return http.StatusBadRequest
+	case errors.Is(err, conversations.ErrAlreadyMember):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
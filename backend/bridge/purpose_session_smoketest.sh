@@ -0,0 +1,63 @@
+#!/usr/bin/env bash
+# Checks the Phase-1 purpose registry: scope rules at /session/issue, domain
+# binding and one-time use on /connect, and restricted purposes.
+# Builds the bridge and core stub unless BRIDGE_BIN / STUB_BIN point at binaries.
+set -euo pipefail
+
+PORT="${PORT:-18792}"
+BASE="http://127.0.0.1:$PORT"
+CORE_PORT="${CORE_PORT:-18892}"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" "${CORE_PID:-}" 2>/dev/null; wait 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${BRIDGE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/bridge"
+  go build -o "$BIN" .
+fi
+STUB="${STUB_BIN:-}"
+if [ -z "$STUB" ]; then
+  STUB="$WORK/stub"
+  (cd ../core && go build -o "$STUB" ./cmd/privxx-backend-stub)
+fi
+# Unlock and connect go to core; the stub stands in for it.
+"$STUB" -addr "127.0.0.1:$CORE_PORT" >"$WORK/stub.log" 2>&1 &
+CORE_PID=$!
+export BACKEND_URL="http://127.0.0.1:$CORE_PORT"
+for _ in $(seq 50); do curl -sf "$BACKEND_URL/health" >/dev/null && break; sleep 0.1; done
+
+export ENVIRONMENT=development AUTH_DEV_ISSUER=true BRIDGE_MODE=phase1 PORT
+export BRIDGE_CONTACTS_KEY=$(openssl rand -hex 32) BRIDGE_DATA_DIR="$WORK/data"
+export BRIDGE_ADMIN_SUBJECTS=purpose-admin
+"$BIN" >"$WORK/bridge.log" 2>&1 &
+PID=$!
+for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && break; sleep 0.1; done
+
+mint() { curl -sS -X POST "$BASE/dev/token" -d "{\"sub\":\"$1\"}" | jq -er '.access_token'; }
+USER=(-H "Authorization: Bearer $(mint purpose-user)" -H "Content-Type: application/json")
+ADMIN=(-H "Authorization: Bearer $(mint purpose-admin)" -H "Content-Type: application/json")
+issue() { curl -sS -X POST "$BASE/session/issue" "${USER[@]}" -d "$1"; }
+
+echo "== scope rules =="
+issue '{"purpose":"browse_fetch"}' | jq -e '.detail == "domain_required"'
+issue '{"purpose":"connect","domain":"example.com","conversationId":"c1"}' | jq -e '.detail == "conversationId_not_allowed"'
+issue '{"purpose":"attachment_upload"}' | jq -e '.detail == "conversationId_required"'
+issue '{"purpose":"nope"}' | jq -e '.detail == "invalid_purpose" and (.purposes | index("connect") != null)'
+
+echo "== restricted purpose =="
+issue '{"purpose":"admin_diagnostics"}' | jq -e '.detail == "purpose_restricted"'
+curl -sS -X POST "$BASE/session/issue" "${ADMIN[@]}" -d '{"purpose":"admin_diagnostics"}' | jq -e '.maxUses == 1'
+
+echo "== connect: one-time, domain-bound =="
+curl -sS -X POST "$BASE/unlock" "${USER[@]}" -d '{}' | jq -e '.error == "invalid_password"'
+curl -sS -X POST "$BASE/unlock" "${USER[@]}" -d '{"password":"smoke"}' | jq -e '.success == true'
+CONNECT='{"v":1,"type":"connect_intent","requestId":"r1","sessionId":"s1","targetUrl":"https://Example.com/page"}'
+SID=$(issue '{"purpose":"connect","domain":"example.com"}' | jq -er 'select(.maxUses == 1) | .sessionId')
+curl -sS -X POST "$BASE/connect" "${USER[@]}" -d "$CONNECT" | jq -e '.detail == "sessionId_required"'
+curl -sS -X POST "$BASE/connect" "${USER[@]}" -H "X-Session-Id: $SID" \
+  -d '{"v":1,"type":"connect_intent","requestId":"r0","targetUrl":"https://other.example"}' | jq -e '.detail == "invalid_session"'
+curl -sS -X POST "$BASE/connect" "${USER[@]}" -H "X-Session-Id: $SID" -d "$CONNECT" | jq -e '.type == "connect_ack" and .ack == true'
+curl -sS -X POST "$BASE/connect" "${USER[@]}" -H "X-Session-Id: $SID" -d "$CONNECT" | jq -e '.error == "unauthorized"'
+
+echo
+echo "purpose session checks OK"
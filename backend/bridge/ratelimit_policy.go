@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// RoutePolicy limits one group of routes. Every authenticated request draws a
+// token from the caller's subject bucket and from its client-IP bucket;
+// daily quotas are counted per subject only.
+type RoutePolicy struct {
+	Routes        []string `json:"routes,omitempty"`        // paths sharing this policy
+	RPS           float64  `json:"rps"`                     // sustained requests per second
+	Burst         int      `json:"burst"`                   // bucket size
+	DailyRequests int      `json:"dailyRequests,omitempty"` // 0 = unlimited (UTC day)
+	DailyBytes    int64    `json:"dailyBytes,omitempty"`    // response bytes per UTC day, 0 = unlimited
+}
+
+// RateLimitPolicies is the file/env configuration. Default applies to every
+// authenticated route not listed in a named policy.
+type RateLimitPolicies struct {
+	Default  RoutePolicy            `json:"default"`
+	Policies map[string]RoutePolicy `json:"policies"`
+}
+
+func defaultRateLimitPolicies() RateLimitPolicies {
+	return RateLimitPolicies{
+		Default: RoutePolicy{RPS: 5, Burst: 20},
+		Policies: map[string]RoutePolicy{
+			"message_send": {
+				Routes: []string{"/v1/message/send", "/message/send", "/messages/send"},
+				RPS:    1, Burst: 10, DailyRequests: 1000,
+			},
+			"session_issue": {
+				Routes: []string{"/session/issue"},
+				RPS:    2, Burst: 10,
+			},
+			"conversation_create": {
+				Routes: []string{"/conversation/create"},
+				RPS:    0.2, Burst: 5, DailyRequests: 200,
+			},
+			"browse": {
+				Routes: []string{"/browse/fetch", "/browse/preview"},
+				RPS:    1, Burst: 5, DailyBytes: 50 << 20,
+			},
+		},
+	}
+}
+
+// loadRateLimitPolicies reads RATE_LIMIT_CONFIG (path to a JSON file) or
+// RATE_LIMIT_POLICIES (inline JSON). Either replaces the built-in defaults
+// wholesale; setting both is an error.
+func loadRateLimitPolicies() (RateLimitPolicies, error) {
+	path := strings.TrimSpace(os.Getenv("RATE_LIMIT_CONFIG"))
+	inline := strings.TrimSpace(os.Getenv("RATE_LIMIT_POLICIES"))
+	var raw []byte
+	switch {
+	case path != "" && inline != "":
+		return RateLimitPolicies{}, errors.New("set only one of RATE_LIMIT_CONFIG and RATE_LIMIT_POLICIES")
+	case path != "":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return RateLimitPolicies{}, fmt.Errorf("RATE_LIMIT_CONFIG: %w", err)
+		}
+		raw = b
+	case inline != "":
+		raw = []byte(inline)
+	default:
+		return defaultRateLimitPolicies(), nil
+	}
+
+	var p RateLimitPolicies
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return RateLimitPolicies{}, fmt.Errorf("rate limit policies: %w", err)
+	}
+	if err := p.validate(); err != nil {
+		return RateLimitPolicies{}, fmt.Errorf("rate limit policies: %w", err)
+	}
+	return p, nil
+}
+
+func (p RateLimitPolicies) validate() error {
+	check := func(name string, rp RoutePolicy) error {
+		if rp.RPS <= 0 || rp.Burst < 1 {
+			return fmt.Errorf("%s: rps must be > 0 and burst >= 1", name)
+		}
+		if rp.DailyRequests < 0 || rp.DailyBytes < 0 {
+			return fmt.Errorf("%s: daily quotas must be >= 0", name)
+		}
+		return nil
+	}
+	if err := check("default", p.Default); err != nil {
+		return err
+	}
+	seen := map[string]string{}
+	for name, rp := range p.Policies {
+		if err := check(name, rp); err != nil {
+			return err
+		}
+		if len(rp.Routes) == 0 {
+			return fmt.Errorf("%s: routes required", name)
+		}
+		for _, route := range rp.Routes {
+			if other, dup := seen[route]; dup {
+				return fmt.Errorf("route %s is in both %s and %s", route, other, name)
+			}
+			seen[route] = name
+		}
+	}
+	return nil
+}
+
+// ============================
+// Token bucket on RateLimiter
+// ============================
+
+// newPolicyLimiter returns a RateLimiter whose entries are token buckets for p.
+func newPolicyLimiter(name string, p RoutePolicy) *RateLimiter {
+	pc := p
+	return &RateLimiter{
+		entries: make(map[string]*RateLimitEntry),
+		name:    name,
+		policy:  &pc,
+	}
+}
+
+// rateDecision is the outcome of one take.
+type rateDecision struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration // until the bucket is full again (or the quota resets)
+	retryAfter time.Duration
+	quota      bool // denied by a daily quota rather than the bucket
+}
+
+func utcDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// take draws one token from both the subject's and the client IP's bucket,
+// plus one unit of the subject's daily request quota. Both buckets are
+// checked before either is charged, so a request the IP bucket denies costs
+// the subject nothing. Byte quotas are checked here and charged by addBytes.
+// The returned decision is the denying one, or else the tighter of the two.
+func (rl *RateLimiter) take(subKey, ipKey string, now time.Time) rateDecision {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	sub, ip := rl.refill(subKey, now), rl.refill(ipKey, now)
+	if d := rl.check(sub, true, now); !d.allowed {
+		return d
+	}
+	if d := rl.check(ip, false, now); !d.allowed {
+		return d
+	}
+	sub.Tokens--
+	sub.DayRequests++
+	ip.Tokens--
+	d := rl.decision(sub)
+	if ipd := rl.decision(ip); ipd.remaining < d.remaining {
+		d = ipd
+	}
+	return d
+}
+
+// refill returns key's entry with its bucket and day rolled forward to now.
+// Callers hold rl.mu.
+func (rl *RateLimiter) refill(key string, now time.Time) *RateLimitEntry {
+	p := rl.policy
+	e, ok := rl.entries[key]
+	if !ok {
+		e = &RateLimitEntry{Tokens: float64(p.Burst), LastRefill: now}
+		rl.entries[key] = e
+	}
+	if day := utcDay(now); !e.DayStart.Equal(day) {
+		e.DayStart, e.DayRequests, e.DayBytes = day, 0, 0
+	}
+	e.Tokens = math.Min(float64(p.Burst), e.Tokens+now.Sub(e.LastRefill).Seconds()*p.RPS)
+	e.LastRefill = now
+	return e
+}
+
+// check reports whether e has a token (and, with countDaily, daily quota)
+// left, without charging it. Callers hold rl.mu.
+func (rl *RateLimiter) check(e *RateLimitEntry, countDaily bool, now time.Time) rateDecision {
+	p := rl.policy
+	untilMidnight := e.DayStart.Add(24 * time.Hour).Sub(now)
+	if countDaily {
+		if (p.DailyRequests > 0 && e.DayRequests >= p.DailyRequests) ||
+			(p.DailyBytes > 0 && e.DayBytes >= p.DailyBytes) {
+			return rateDecision{remaining: 0, reset: untilMidnight, retryAfter: untilMidnight, quota: true}
+		}
+	}
+	if e.Tokens < 1 {
+		full := time.Duration((float64(p.Burst) - e.Tokens) / p.RPS * float64(time.Second))
+		wait := time.Duration((1 - e.Tokens) / p.RPS * float64(time.Second))
+		return rateDecision{remaining: 0, reset: full, retryAfter: wait}
+	}
+	return rateDecision{allowed: true}
+}
+
+// decision describes e after a charged request. Callers hold rl.mu.
+func (rl *RateLimiter) decision(e *RateLimitEntry) rateDecision {
+	p := rl.policy
+	return rateDecision{
+		allowed:   true,
+		remaining: int(e.Tokens),
+		reset:     time.Duration((float64(p.Burst) - e.Tokens) / p.RPS * float64(time.Second)),
+	}
+}
+
+// addBytes charges n response bytes to key's daily byte quota.
+func (rl *RateLimiter) addBytes(key string, n int64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if e, ok := rl.entries[key]; ok {
+		e.DayBytes += n
+	}
+}
+
+// policyEntryIdle reports whether a bucket entry carries no state worth
+// keeping: the bucket has refilled and no daily quota is in use today.
+func (rl *RateLimiter) policyEntryIdle(e *RateLimitEntry, now time.Time) bool {
+	p := rl.policy
+	refilled := e.Tokens+now.Sub(e.LastRefill).Seconds()*p.RPS >= float64(p.Burst)
+	quotaToday := utcDay(now).Equal(e.DayStart) && (e.DayRequests > 0 || e.DayBytes > 0) &&
+		(p.DailyRequests > 0 || p.DailyBytes > 0)
+	return refilled && !quotaToday
+}
+
+// ============================
+// Middleware
+// ============================
+
+// routeLimiter applies per-route policies to every authenticated route.
+// main replaces it with the configured policies before building the server.
+var routeLimiter = newRouteLimits(defaultRateLimitPolicies())
+
+// routeLimits maps request paths to policy limiters.
+type routeLimits struct {
+	byRoute  map[string]*RateLimiter
+	fallback *RateLimiter
+	all      []*RateLimiter
+}
+
+func newRouteLimits(cfg RateLimitPolicies) *routeLimits {
+	rl := &routeLimits{byRoute: map[string]*RateLimiter{}, fallback: newPolicyLimiter("default", cfg.Default)}
+	rl.all = append(rl.all, rl.fallback)
+
+	names := make([]string, 0, len(cfg.Policies))
+	for name := range cfg.Policies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Policies[name]
+		lim := newPolicyLimiter(name, p)
+		rl.all = append(rl.all, lim)
+		for _, route := range p.Routes {
+			rl.byRoute[route] = lim
+		}
+	}
+	return rl
+}
+
+func (rl *routeLimits) startCleanupRoutine(ctx context.Context) {
+	for _, lim := range rl.all {
+		lim.startCleanupRoutine(ctx)
+	}
+}
+
+// wrap returns an auth wrapper that applies the limits after auth, so the
+// verified subject is known. Usage: s.auth = limits.wrap(authMiddleware).
+func (rl *routeLimits) wrap(auth func(http.HandlerFunc) http.HandlerFunc) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return auth(rl.middleware(next))
+	}
+}
+
+func (rl *routeLimits) middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lim := rl.byRoute[r.URL.Path]
+		if lim == nil {
+			lim = rl.fallback
+		}
+		now := time.Now()
+		subKey := "sub:" + authSubject(r)
+		ipKey := "ip:" + getClientIP(r)
+
+		d := lim.take(subKey, ipKey, now)
+		setRateLimitHeaders(w, lim, d)
+		if !d.allowed {
+			writeRateLimited(w, r, lim.name, d)
+			return
+		}
+
+		if lim.policy.DailyBytes == 0 {
+			next(w, r)
+			return
+		}
+		cw := &countingWriter{ResponseWriter: w}
+		next(cw, r)
+		lim.addBytes(subKey, cw.n)
+	}
+}
+
+// setRateLimitHeaders emits the IETF RateLimit header fields for the bucket.
+func setRateLimitHeaders(w http.ResponseWriter, lim *RateLimiter, d rateDecision) {
+	p := lim.policy
+	window := int(math.Ceil(float64(p.Burst) / p.RPS))
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(p.Burst))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(d.reset.Seconds()))))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", p.Burst, window))
+}
+
+func writeRateLimited(w http.ResponseWriter, r *http.Request, policy string, d rateDecision) {
+	secs := int(math.Ceil(d.retryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	code, msg, reason := "too_many_requests", fmt.Sprintf("Rate limit exceeded. Try again in %d seconds.", secs), "rate"
+	if d.quota {
+		code, msg, reason = "quota_exceeded", "Daily quota exhausted. It resets at 00:00 UTC.", "quota"
+		logging.Warn(r.Context(), "daily quota exhausted", logging.Public("policy", logging.Label(policy)))
+	}
+	bridgeMetrics.rateLimited.Inc(policy, reason)
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+	writeJSON(w, http.StatusTooManyRequests, map[string]any{
+		"error":      "rate_limited",
+		"code":       code,
+		"message":    msg,
+		"policy":     policy,
+		"retryAfter": secs,
+	})
+}
+
+// countingWriter counts response body bytes for byte quotas.
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(b)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// correlationIDHeader is accepted in place of X-Request-Id from clients that
+// only send a correlation ID.
+const correlationIDHeader = "X-Correlation-Id"
+
+// incomingRequestID returns the caller's X-Request-Id (or X-Correlation-Id)
+// when well-formed, otherwise a fresh ID.
+func incomingRequestID(r *http.Request) string {
+	for _, h := range []string{contracts.RequestIDHeader, correlationIDHeader} {
+		if id := strings.TrimSpace(r.Header.Get(h)); logging.ValidRequestID(id) {
+			return id
+		}
+	}
+	return logging.NewRequestID()
+}
+
+// withRequestID assigns r its request ID: it is echoed on the response, set
+// on r's X-Request-Id header for handlers that read it, and carried in r's
+// context, from which the backend client forwards it to core and slog adds
+// it to every record.
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := incomingRequestID(r)
+	r.Header.Set(contracts.RequestIDHeader, id)
+	w.Header().Set(contracts.RequestIDHeader, id)
+	return r.WithContext(logging.WithRequestID(r.Context(), id))
+}
+
+// statusRecorder keeps the response status for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// logRequest writes the access log line. It names the route pattern, never
+// the URL: paths and queries can carry IDs and browsed addresses.
+func logRequest(r *http.Request, route string, status int, start time.Time) {
+	if route == "" {
+		route = "unmatched"
+	}
+	slog.InfoContext(r.Context(), "request",
+		"method", r.Method,
+		"route", route,
+		"status", status,
+		"duration_ms", time.Since(start).Milliseconds())
+}
@@ -1,32 +1,164 @@
 package main
 
 import (
-	"log"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/Bulldog-Master/privxx/backend/bridge/internal/sessions"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
 )
 
+// sessionProofHeader carries the proof for key-bound Phase-1 sessions.
+const sessionProofHeader = "DPoP"
+
+// bodyHashKey holds the proof body hash computed by withProofBody.
+const bodyHashKey ctxKey = "proofBodyHash"
+
+// maxProofBody matches the legacy dispatch limit on message bodies.
+const maxProofBody = 1 << 20
+
+var errPhase1SessionScope = errors.New("session scope mismatch")
+
 // requirePhase1Session validates a session against the canonical in-memory Manager.
-// It also enforces inbox-scope vs conversation-scope matching.
-func requirePhase1Session(m *sessions.Manager, key phase1SessionKey, sessionID string) bool {
+// It also enforces inbox-scope vs conversation-scope matching, the domain of
+// domain-scoped purposes, the request's proof of possession for sessions
+// issued with a proof key, and finally spends one use (see Manager.Consume).
+func requirePhase1Session(m *sessions.Manager, r *http.Request, key phase1SessionKey, sessionID string) (err error) {
+	defer func() { bridgeMetrics.phase1Validation(err) }()
 	p := sessions.Purpose(strings.TrimSpace(key.Purpose))
 	s, err := m.Validate(sessionID, strings.TrimSpace(key.OwnerSubject), p)
 	if err != nil {
-		log.Printf("P1 session INVALID sid=%s subj=%s purpose=%s err=%v", sessionID, strings.TrimSpace(key.OwnerSubject), p, err)
-		return false
+		logging.Info(r.Context(), "phase-1 session invalid",
+			logging.Pseudonym("session", sessionID),
+			logging.Pseudonym("subject", strings.TrimSpace(key.OwnerSubject)),
+			logging.Public("purpose", logging.Label(p)),
+			logging.Err(err))
+		return err
 	}
 
 	conv := strings.TrimSpace(key.ConversationID)
 	if conv == "" {
 		if s.ConversationID != nil {
-			log.Printf("P1 session SCOPE mismatch: expected inbox-scope, got conv=%s sid=%s", strings.TrimSpace(*s.ConversationID), sessionID)
-			return false
+			logging.Info(r.Context(), "phase-1 session scope mismatch: expected inbox scope",
+				logging.Pseudonym("session", sessionID),
+				logging.Pseudonym("conversation", strings.TrimSpace(*s.ConversationID)))
+			return errPhase1SessionScope
+		}
+	} else if s.ConversationID == nil || strings.TrimSpace(*s.ConversationID) != conv {
+		return errPhase1SessionScope
+	}
+	if s.Domain != "" || key.Domain != "" {
+		if d, err := sessions.NormalizeDomain(key.Domain); err != nil || d != s.Domain {
+			logging.Info(r.Context(), "phase-1 session scope mismatch: domain", logging.Pseudonym("session", sessionID))
+			return errPhase1SessionScope
+		}
+	}
+
+	bodyHash, _ := r.Context().Value(bodyHashKey).(string)
+	if err := m.VerifyProof(s, r.Header.Get(sessionProofHeader), r.Method, r.URL.Path, bodyHash); err != nil {
+		logging.Info(r.Context(), "phase-1 session proof rejected", logging.Pseudonym("session", sessionID), logging.Err(err))
+		return err
+	}
+	// Last, so a rejected request never spends a one-time session.
+	return m.Consume(sessionID)
+}
+
+// withProofBody buffers the body of requests that carry a session proof and
+// records its hash, since handlers consume the body before the session check.
+func withProofBody(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(sessionProofHeader) == "" {
+			next(w, r)
+			return
+		}
+		raw, err := io.ReadAll(io.LimitReader(r.Body, maxProofBody+1))
+		if err != nil || len(raw) > maxProofBody {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_request_body"})
+			return
 		}
-		return true
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		ctx := context.WithValue(r.Context(), bodyHashKey, sessions.BodyHash(raw))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// writePhase1SessionError answers a failed requirePhase1Session. Proof
+// failures get their own detail so clients can tell a bad signature or a
+// reused proof from a dead session.
+func writePhase1SessionError(w http.ResponseWriter, err error) {
+	detail := "invalid_session"
+	switch {
+	case errors.Is(err, sessions.ErrProofRequired):
+		detail = "session_proof_required"
+	case errors.Is(err, sessions.ErrProofReplayed):
+		detail = "session_proof_replayed"
+	case errors.Is(err, sessions.ErrProofStale):
+		detail = "session_proof_stale"
+	case errors.Is(err, sessions.ErrProofInvalid):
+		detail = "invalid_session_proof"
+	case errors.Is(err, sessions.ErrSessionUsed):
+		detail = "session_used"
 	}
-	if s.ConversationID == nil {
-		return false
+	writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": detail})
+}
+
+// purposeSessionHeader names the Phase-1 session on routes whose bodies are
+// forwarded as-is (browse, connect) and so cannot carry a sessionId field.
+const purposeSessionHeader = "X-Session-Id"
+
+// purposeScoped requires a purpose session for h in phase1 mode. The session's
+// domain must match the host of the URL in the JSON body field urlField.
+// Other modes have no session manager and serve h unchanged.
+func (s *server) purposeScoped(m *sessions.Manager, p sessions.Purpose, urlField string, h http.HandlerFunc) http.HandlerFunc {
+	if s.mode != modePhase1 {
+		return h
 	}
-	return strings.TrimSpace(*s.ConversationID) == conv
+	return withProofBody(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			h(w, r)
+			return
+		}
+		ownerSubject, ok := mustAuthSubject(r)
+		if !ok {
+			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized"})
+			return
+		}
+		sessionID := strings.TrimSpace(r.Header.Get(purposeSessionHeader))
+		if sessionID == "" {
+			writeJSONP1(w, http.StatusUnauthorized, map[string]any{"error": "unauthorized", "detail": "sessionId_required"})
+			return
+		}
+
+		raw, err := io.ReadAll(io.LimitReader(r.Body, maxProofBody+1))
+		if err != nil || len(raw) > maxProofBody {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_request_body"})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+		var fields map[string]any
+		_ = json.Unmarshal(raw, &fields)
+		target, _ := fields[urlField].(string)
+		target = strings.TrimSpace(target)
+		if target != "" && !strings.Contains(target, "://") {
+			target = "https://" + target // typed URLs often omit the scheme
+		}
+		u, err := url.Parse(target)
+		if err != nil || u.Hostname() == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": urlField + "_required"})
+			return
+		}
+
+		key := phase1SessionKey{OwnerSubject: ownerSubject, Purpose: string(p), Domain: u.Hostname()}
+		if err := requirePhase1Session(m, r, key, sessionID); err != nil {
+			writePhase1SessionError(w, err)
+			return
+		}
+		h(w, r)
+	})
 }
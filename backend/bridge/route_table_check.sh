@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+# Route-table check: the registered surface for each BRIDGE_MODE must match
+# testdata/routes/<mode>.txt exactly. Update the golden file in the same change
+# that adds or removes a route.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+go build -o /tmp/privxx-bridge-routes .
+
+status=0
+for mode in legacy phase1 api-only; do
+  if ! diff -u "testdata/routes/$mode.txt" <(BRIDGE_MODE="$mode" /tmp/privxx-bridge-routes -routes); then
+    echo "route table mismatch for mode=$mode" >&2
+    status=1
+  fi
+done
+
+[ "$status" -eq 0 ] && echo "route tables OK"
+exit "$status"
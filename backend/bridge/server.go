@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/sessions"
+)
+
+// bridgeMode selects the route surface at startup (BRIDGE_MODE).
+type bridgeMode string
+
+const (
+	// modeLegacy serves the Phase-D surface: health, unlock/lock, connect/status/disconnect, browse.
+	modeLegacy bridgeMode = "legacy"
+	// modePhase1 serves the legacy surface plus sessions, conversations, messaging, contacts and invites.
+	modePhase1 bridgeMode = "phase1"
+	// modeAPIOnly serves the Phase-5 stubs with local token verification (no xxDK, no Supabase round-trip).
+	modeAPIOnly bridgeMode = "api-only"
+)
+
+// parseMode reads BRIDGE_MODE. BRIDGE_API_ONLY=true is honored as a legacy alias for api-only.
+func parseMode() (bridgeMode, error) {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("BRIDGE_MODE")))
+	if v == "" {
+		if strings.EqualFold(os.Getenv("BRIDGE_API_ONLY"), "true") {
+			return modeAPIOnly, nil
+		}
+		return modeLegacy, nil
+	}
+	switch m := bridgeMode(v); m {
+	case modeLegacy, modePhase1, modeAPIOnly:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown BRIDGE_MODE %q (want legacy, phase1 or api-only)", v)
+	}
+}
+
+// server owns the bridge's single ServeMux. Every route is registered through
+// handle exactly once; ServeMux panics on a duplicate pattern, so a conflicting
+// registration fails at startup rather than shadowing a handler.
+type server struct {
+	mode   bridgeMode
+	mux    *http.ServeMux
+	auth   func(http.HandlerFunc) http.HandlerFunc
+	routes []string
+}
+
+// newServer builds the route table for mode. d is required for modePhase1.
+func newServer(mode bridgeMode, d *phase1Deps) (*server, error) {
+	s := &server{mode: mode, mux: http.NewServeMux()}
+
+	switch mode {
+	case modeLegacy:
+		s.auth = routeLimiter.wrap(authMiddleware)
+		s.registerLegacyRoutes()
+	case modePhase1:
+		if d == nil {
+			return nil, fmt.Errorf("phase1 mode requires dependencies")
+		}
+		s.auth = routeLimiter.wrap(authMiddleware)
+		s.registerLegacyRoutes()
+		s.registerPhase1Routes(d)
+	case modeAPIOnly:
+		s.auth = routeLimiter.wrap(localAuthMiddleware)
+		s.registerAPIOnlyRoutes()
+	default:
+		return nil, fmt.Errorf("unknown mode %q", mode)
+	}
+	if devTokens != nil {
+		s.registerDevIssuerRoutes(devTokens)
+	}
+	return s, nil
+}
+
+func (s *server) handle(pattern string, h http.HandlerFunc) {
+	s.mux.HandleFunc(pattern, h)
+	s.routes = append(s.routes, pattern)
+}
+
+// Routes returns the registered patterns, sorted.
+func (s *server) Routes() []string {
+	out := append([]string(nil), s.routes...)
+	sort.Strings(out)
+	return out
+}
+
+// ServeHTTP strips client-supplied identity headers before routing. Handlers
+// identify the caller only through the Principal set by auth middleware.
+// Every request gets a request ID, an access log line and request metrics. CORS is applied
+// here for every route; preflights never reach the mux.
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	r.Header.Del("X-User-Id")
+	r = withRequestID(w, r)
+	_, route := s.mux.Handler(r)
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		logRequest(r, route, rec.status, start)
+		bridgeMetrics.observeRequest(r, route, rec.status, time.Since(start))
+	}()
+
+	if corsPolicy.apply(rec, r) {
+		return
+	}
+	s.mux.ServeHTTP(rec, r)
+}
+
+// registerLegacyRoutes registers the Phase-D surface (see main.go handlers).
+func (s *server) registerLegacyRoutes() {
+	// /health is public (no auth required)
+	s.handle("/health", handleHealth)
+
+	// Unlock/lock endpoints require auth but not unlock status
+	s.handle("/unlock", s.auth(handleUnlock))
+	s.handle("/unlock/status", s.auth(handleUnlockStatus))
+	s.handle("/lock", s.auth(handleLock))
+
+	// Protected routes require both auth AND unlocked session
+	// In phase1 mode /connect and /browse/fetch also need a domain-scoped purpose session.
+	s.handle("/connect", s.auth(unlockRequiredMiddleware(
+		s.purposeScoped(phase1SessionMgr, sessions.PurposeConnect, "targetUrl", handleConnect))))
+	s.handle("/status", s.auth(handleStatus)) // Status doesn't require unlock
+	s.handle("/disconnect", s.auth(unlockRequiredMiddleware(handleDisconnect)))
+	s.handle("/browse/preview", s.auth(unlockRequiredMiddleware(handleBrowsePreview)))
+	s.handle("/browse/fetch", s.auth(unlockRequiredMiddleware(
+		s.purposeScoped(phase1SessionMgr, sessions.PurposeBrowseFetch, "url", handleBrowseFetch))))
+}
+
+// registerAPIOnlyRoutes registers the Phase-5 stub surface (legacy_api_stubs.go).
+func (s *server) registerAPIOnlyRoutes() {
+	s.handle("/health", stubHealth)
+	s.handle("/unlock", s.auth(stubUnlock))
+	s.handle("/unlock/status", s.auth(stubUnlockStatus))
+	s.handle("/lock", s.auth(stubLock))
+	s.handle("/connect", s.auth(stubConnect))
+	s.handle("/status", s.auth(stubStatus))
+	s.handle("/disconnect", s.auth(stubDisconnect))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 	"time"
@@ -14,15 +15,28 @@ var phase1SessionMgr = sessions.NewManager(15 * time.Minute)
 type sessionIssueRequest struct {
 	Purpose        string `json:"purpose"`
 	ConversationID string `json:"conversationId,omitempty"`
+	Domain         string `json:"domain,omitempty"`     // browse_fetch, connect
+	TTLSeconds     int64  `json:"ttlSeconds,omitempty"` // shorter than the default only
+
+	// ProofKey optionally binds the session to a client P-256 key (JWK).
+	ProofKey *sessions.ProofKey `json:"proofKey,omitempty"`
 }
 
 type sessionIssueResponse struct {
 	SessionID      string `json:"sessionId"`
 	Purpose        string `json:"purpose"`
 	ConversationID string `json:"conversationId,omitempty"`
+	Domain         string `json:"domain,omitempty"`
+	MaxUses        int    `json:"maxUses,omitempty"` // 1 = one-time session
+	ExpiresAtUnix  int64  `json:"expiresAtUnix"`
+	ProofKeyJKT    string `json:"proofKeyJkt,omitempty"` // RFC 7638 thumbprint of the bound key
 	ServerTime     string `json:"serverTime"`
 }
 
+// adminSubjects may hold restricted purposes (admin_diagnostics).
+// Set from BRIDGE_ADMIN_SUBJECTS (comma-separated jwt.sub values) in main.
+var adminSubjects = map[string]bool{}
+
 func handleSessionIssue(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Content-Type", "application/json")
@@ -33,8 +47,8 @@ func handleSessionIssue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ownerSubject := strings.TrimSpace(r.Header.Get("X-User-Id"))
-	if ownerSubject == "" {
+	ownerSubject, ok := mustAuthSubject(r)
+	if !ok {
 		w.WriteHeader(http.StatusUnauthorized)
 		_ = json.NewEncoder(w).Encode(map[string]any{"error": "unauthorized"})
 		return
@@ -48,37 +62,203 @@ func handleSessionIssue(w http.ResponseWriter, r *http.Request) {
 	}
 
 	p := sessions.Purpose(strings.TrimSpace(req.Purpose))
-	if p != sessions.PurposeMessageSend && p != sessions.PurposeMessageReceive {
+	if _, ok := sessions.LookupPurpose(p); !ok {
 		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]any{"error": "bad_request", "detail": "invalid_purpose"})
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "bad_request", "detail": "invalid_purpose", "purposes": sessions.Purposes()})
 		return
 	}
 
 	convID := strings.TrimSpace(req.ConversationID)
-	if p == sessions.PurposeMessageSend && convID == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		_ = json.NewEncoder(w).Encode(map[string]any{"error": "bad_request", "detail": "conversationId_required"})
-		return
-	}
-
 	var convPtr *string
 	if convID != "" {
 		convPtr = &convID
 	}
 
-	s, err := phase1SessionMgr.Issue(ownerSubject, p, convPtr)
+	s, err := phase1SessionMgr.IssueScoped(ownerSubject, p, sessions.IssueScope{
+		ConversationID: convPtr,
+		Domain:         req.Domain,
+		TTL:            time.Duration(req.TTLSeconds) * time.Second,
+		ProofKey:       req.ProofKey,
+		Privileged:     adminSubjects[ownerSubject],
+	})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		_ = json.NewEncoder(w).Encode(map[string]any{"error": "session_issue_failed"})
+		status, detail := sessionIssueErrorDetail(err)
+		w.WriteHeader(status)
+		if status == http.StatusInternalServerError {
+			bridgeMetrics.phase1Sessions.Inc("issue_failed", "internal")
+			_ = json.NewEncoder(w).Encode(map[string]any{"error": "session_issue_failed"})
+			return
+		}
+		bridgeMetrics.phase1Sessions.Inc("issue_failed", detail)
+		_ = json.NewEncoder(w).Encode(map[string]any{"error": "bad_request", "detail": detail})
 		return
 	}
+	bridgeMetrics.phase1Sessions.Inc("issued", string(p))
 
 	resp := sessionIssueResponse{
 		SessionID:      s.SessionID,
 		Purpose:        string(p),
 		ConversationID: convID,
+		Domain:         s.Domain,
+		MaxUses:        s.MaxUses,
+		ExpiresAtUnix:  s.ExpiresAtUnix,
 		ServerTime:     time.Now().UTC().Format(time.RFC3339),
 	}
+	if s.ProofKey != nil {
+		resp.ProofKeyJKT = s.ProofKey.Thumbprint()
+	}
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
+
+// sessionIssueErrorDetail maps purpose-rule violations to a status and detail.
+func sessionIssueErrorDetail(err error) (int, string) {
+	switch {
+	case errors.Is(err, sessions.ErrProofKeyInvalid):
+		return http.StatusBadRequest, "invalid_proofKey"
+	case errors.Is(err, sessions.ErrScopeConversationRequired):
+		return http.StatusBadRequest, "conversationId_required"
+	case errors.Is(err, sessions.ErrScopeConversationNotAllowed):
+		return http.StatusBadRequest, "conversationId_not_allowed"
+	case errors.Is(err, sessions.ErrScopeDomainRequired):
+		return http.StatusBadRequest, "domain_required"
+	case errors.Is(err, sessions.ErrScopeDomainNotAllowed):
+		return http.StatusBadRequest, "domain_not_allowed"
+	case errors.Is(err, sessions.ErrScopeDomainInvalid):
+		return http.StatusBadRequest, "invalid_domain"
+	case errors.Is(err, sessions.ErrPurposeRestricted):
+		return http.StatusForbidden, "purpose_restricted"
+	default:
+		return http.StatusInternalServerError, ""
+	}
+}
+
+/*
+Session lifecycle routes (caller's own sessions only):
+- POST /session/refresh  slide expiry by the TTL, capped at the max lifetime
+- POST /session/revoke   one session, or all with {"all":true}
+- POST /session/list     live sessions, no secrets beyond the IDs the caller already holds
+Locking the identity (POST /lock) revokes all of the caller's sessions.
+*/
+func (s *server) registerSessionRoutes(m *sessions.Manager) {
+	s.handle("/session/issue", s.auth(handleSessionIssue))
+
+	type sessionView struct {
+		SessionID      string `json:"sessionId"`
+		Purpose        string `json:"purpose"`
+		ConversationID string `json:"conversationId,omitempty"`
+		Domain         string `json:"domain,omitempty"`
+		UsesLeft       *int   `json:"usesLeft,omitempty"` // nil = unlimited
+		IssuedAtUnix   int64  `json:"issuedAtUnix"`
+		ExpiresAtUnix  int64  `json:"expiresAtUnix"`
+		ProofKeyJKT    string `json:"proofKeyJkt,omitempty"`
+	}
+	view := func(sess *sessions.Session) sessionView {
+		v := sessionView{
+			SessionID:     sess.SessionID,
+			Purpose:       string(sess.Purpose),
+			Domain:        sess.Domain,
+			IssuedAtUnix:  sess.IssuedAtUnix,
+			ExpiresAtUnix: sess.ExpiresAtUnix,
+		}
+		if sess.ConversationID != nil {
+			v.ConversationID = *sess.ConversationID
+		}
+		if sess.ProofKey != nil {
+			v.ProofKeyJKT = sess.ProofKey.Thumbprint()
+		}
+		if sess.MaxUses > 0 {
+			left := sess.MaxUses - sess.Uses
+			v.UsesLeft = &left
+		}
+		return v
+	}
+
+	// ---- POST /session/refresh ----
+	type refreshReq struct {
+		SessionID string `json:"sessionId"`
+	}
+	s.handle("/session/refresh", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req refreshReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SessionID == "" {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_required"})
+			return
+		}
+		sess, err := m.Refresh(req.SessionID, ownerSubject)
+		if err != nil {
+			writeSessionError(w, err)
+			return
+		}
+		writeJSONP1(w, http.StatusOK, map[string]any{
+			"session":    view(sess),
+			"serverTime": time.Now().UTC().Format(time.RFC3339),
+		})
+	}))
+
+	// ---- POST /session/revoke ----
+	type revokeReq struct {
+		SessionID string `json:"sessionId,omitempty"`
+		All       bool   `json:"all,omitempty"`
+	}
+	s.handle("/session/revoke", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		var req revokeReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "invalid_json"})
+			return
+		}
+		switch {
+		case req.All:
+			n := m.RevokeAll(ownerSubject)
+			writeJSONP1(w, http.StatusOK, map[string]any{"revoked": n})
+		case req.SessionID != "":
+			if err := m.Revoke(req.SessionID, ownerSubject); err != nil {
+				writeSessionError(w, err)
+				return
+			}
+			writeJSONP1(w, http.StatusOK, map[string]any{"revoked": 1})
+		default:
+			writeJSONP1(w, http.StatusBadRequest, map[string]any{"error": "bad_request", "detail": "sessionId_or_all_required"})
+		}
+	}))
+
+	// ---- POST /session/list ----
+	s.handle("/session/list", s.auth(func(w http.ResponseWriter, r *http.Request) {
+		ownerSubject, ok := contactPreamble(w, r)
+		if !ok {
+			return
+		}
+		out := []sessionView{}
+		for _, sess := range m.List(ownerSubject) {
+			out = append(out, view(&sess))
+		}
+		writeJSONP1(w, http.StatusOK, map[string]any{
+			"sessions":   out,
+			"serverTime": time.Now().UTC().Format(time.RFC3339),
+		})
+	}))
+}
+
+// writeSessionError maps sessions errors to HTTP status codes. Sessions owned
+// by someone else look exactly like unknown ones.
+func writeSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sessions.ErrSessionNotFound), errors.Is(err, sessions.ErrSessionForbidden):
+		writeJSONP1(w, http.StatusNotFound, map[string]any{"error": "session_not_found"})
+	case errors.Is(err, sessions.ErrSessionExpired):
+		writeJSONP1(w, http.StatusGone, map[string]any{"error": "session_expired"})
+	case errors.Is(err, sessions.ErrSessionRevoked):
+		writeJSONP1(w, http.StatusGone, map[string]any{"error": "session_revoked"})
+	case errors.Is(err, sessions.ErrSessionMaxAge):
+		writeJSONP1(w, http.StatusConflict, map[string]any{"error": "session_max_lifetime"})
+	default:
+		writeJSONP1(w, http.StatusInternalServerError, map[string]any{"error": "session_error"})
+	}
+}
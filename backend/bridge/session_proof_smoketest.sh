@@ -0,0 +1,81 @@
+#!/usr/bin/env bash
+# Checks proof-of-possession for key-bound Phase-1 sessions: a session issued
+# with a proofKey is refused without a DPoP proof, accepts a fresh one, and
+# rejects a replayed proof, one signed over a different body, or one signed
+# before the bridge started (its replay cache is in memory).
+# Builds the bridge unless BRIDGE_BIN points at a binary. Needs openssl, xxd, jq.
+set -euo pipefail
+
+PORT="${PORT:-18791}"
+BASE="http://127.0.0.1:$PORT"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" 2>/dev/null && wait "${PID:-}" 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${BRIDGE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/bridge"
+  go build -o "$BIN" .
+fi
+
+export ENVIRONMENT=development AUTH_DEV_ISSUER=true BRIDGE_MODE=phase1 PORT
+export BRIDGE_CONTACTS_KEY=$(openssl rand -hex 32) BRIDGE_DATA_DIR="$WORK/data"
+STARTED=$(date +%s)
+"$BIN" >"$WORK/bridge.log" 2>&1 &
+PID=$!
+for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && break; sleep 0.1; done
+sleep 1 # iat is in whole seconds: fresh proofs must be signed after the start second
+
+b64url() { base64 -w0 | tr '+/' '-_' | tr -d '='; }
+
+# Ephemeral client key; the public point is the last 64 bytes of the SPKI DER.
+openssl ecparam -name prime256v1 -genkey -noout -out "$WORK/k.pem" 2>/dev/null
+openssl ec -in "$WORK/k.pem" -pubout -outform DER 2>/dev/null | tail -c 64 >"$WORK/pub"
+X=$(head -c 32 "$WORK/pub" | b64url)
+Y=$(tail -c 32 "$WORK/pub" | b64url)
+
+proof() { # method path body [iat] -> compact ES256 JWS
+  local bh hdr pl sig
+  bh=$(printf '%s' "$3" | openssl dgst -sha256 -binary | b64url)
+  hdr=$(printf '{"typ":"dpop+jwt","alg":"ES256"}' | b64url)
+  pl=$(printf '{"htm":"%s","htu":"%s","bh":"%s","iat":%d,"jti":"%s"}' "$1" "$2" "$bh" "${4:-$(date +%s)}" "$(openssl rand -hex 12)" | b64url)
+  # DER ECDSA signature -> raw r||s (32 bytes each)
+  sig=$(printf '%s.%s' "$hdr" "$pl" | openssl dgst -sha256 -sign "$WORK/k.pem" \
+    | openssl asn1parse -inform DER 2>/dev/null | awk -F: '/INTEGER/ {printf "%064s", $NF}' | tr ' ' 0 \
+    | xxd -r -p | b64url)
+  printf '%s.%s.%s' "$hdr" "$pl" "$sig"
+}
+
+TOKEN=$(curl -sS -X POST "$BASE/dev/token" -d '{"sub":"proof-user"}' | jq -er '.access_token')
+AUTH=(-H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json")
+
+echo "== issue a key-bound inbox session =="
+ISSUE=$(curl -sS -X POST "$BASE/session/issue" "${AUTH[@]}" \
+  -d "{\"purpose\":\"message_receive\",\"proofKey\":{\"kty\":\"EC\",\"crv\":\"P-256\",\"x\":\"$X\",\"y\":\"$Y\"}}")
+echo "$ISSUE" | jq -e '.proofKeyJkt | length > 0' >/dev/null
+SID=$(echo "$ISSUE" | jq -r .sessionId)
+BODY="{\"sessionId\":\"$SID\"}"
+
+echo "== no proof -> session_proof_required =="
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -d "$BODY" | jq -e '.detail == "session_proof_required"'
+
+echo "== fresh proof -> ok, replay -> session_proof_replayed =="
+P=$(proof POST /v1/message/inbox "$BODY")
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -H "DPoP: $P" -d "$BODY" | jq -e 'has("items") and (.error == null)'
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -H "DPoP: $P" -d "$BODY" | jq -e '.detail == "session_proof_replayed"'
+
+echo "== proof over another body or path -> invalid_session_proof =="
+P=$(proof POST /v1/message/inbox "{\"sessionId\":\"$SID\",\"limit\":1}")
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -H "DPoP: $P" -d "$BODY" | jq -e '.detail == "invalid_session_proof"'
+P=$(proof POST /message/inbox "$BODY")
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -H "DPoP: $P" -d "$BODY" | jq -e '.detail == "invalid_session_proof"'
+
+echo "== proof signed before the bridge started -> session_proof_stale =="
+P=$(proof POST /v1/message/inbox "$BODY" $((STARTED - 1)))
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -H "DPoP: $P" -d "$BODY" | jq -e '.detail == "session_proof_stale"'
+
+echo "== unbound sessions still work without a proof =="
+SID2=$(curl -sS -X POST "$BASE/session/issue" "${AUTH[@]}" -d '{"purpose":"message_receive"}' | jq -r .sessionId)
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -d "{\"sessionId\":\"$SID2\"}" | jq -e 'has("items") and (.error == null)'
+
+echo
+echo "session proof checks OK"
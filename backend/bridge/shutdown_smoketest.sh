@@ -0,0 +1,74 @@
+#!/usr/bin/env bash
+# Graceful shutdown under load: several clients send messages while the
+# bridge gets SIGTERM. The bridge must drain, close its stores and exit 0,
+# and after a restart every send that was answered 200 must be in the thread.
+# Builds the bridge unless BRIDGE_BIN points at a binary. Needs openssl, jq.
+set -euo pipefail
+
+PORT="${PORT:-18795}"
+BASE="http://127.0.0.1:$PORT"
+WORKERS="${WORKERS:-8}"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" 2>/dev/null && wait "${PID:-}" 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${BRIDGE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/bridge"
+  go build -o "$BIN" .
+fi
+
+export ENVIRONMENT=development AUTH_DEV_ISSUER=true BRIDGE_MODE=phase1 PORT METRICS_ADDR=
+export BRIDGE_CONTACTS_KEY=$(openssl rand -hex 32) BRIDGE_DATA_DIR="$WORK/data"
+export AUTH_DEV_ISSUER_KEY=$(openssl rand -hex 32) SHUTDOWN_TIMEOUT_SECONDS=10
+# Generous limits, so the load is not throttled away before the signal.
+export RATE_LIMIT_POLICIES='{"default":{"rps":10000,"burst":10000},"policies":{}}'
+
+start() {
+  "$BIN" >>"$WORK/bridge.log" 2>&1 &
+  PID=$!
+  for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && return; sleep 0.1; done
+  echo "bridge did not start"; cat "$WORK/bridge.log"; exit 1
+}
+
+start
+TOKEN=$(curl -sS -X POST "$BASE/dev/token" -d '{"sub":"shutdown-user"}' | jq -er '.access_token')
+AUTH=(-H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json")
+CONV=$(curl -sS -X POST "$BASE/conversation/create" "${AUTH[@]}" -d '{"peerFingerprint":"fp_shutdown"}' | jq -er '.conversationId')
+
+# Each worker sends until the bridge stops answering and records one line per
+# send that was acknowledged with 200.
+worker() {
+  local sid code
+  while true; do
+    sid=$(curl -s -X POST "$BASE/session/issue" "${AUTH[@]}" \
+      -d "{\"purpose\":\"message_send\",\"conversationId\":\"$CONV\"}" | jq -r '.sessionId // empty' 2>/dev/null) || return 0
+    [ -n "$sid" ] || return 0
+    code=$(curl -s -o /dev/null -w '%{http_code}' -X POST "$BASE/message/send" "${AUTH[@]}" \
+      -d "{\"sessionId\":\"$sid\",\"conversationId\":\"$CONV\",\"plaintextB64\":\"$(openssl rand -base64 12)\"}") || return 0
+    [ "$code" = 200 ] && echo ok >>"$WORK/acked.$1"
+  done
+}
+
+echo "== $WORKERS senders, SIGTERM after 2s =="
+for i in $(seq "$WORKERS"); do worker "$i" & done
+sleep 2
+kill -TERM "$PID"
+STATUS=0
+wait "$PID" || STATUS=$?
+wait
+[ "$STATUS" -eq 0 ] || { echo "bridge exited $STATUS"; cat "$WORK/bridge.log"; exit 1; }
+grep -q "shutdown: stopped" "$WORK/bridge.log"
+ACKED=$(cat "$WORK"/acked.* 2>/dev/null | wc -l)
+[ "$ACKED" -gt 0 ] || { echo "no sends were acknowledged"; exit 1; }
+echo "acknowledged sends: $ACKED"
+
+echo "== restart: every acknowledged send is stored =="
+start
+RSID=$(curl -sS -X POST "$BASE/session/issue" "${AUTH[@]}" \
+  -d "{\"purpose\":\"message_receive\",\"conversationId\":\"$CONV\"}" | jq -er '.sessionId')
+STORED=$(curl -sS -X POST "$BASE/message/thread" "${AUTH[@]}" \
+  -d "{\"sessionId\":\"$RSID\",\"conversationId\":\"$CONV\",\"limit\":100000}" | jq -er '.items | length')
+echo "stored: $STORED"
+[ "$STORED" -ge "$ACKED" ] || { echo "lost $((ACKED - STORED)) acknowledged messages"; exit 1; }
+
+echo "shutdown checks OK"
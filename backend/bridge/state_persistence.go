@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/sessions"
+	"github.com/Bulldog-Master/privxx/backend/bridge/internal/store"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// statePersistence snapshots the bridge's in-memory auth state so restarts
+// neither clear lockouts nor log everyone out:
+//   - rateLimiter.entries            (failed-auth lockouts)
+//   - identityManager.sessions       (unlock sessions)
+//   - phase1SessionMgr               (purpose-scoped Phase-1 sessions)
+//
+// Each is a separate sealed file under BRIDGE_STATE_DIR. Snapshots are
+// written every flush interval when their content changed, so at most one
+// interval of changes is lost on a crash. Expired entries are dropped on load.
+type statePersistence struct {
+	every time.Duration
+	items []*persistedState
+}
+
+type persistedState struct {
+	name     string
+	file     *store.SealedFile
+	snapshot func() any
+	last     [32]byte
+}
+
+// persistedIdentitySession keeps the fields IdentitySession hides from JSON.
+type persistedIdentitySession struct {
+	UserID       string    `json:"userId"`
+	XXIdentityID string    `json:"xxIdentityId"`
+	UnlockedAt   time.Time `json:"unlockedAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	LastActivity time.Time `json:"lastActivity"`
+}
+
+// loadStatePersistence reads the environment:
+//   - BRIDGE_STATE_DIR            enables persistence (unset = in-memory only)
+//   - BRIDGE_STATE_KEY            hex-encoded 32-byte key sealing the snapshots (required with the dir)
+//   - BRIDGE_STATE_FLUSH_SECONDS  snapshot interval (default 5)
+//
+// It returns nil when persistence is disabled.
+func loadStatePersistence() (*statePersistence, error) {
+	dir := strings.TrimSpace(os.Getenv("BRIDGE_STATE_DIR"))
+	if dir == "" {
+		return nil, nil
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(os.Getenv("BRIDGE_STATE_KEY")))
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("BRIDGE_STATE_KEY must be 32 bytes hex when BRIDGE_STATE_DIR is set")
+	}
+	every, err := envSeconds("BRIDGE_STATE_FLUSH_SECONDS", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if every <= 0 {
+		return nil, fmt.Errorf("BRIDGE_STATE_FLUSH_SECONDS must be positive")
+	}
+
+	p := &statePersistence{every: every}
+	add := func(name string, snapshot func() any) error {
+		f, err := store.NewSealedFile(filepath.Join(dir, name+".sealed"), key, "privxx-bridge-state/"+name)
+		if err != nil {
+			return err
+		}
+		p.items = append(p.items, &persistedState{name: name, file: f, snapshot: snapshot})
+		return nil
+	}
+	if err := add("ratelimit", func() any { return rateLimiter.snapshot() }); err != nil {
+		return nil, err
+	}
+	if err := add("identity", func() any { return identityManager.snapshot() }); err != nil {
+		return nil, err
+	}
+	if err := add("phase1_sessions", func() any { return phase1SessionMgr.Snapshot() }); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// restore loads every snapshot into its live structure. Call before serving.
+func (p *statePersistence) restore() error {
+	for _, it := range p.items {
+		var (
+			ok  bool
+			err error
+			n   int
+		)
+		switch it.name {
+		case "ratelimit":
+			var snap map[string]RateLimitEntry
+			if ok, err = it.file.Load(&snap); ok && err == nil {
+				n = rateLimiter.restore(snap)
+			}
+		case "identity":
+			var snap []persistedIdentitySession
+			if ok, err = it.file.Load(&snap); ok && err == nil {
+				n = identityManager.restore(snap)
+			}
+		case "phase1_sessions":
+			var snap []sessions.Session
+			if ok, err = it.file.Load(&snap); ok && err == nil {
+				n = phase1SessionMgr.Restore(snap)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("restore %s: %w", it.name, err)
+		}
+		if ok {
+			logging.Info(context.Background(), "state restored",
+				logging.Public("item", logging.Label(it.name)), logging.Public("live_entries", n))
+		}
+	}
+	return nil
+}
+
+// flush writes every snapshot whose content changed since the last write.
+func (p *statePersistence) flush() {
+	for _, it := range p.items {
+		v := it.snapshot()
+		b, err := json.Marshal(v)
+		if err != nil {
+			logging.Error(context.Background(), "state snapshot failed",
+				logging.Public("item", logging.Label(it.name)), logging.Err(err))
+			continue
+		}
+		sum := sha256.Sum256(b)
+		if sum == it.last {
+			continue
+		}
+		if err := it.file.Save(v); err != nil {
+			logging.Error(context.Background(), "state save failed",
+				logging.Public("item", logging.Label(it.name)), logging.Err(err))
+			continue
+		}
+		it.last = sum
+	}
+}
+
+// start flushes on every interval until ctx is done, then flushes once more.
+// The returned channel closes after that final flush.
+func (p *statePersistence) start(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(p.every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.flush()
+			case <-ctx.Done():
+				p.flush()
+				return
+			}
+		}
+	}()
+	return done
+}
+
+// ---- RateLimiter ----
+
+// snapshot copies the entries that still matter (open window or active lockout).
+func (rl *RateLimiter) snapshot() map[string]RateLimitEntry {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+
+	now := time.Now()
+	out := make(map[string]RateLimitEntry, len(rl.entries))
+	for ip, e := range rl.entries {
+		if rl.entryExpired(e, now) {
+			continue
+		}
+		out[ip] = *e
+	}
+	return out
+}
+
+func (rl *RateLimiter) restore(snap map[string]RateLimitEntry) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	n := 0
+	for ip, e := range snap {
+		e := e
+		if rl.entryExpired(&e, now) {
+			continue
+		}
+		rl.entries[ip] = &e
+		n++
+	}
+	return n
+}
+
+// entryExpired mirrors cleanup: window over and no lockout in force.
+func (rl *RateLimiter) entryExpired(e *RateLimitEntry, now time.Time) bool {
+	windowExpired := now.Sub(e.FirstAttempt) > rl.config.WindowDuration
+	lockExpired := e.LockedUntil.IsZero() || now.After(e.LockedUntil)
+	return windowExpired && lockExpired
+}
+
+// ---- IdentityManager ----
+
+func (im *IdentityManager) snapshot() []persistedIdentitySession {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
+
+	now := time.Now()
+	out := make([]persistedIdentitySession, 0, len(im.sessions))
+	for _, s := range im.sessions {
+		if now.After(s.ExpiresAt) {
+			continue
+		}
+		out = append(out, persistedIdentitySession{
+			UserID:       s.UserID,
+			XXIdentityID: s.XXIdentityID,
+			UnlockedAt:   s.UnlockedAt,
+			ExpiresAt:    s.ExpiresAt,
+			LastActivity: s.LastActivity,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UserID < out[j].UserID })
+	return out
+}
+
+func (im *IdentityManager) restore(snap []persistedIdentitySession) int {
+	im.mu.Lock()
+	defer im.mu.Unlock()
+
+	now := time.Now()
+	n := 0
+	for _, s := range snap {
+		if s.UserID == "" || now.After(s.ExpiresAt) {
+			continue
+		}
+		im.sessions[s.UserID] = &IdentitySession{
+			UserID:       s.UserID,
+			XXIdentityID: s.XXIdentityID,
+			UnlockedAt:   s.UnlockedAt,
+			ExpiresAt:    s.ExpiresAt,
+			LastActivity: s.LastActivity,
+		}
+		n++
+	}
+	return n
+}
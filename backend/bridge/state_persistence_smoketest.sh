@@ -0,0 +1,74 @@
+#!/usr/bin/env bash
+# Simulates a bridge restart with BRIDGE_STATE_DIR set and checks that an
+# unlock session, a Phase-1 session and a failed-auth lockout all survive it.
+# Builds the bridge and core stub unless BRIDGE_BIN / STUB_BIN point at binaries.
+set -euo pipefail
+
+PORT="${PORT:-18790}"
+BASE="http://127.0.0.1:$PORT"
+CORE_PORT="${CORE_PORT:-18893}"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" "${CORE_PID:-}" 2>/dev/null; wait 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${BRIDGE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/bridge"
+  go build -o "$BIN" .
+fi
+STUB="${STUB_BIN:-}"
+if [ -z "$STUB" ]; then
+  STUB="$WORK/stub"
+  (cd ../core && go build -o "$STUB" ./cmd/privxx-backend-stub)
+fi
+# Unlock and connect go to core; the stub stands in for it.
+"$STUB" -addr "127.0.0.1:$CORE_PORT" >"$WORK/stub.log" 2>&1 &
+CORE_PID=$!
+export BACKEND_URL="http://127.0.0.1:$CORE_PORT"
+for _ in $(seq 50); do curl -sf "$BACKEND_URL/health" >/dev/null && break; sleep 0.1; done
+
+export ENVIRONMENT=development AUTH_DEV_ISSUER=true BRIDGE_MODE=phase1 PORT
+export AUTH_DEV_ISSUER_KEY=$(openssl rand -hex 32)
+export BRIDGE_CONTACTS_KEY=$(openssl rand -hex 32)
+export BRIDGE_STATE_KEY=$(openssl rand -hex 32)
+export BRIDGE_DATA_DIR="$WORK/data" BRIDGE_STATE_DIR="$WORK/state" BRIDGE_STATE_FLUSH_SECONDS=1
+
+start() {
+  "$BIN" >>"$WORK/bridge.log" 2>&1 &
+  PID=$!
+  for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && return; sleep 0.1; done
+  echo "bridge did not start"; cat "$WORK/bridge.log"; exit 1
+}
+restart() { sleep 2; kill "$PID"; wait "$PID" 2>/dev/null || true; start; }
+
+start
+TOKEN=$(curl -sS -X POST "$BASE/dev/token" -d '{"sub":"persist-user"}' | jq -er '.access_token')
+AUTH=(-H "Authorization: Bearer $TOKEN" -H "Content-Type: application/json")
+
+echo "== unlock + issue inbox session =="
+curl -sS -X POST "$BASE/unlock" "${AUTH[@]}" -d '{"password":"smoke"}' | jq -e '.success == true'
+SID=$(curl -sS -X POST "$BASE/session/issue" "${AUTH[@]}" -d '{"purpose":"message_receive"}' | jq -er '.sessionId')
+
+echo "== restart =="
+restart
+
+echo "== unlock session survived =="
+curl -sS "$BASE/unlock/status" "${AUTH[@]}" | jq -e '.unlocked == true'
+# /disconnect is gated on the bridge's own copy of the unlock.
+curl -sS -X POST "$BASE/disconnect" "${AUTH[@]}" | jq -e '.success == true'
+echo "== phase-1 session survived =="
+curl -sS -X POST "$BASE/v1/message/inbox" "${AUTH[@]}" -d "{\"sessionId\":\"$SID\"}" | jq -e 'has("items") and (.error == null)'
+
+echo "== trigger failed-auth lockout =="
+for _ in $(seq 10); do
+  curl -sS -o /dev/null -X POST "$BASE/unlock" -H "Authorization: Bearer bad.token.value"
+done
+curl -sS -o /dev/null -w '%{http_code}\n' "$BASE/unlock/status" "${AUTH[@]}" | grep -qx 429
+
+echo "== restart =="
+restart
+
+echo "== lockout survived =="
+curl -sS -o /dev/null -w '%{http_code}\n' "$BASE/unlock/status" "${AUTH[@]}" | grep -qx 429
+
+echo
+echo "state persistence OK"
@@ -0,0 +1,28 @@
+#!/usr/bin/env bash
+# Token verifier chain check: runs the bridge against a local IdP (httptest
+# JWKS + /auth/v1/user) with locally generated ES256/HS256 keys. See
+# cmd/verifier-check. Builds both unless BRIDGE_BIN / CHECK_BIN point at
+# binaries. Takes ~35s: the unknown-kid case waits out the JWKS refresh limit
+# (SKIP_REFRESH=1 skips it).
+set -euo pipefail
+
+cd "$(dirname "$0")"
+WORK=$(mktemp -d)
+trap 'rm -rf "$WORK"' EXIT
+
+BRIDGE="${BRIDGE_BIN:-}"
+if [ -z "$BRIDGE" ]; then
+  BRIDGE="$WORK/bridge"
+  go build -o "$BRIDGE" .
+fi
+CHECK="${CHECK_BIN:-}"
+if [ -z "$CHECK" ]; then
+  CHECK="$WORK/verifier-check"
+  go build -o "$CHECK" ./cmd/verifier-check
+fi
+
+ARGS=(-bridge "$BRIDGE")
+if [ "${SKIP_REFRESH:-}" = "1" ]; then
+  ARGS+=(-skip-refresh)
+fi
+"$CHECK" "${ARGS[@]}"
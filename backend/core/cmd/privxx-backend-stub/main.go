@@ -6,17 +6,24 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/Bulldog-Master/privxx/backend/core/internal/httpapi"
+	"github.com/Bulldog-Master/privxx/backend/core/internal/server"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
 )
 
+// The stub is the core server with stub=true in /health; it exists so
+// deployments that start privxx-backend-stub keep working.
 func main() {
 	addr := flag.String("addr", "127.0.0.1:8091", "listen address")
 	ttl := flag.Duration("ttl", 15*time.Minute, "identity session TTL (stub)")
 	flag.Parse()
+	if _, err := logging.Setup("core-stub"); err != nil {
+		log.Fatal(err)
+	}
 
-	_ = ttl // kept for compatibility with earlier stub behavior
-
-	s := httpapi.NewServer()
+	s, err := server.New(server.Config{IdentityTTL: *ttl, Stub: true})
+	if err != nil {
+		log.Fatal(err)
+	}
 	log.Printf("[BACKEND] stub starting on %s (ttl=%s)", *addr, ttl.String())
 	log.Fatal(http.ListenAndServe(*addr, s.Handler()))
 }
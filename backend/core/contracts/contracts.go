@@ -1,72 +1,331 @@
+// Package contracts is the wire format of the backend core API. The core
+// server and the bridge's backend client both use these types; the OpenAPI
+// document (core -openapi) is generated from them.
 package contracts
 
+import "net/http"
+
 // Contract versioning
 const ContractVersionV1 = 1
 
-// Standard error codes (minimum set to satisfy current httpapi usage)
+// Standard error codes. Every failure carries one of these in error.code.
+const (
+	ErrInvalidInput       = "INVALID_INPUT"
+	ErrInvalidIntent      = "INVALID_INTENT"
+	ErrInvalidURL         = "INVALID_URL"
+	ErrUnsupportedVersion = "UNSUPPORTED_VERSION"
+	ErrInvalidPassword    = "INVALID_PASSWORD"
+	ErrSessionLocked      = "SESSION_LOCKED"
+	ErrNotFound           = "NOT_FOUND"
+	ErrMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	ErrInternal           = "INTERNAL"
+)
+
+// ErrorCodes lists every code above (for the OpenAPI enum).
+var ErrorCodes = []string{
+	ErrInvalidInput, ErrInvalidIntent, ErrInvalidURL, ErrUnsupportedVersion,
+	ErrInvalidPassword, ErrSessionLocked, ErrNotFound, ErrMethodNotAllowed, ErrInternal,
+}
+
+// HTTPStatus is the status code the core answers with for an error code.
+func HTTPStatus(code string) int {
+	switch code {
+	case ErrInvalidPassword:
+		return http.StatusUnauthorized
+	case ErrSessionLocked:
+		return http.StatusForbidden
+	case ErrNotFound:
+		return http.StatusNotFound
+	case ErrMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	case ErrInternal, "":
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// RequestIDHeader carries the caller's request ID; the core echoes it back and
+// copies it into the result's requestId.
+const RequestIDHeader = "X-Request-Id"
+
+// Endpoint paths. Every contract endpoint takes a POST with the JSON intent.
 const (
-	ErrInvalidInput  = "INVALID_INPUT"
-	ErrInvalidIntent = "INVALID_INTENT"
-	ErrInternal      = "INTERNAL"
+	PathHealth             = "/health"
+	PathOpenAPI            = "/openapi.json"
+	PathConnect            = "/v1/connect"
+	PathDisconnect         = "/v1/disconnect"
+	PathStatus             = "/v1/status"
+	PathCreateConversation = "/v1/conversations/create"
+	PathOpenConversation   = "/v1/conversations/open"
+	PathListConversations  = "/v1/conversations/list"
+	PathSendMessage        = "/v1/message/send"
+	PathInbox              = "/v1/message/inbox"
+	PathThread             = "/v1/message/thread"
+	PathAck                = "/v1/message/ack"
+	PathUnlock             = "/v1/identity/unlock"
+	PathLock               = "/v1/identity/lock"
+	PathIdentityStatus     = "/v1/identity/status"
 )
 
 // Ptr is a tiny helper to take addresses of string literals safely.
 func Ptr(s string) *string { return &s }
 
 // ErrorResponse is the canonical error payload returned by backend endpoints.
+// It is also the error handlers return inside the core.
 type ErrorResponse struct {
 	Code    string  `json:"code,omitempty"`
 	Message *string `json:"message,omitempty"`
 }
 
-// ----- Intents (requests) -----
+func (e *ErrorResponse) Error() string {
+	if e.Message != nil {
+		return e.Code + ": " + *e.Message
+	}
+	return e.Code
+}
 
-type ConnectIntent struct {
+// ----- Envelopes -----
+
+// Intent is the envelope every request embeds.
+type Intent struct {
 	V         int    `json:"v"`
 	UserID    string `json:"userId,omitempty"`
-	Domain    string `json:"domain,omitempty"`
 	RequestID string `json:"requestId,omitempty"`
 }
 
-type OpenConversationIntent struct {
-	V               int    `json:"v"`
-	UserID          string `json:"userId,omitempty"`
-	ConversationID  string `json:"conversationId,omitempty"`
-	ParticipantHint string `json:"participantHint,omitempty"`
-	RequestID       string `json:"requestId,omitempty"`
-}
-
-// ----- Results (responses) -----
+// IntentHead gives generic code access to the embedded envelope.
+func (h *Intent) IntentHead() *Intent { return h }
 
-type ConnectResult struct {
+// Result is the envelope every response embeds. On failure ok is false and
+// error is set; the other fields are zero.
+type Result struct {
 	V         int            `json:"v"`
 	RequestID string         `json:"requestId,omitempty"`
 	Ok        bool           `json:"ok"`
 	Error     *ErrorResponse `json:"error,omitempty"`
 }
 
+// ResultHead gives generic code access to the embedded envelope.
+func (h *Result) ResultHead() *Result { return h }
+
+// ----- Health -----
+
+type Health struct {
+	Status       string          `json:"status"`
+	Version      string          `json:"version"`
+	Stub         bool            `json:"stub"`
+	XXDKReady    bool            `json:"xxdkReady"`
+	Capabilities map[string]bool `json:"capabilities,omitempty"`
+}
+
+// ----- Connect -----
+
+type ConnectIntent struct {
+	Intent
+	Domain    string `json:"domain,omitempty"`
+	TargetURL string `json:"targetUrl,omitempty"`
+}
+
+type ConnectResult struct {
+	Result
+	Status string `json:"status,omitempty"` // "connected"
+}
+
+type DisconnectIntent struct {
+	Intent
+}
+
+type DisconnectResult struct {
+	Result
+	Status string `json:"status,omitempty"` // "disconnected"
+}
+
+type StatusIntent struct {
+	Intent
+}
+
+type StatusResult struct {
+	Result
+	BackendReady bool   `json:"backendReady"`
+	XXDKReady    bool   `json:"xxdkReady"`
+	Unlocked     bool   `json:"unlocked"`
+	ExpiresAt    string `json:"expiresAt,omitempty"` // RFC 3339
+}
+
+// ----- Conversations -----
+
+// Conversation kinds, states and participant roles. The bridge's own
+// conversation records use the same values.
+const (
+	KindDirect = "direct"
+	KindGroup  = "group"
+
+	StateActive   = "active"
+	StateArchived = "archived"
+
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// MaxParticipants caps a conversation's size, creator included.
+const MaxParticipants = 32
+
+// Conversation is the core's conversation record. Only participants ever see
+// it; to anyone else it does not exist (NOT_FOUND).
+type Conversation struct {
+	ConversationID string        `json:"conversationId"`
+	Kind           string        `json:"kind"`  // KindDirect | KindGroup
+	State          string        `json:"state"` // StateActive | StateArchived
+	CreatedBy      string        `json:"createdBy"`
+	CreatedAt      string        `json:"createdAt"` // RFC 3339
+	Participants   []Participant `json:"participants"`
+}
+
+type Participant struct {
+	UserID   string `json:"userId"`
+	Role     string `json:"role"`     // RoleAdmin | RoleMember
+	JoinedAt string `json:"joinedAt"` // RFC 3339
+}
+
+// CreateConversationIntent creates a conversation between the caller and
+// Participants. A direct conversation names exactly one other participant
+// and is reused if the pair already has one; a group makes the caller admin.
+type CreateConversationIntent struct {
+	Intent
+	Kind         string   `json:"kind,omitempty"` // default KindDirect
+	Participants []string `json:"participants"`
+}
+
+type CreateConversationResult struct {
+	Result
+	Conversation *Conversation `json:"conversation,omitempty"`
+}
+
+// OpenConversationIntent returns the record for ConversationID, or with only
+// ParticipantHint, the caller's direct conversation with that user (created
+// on first use).
+type OpenConversationIntent struct {
+	Intent
+	ConversationID  string `json:"conversationId,omitempty"`
+	ParticipantHint string `json:"participantHint,omitempty"`
+}
+
 type OpenConversationResult struct {
-	V              int            `json:"v"`
-	RequestID      string         `json:"requestId,omitempty"`
-	ConversationID string         `json:"conversationId,omitempty"`
-	Ok             bool           `json:"ok"`
-	Error          *ErrorResponse `json:"error,omitempty"`
+	Result
+	ConversationID string        `json:"conversationId,omitempty"`
+	Conversation   *Conversation `json:"conversation,omitempty"`
+}
+
+// ListConversationsIntent lists the caller's conversations, oldest first,
+// optionally only those in State.
+type ListConversationsIntent struct {
+	Intent
+	State string `json:"state,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type ListConversationsResult struct {
+	Result
+	Conversations []Conversation `json:"conversations"`
 }
 
 // ----- Messaging -----
 
+// SendMessageIntent sends to Recipients, or to every other participant of
+// the conversation when empty. The conversation must exist (see
+// CreateConversationIntent) and list the sender and every recipient.
 type SendMessageIntent struct {
-	V              int    `json:"v"`
-	UserID         string `json:"userId,omitempty"`
-	ConversationID string `json:"conversationId,omitempty"`
-	Payload        string `json:"payload,omitempty"`
-	RequestID      string `json:"requestId,omitempty"`
+	Intent
+	ConversationID string   `json:"conversationId,omitempty"`
+	Recipients     []string `json:"recipients,omitempty"`
+	Payload        string   `json:"payload,omitempty"`
 }
 
 type SendMessageResult struct {
-	V         int            `json:"v"`
-	RequestID string         `json:"requestId,omitempty"`
-	Ok        bool           `json:"ok"`
-	Accepted  bool           `json:"accepted,omitempty"`
-	Error     *ErrorResponse `json:"error,omitempty"`
+	Result
+	Accepted  bool   `json:"accepted,omitempty"`
+	MessageID string `json:"messageId,omitempty"`
+}
+
+// Message is one stored message as returned by inbox and thread. Consumed
+// is from the caller's side: for a recipient, whether they acked it; for the
+// sender, whether every recipient did.
+type Message struct {
+	ID             string   `json:"id"`
+	ConversationID string   `json:"conversationId"`
+	SenderID       string   `json:"senderId"`
+	Recipients     []string `json:"recipients"`
+	Payload        string   `json:"payload"`
+	CreatedAt      string   `json:"createdAt"` // RFC 3339
+	Consumed       bool     `json:"consumed"`
+}
+
+// InboxIntent lists messages the caller received.
+type InboxIntent struct {
+	Intent
+	Limit          int  `json:"limit,omitempty"`
+	UnconsumedOnly bool `json:"unconsumedOnly,omitempty"`
+}
+
+type InboxResult struct {
+	Result
+	Messages []Message `json:"messages"`
+}
+
+type ThreadIntent struct {
+	Intent
+	ConversationID string `json:"conversationId,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+}
+
+type ThreadResult struct {
+	Result
+	ConversationID string    `json:"conversationId,omitempty"`
+	Messages       []Message `json:"messages"`
+}
+
+// AckIntent marks messages the caller received as consumed. All IDs must
+// be the caller's received messages, or none are marked (NOT_FOUND).
+type AckIntent struct {
+	Intent
+	MessageIDs []string `json:"messageIds"`
+}
+
+type AckResult struct {
+	Result
+	Acked int `json:"acked"` // newly marked; repeats count zero
+}
+
+// ----- Identity -----
+
+type UnlockIntent struct {
+	Intent
+	Password string `json:"password,omitempty"`
+}
+
+type UnlockResult struct {
+	Result
+	ExpiresAt  string `json:"expiresAt,omitempty"` // RFC 3339
+	TTLSeconds int    `json:"ttlSeconds,omitempty"`
+}
+
+type LockIntent struct {
+	Intent
+}
+
+type LockResult struct {
+	Result
+}
+
+type IdentityStatusIntent struct {
+	Intent
+}
+
+type IdentityStatusResult struct {
+	Result
+	Unlocked   bool   `json:"unlocked"`
+	UnlockedAt string `json:"unlockedAt,omitempty"` // RFC 3339
+	ExpiresAt  string `json:"expiresAt,omitempty"`  // RFC 3339
+	TTLSeconds int    `json:"ttlSeconds"`
 }
@@ -0,0 +1,181 @@
+package msgstore
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+)
+
+// Conversation is a stored conversation record. Kind, State and Role use the
+// contracts values.
+type Conversation struct {
+	ID           string        `json:"id"`
+	Kind         string        `json:"kind"`
+	State        string        `json:"state"`
+	CreatedBy    string        `json:"createdBy"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	Participants []Participant `json:"participants"`
+}
+
+type Participant struct {
+	UserID   string    `json:"userId"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joinedAt"`
+}
+
+func (c *Conversation) has(userID string) bool {
+	for _, p := range c.Participants {
+		if p.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Conversation) view() contracts.Conversation {
+	out := contracts.Conversation{
+		ConversationID: c.ID,
+		Kind:           c.Kind,
+		State:          c.State,
+		CreatedBy:      c.CreatedBy,
+		CreatedAt:      c.CreatedAt.UTC().Format(time.RFC3339),
+		Participants:   make([]contracts.Participant, len(c.Participants)),
+	}
+	for i, p := range c.Participants {
+		out.Participants[i] = contracts.Participant{
+			UserID:   p.UserID,
+			Role:     p.Role,
+			JoinedAt: p.JoinedAt.UTC().Format(time.RFC3339),
+		}
+	}
+	return out
+}
+
+// addConversation indexes c. Callers hold s.mu (or are replaying).
+func (s *Store) addConversation(c *Conversation) {
+	s.convs[c.ID] = c
+	for _, p := range c.Participants {
+		s.byUser[p.UserID] = append(s.byUser[p.UserID], c)
+	}
+}
+
+// member returns convID when userID participates in it.
+func (s *Store) member(convID, userID string) (*Conversation, bool) {
+	c := s.convs[convID]
+	if c == nil || !c.has(userID) {
+		return nil, false
+	}
+	return c, true
+}
+
+// CreateConversation creates a conversation between creatorID and others.
+// A direct conversation takes exactly one other participant; if the pair
+// already has an active one, that is returned instead. In a group the
+// creator is admin.
+func (s *Store) CreateConversation(creatorID, kind string, others []string) (contracts.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	others = dedupe(others, creatorID)
+	switch kind {
+	case "", contracts.KindDirect:
+		kind = contracts.KindDirect
+		if len(others) != 1 {
+			return contracts.Conversation{}, fmt.Errorf("%w: a direct conversation takes exactly one other participant", ErrInvalid)
+		}
+		if c := s.direct(creatorID, others[0]); c != nil {
+			return c.view(), nil
+		}
+	case contracts.KindGroup:
+		if len(others) == 0 {
+			return contracts.Conversation{}, ErrNoRecipients
+		}
+		if len(others)+1 > contracts.MaxParticipants {
+			return contracts.Conversation{}, fmt.Errorf("%w: at most %d participants", ErrInvalid, contracts.MaxParticipants)
+		}
+	default:
+		return contracts.Conversation{}, fmt.Errorf("%w: unknown kind %q", ErrInvalid, kind)
+	}
+
+	now := time.Now().UTC()
+	creatorRole := contracts.RoleMember
+	if kind == contracts.KindGroup {
+		creatorRole = contracts.RoleAdmin
+	}
+	c := &Conversation{
+		ID:           newConversationID(),
+		Kind:         kind,
+		State:        contracts.StateActive,
+		CreatedBy:    creatorID,
+		CreatedAt:    now,
+		Participants: []Participant{{UserID: creatorID, Role: creatorRole, JoinedAt: now}},
+	}
+	for _, u := range others {
+		c.Participants = append(c.Participants, Participant{UserID: u, Role: contracts.RoleMember, JoinedAt: now})
+	}
+	if err := s.commit(entry{Op: opCreate, Conv: c}); err != nil {
+		return contracts.Conversation{}, err
+	}
+	return c.view(), nil
+}
+
+// direct returns the active direct conversation between a and b, if any.
+func (s *Store) direct(a, b string) *Conversation {
+	for _, c := range s.byUser[a] {
+		if c.Kind == contracts.KindDirect && c.State == contracts.StateActive && c.has(b) {
+			return c
+		}
+	}
+	return nil
+}
+
+// Conversation returns convID for a participant; ErrNotFound otherwise.
+func (s *Store) Conversation(userID, convID string) (contracts.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.member(convID, userID)
+	if !ok {
+		return contracts.Conversation{}, ErrNotFound
+	}
+	return c.view(), nil
+}
+
+// Conversations lists userID's conversations, oldest first, at most limit
+// (0 = all). A non-empty state keeps only conversations in that state.
+func (s *Store) Conversations(userID, state string, limit int) []contracts.Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var src []*Conversation
+	for _, c := range s.byUser[userID] {
+		if state == "" || c.State == state {
+			src = append(src, c)
+		}
+	}
+	// Replay after compaction may index in another order; sort for stability.
+	sort.Slice(src, func(i, j int) bool {
+		if !src[i].CreatedAt.Equal(src[j].CreatedAt) {
+			return src[i].CreatedAt.Before(src[j].CreatedAt)
+		}
+		return src[i].ID < src[j].ID
+	})
+	if limit > 0 && len(src) > limit {
+		src = src[:limit]
+	}
+	out := make([]contracts.Conversation, len(src))
+	for i, c := range src {
+		out[i] = c.view()
+	}
+	return out
+}
+
+func newConversationID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "conv_" + hex.EncodeToString(b[:])
+}
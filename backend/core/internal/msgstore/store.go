@@ -0,0 +1,365 @@
+// Package msgstore is the backend core's conversation and message store.
+// Conversations record their participants; messages record their sender,
+// recipients and conversation, and each recipient acknowledges (consumes) a
+// message separately. With a data directory every change is written to a
+// write-ahead log before it is applied, so a restart loses nothing that was
+// acknowledged to a caller.
+package msgstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+var (
+	// ErrNotFound covers unknown messages and conversations the caller is not
+	// a member of, so existence never leaks to non-members.
+	ErrNotFound = errors.New("not found")
+	// ErrNoRecipients means a send or group named nobody besides the caller.
+	ErrNoRecipients = errors.New("no recipients")
+	// ErrInvalid wraps a malformed conversation request.
+	ErrInvalid = errors.New("invalid conversation")
+	// ErrClosed means the store was closed (the core is shutting down).
+	ErrClosed = errors.New("message store closed")
+	// ErrLogFailed means an earlier write to the log failed and could not be
+	// rolled back; the store refuses changes until it is reopened.
+	ErrLogFailed = errors.New("message log failed")
+)
+
+// Record is a stored message.
+type Record struct {
+	ID             string               `json:"id"`
+	ConversationID string               `json:"conversationId"`
+	SenderID       string               `json:"senderId"`
+	Recipients     []string             `json:"recipients"`
+	Payload        string               `json:"payload"`
+	CreatedAt      time.Time            `json:"createdAt"`
+	Acked          map[string]time.Time `json:"acked,omitempty"` // by recipient
+}
+
+func (r *Record) isRecipient(userID string) bool {
+	for _, u := range r.Recipients {
+		if u == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// view renders r for userID: a recipient sees whether they consumed it, the
+// sender whether every recipient did.
+func (r *Record) view(userID string) contracts.Message {
+	consumed := len(r.Acked) == len(r.Recipients)
+	if userID != r.SenderID {
+		_, consumed = r.Acked[userID]
+	}
+	return contracts.Message{
+		ID:             r.ID,
+		ConversationID: r.ConversationID,
+		SenderID:       r.SenderID,
+		Recipients:     append([]string(nil), r.Recipients...),
+		Payload:        r.Payload,
+		CreatedAt:      r.CreatedAt.UTC().Format(time.RFC3339),
+		Consumed:       consumed,
+	}
+}
+
+// Store holds conversations and their messages. Without a data directory
+// (New) it is memory-only.
+type Store struct {
+	mu          sync.Mutex
+	convs       map[string]*Conversation
+	byUser      map[string][]*Conversation // by participant
+	byID        map[string]*Record
+	byConv      map[string][]*Record
+	byRecipient map[string][]*Record
+	wal         *wal // nil when memory-only
+	closed      bool
+}
+
+// New returns a memory-only store (clears on restart).
+func New() *Store {
+	return &Store{
+		byID:        make(map[string]*Record),
+		byConv:      make(map[string][]*Record),
+		byRecipient: make(map[string][]*Record),
+		convs:       make(map[string]*Conversation),
+		byUser:      make(map[string][]*Conversation),
+	}
+}
+
+// Open loads the store from dir (snapshot, then log) and logs every later
+// change there.
+func Open(dir string) (*Store, error) {
+	s := New()
+	w, err := openWAL(dir, s.apply)
+	if err != nil {
+		return nil, err
+	}
+	s.wal = w
+	return s, nil
+}
+
+// Close flushes and closes the log. Later changes fail with ErrClosed, so
+// nothing is acknowledged that the log does not hold.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.wal == nil {
+		return nil
+	}
+	err := s.wal.close()
+	s.wal = nil
+	return err
+}
+
+// Stats are the store's sizes.
+type Stats struct {
+	Conversations int
+	Messages      int
+	LogEntries    int // in the write-ahead log since the last snapshot
+}
+
+func (s *Store) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := Stats{Conversations: len(s.convs), Messages: len(s.byID)}
+	if s.wal != nil {
+		st.LogEntries = s.wal.entries
+	}
+	return st
+}
+
+// ObserveSync calls f with the duration of every log fsync. It does nothing
+// for a memory-only store.
+func (s *Store) ObserveSync(f func(time.Duration)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal != nil {
+		s.wal.onSync = f
+	}
+}
+
+// commit logs e (when persistent) and applies it. Callers hold s.mu.
+func (s *Store) commit(e entry) error {
+	if s.closed {
+		return ErrClosed
+	}
+	if s.wal == nil {
+		s.apply(e)
+		return nil
+	}
+	if err := s.wal.append(e); err != nil {
+		return err
+	}
+	s.apply(e)
+	if s.wal.due() {
+		// The log still holds everything if this fails; try again later.
+		if err := s.wal.compact(s.snapshot()); err != nil {
+			logging.Error(context.Background(), "message store compaction failed", logging.Err(err))
+		}
+	}
+	return nil
+}
+
+// apply changes memory for one entry. Replaying an entry twice is harmless:
+// a snapshot taken just before a crash may overlap the log.
+func (s *Store) apply(e entry) {
+	switch e.Op {
+	case opCreate:
+		if e.Conv == nil || s.convs[e.Conv.ID] != nil {
+			return
+		}
+		s.addConversation(e.Conv)
+	case opJoin:
+		// Logs written before conversation records existed: membership only.
+		c := s.convs[e.ConversationID]
+		if c == nil {
+			c = &Conversation{ID: e.ConversationID, Kind: contracts.KindGroup, State: contracts.StateActive}
+			s.convs[c.ID] = c
+		}
+		for _, u := range e.Users {
+			if !c.has(u) {
+				c.Participants = append(c.Participants, Participant{UserID: u, Role: contracts.RoleMember})
+				s.byUser[u] = append(s.byUser[u], c)
+			}
+		}
+	case opAdd:
+		if e.Msg == nil || s.byID[e.Msg.ID] != nil {
+			return
+		}
+		r := e.Msg
+		s.byID[r.ID] = r
+		s.byConv[r.ConversationID] = append(s.byConv[r.ConversationID], r)
+		for _, u := range r.Recipients {
+			s.byRecipient[u] = append(s.byRecipient[u], r)
+		}
+	case opAck:
+		for _, id := range e.IDs {
+			r := s.byID[id]
+			if r == nil || !r.isRecipient(e.UserID) {
+				continue
+			}
+			if _, done := r.Acked[e.UserID]; done {
+				continue
+			}
+			if r.Acked == nil {
+				r.Acked = make(map[string]time.Time)
+			}
+			r.Acked[e.UserID] = *e.At
+		}
+	}
+}
+
+// Add stores a message from senderID in convID. The sender and every named
+// recipient must be participants; with no recipients named, every other
+// participant receives it.
+func (s *Store) Add(senderID, convID string, recipients []string, payload string) (contracts.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.member(convID, senderID)
+	if !ok {
+		return contracts.Message{}, ErrNotFound
+	}
+	recips := dedupe(recipients, senderID)
+	if len(recips) == 0 {
+		for _, p := range c.Participants {
+			if p.UserID != senderID {
+				recips = append(recips, p.UserID)
+			}
+		}
+	}
+	for _, u := range recips {
+		if !c.has(u) {
+			return contracts.Message{}, ErrNotFound
+		}
+	}
+	if len(recips) == 0 {
+		return contracts.Message{}, ErrNoRecipients
+	}
+
+	r := &Record{
+		ID:             newMessageID(),
+		ConversationID: convID,
+		SenderID:       senderID,
+		Recipients:     recips,
+		Payload:        payload,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.commit(entry{Op: opAdd, Msg: r}); err != nil {
+		return contracts.Message{}, err
+	}
+	return r.view(senderID), nil
+}
+
+// Inbox returns messages userID received, newest last, at most limit
+// (0 = all). unconsumedOnly drops those userID already acknowledged.
+func (s *Store) Inbox(userID string, limit int, unconsumedOnly bool) []contracts.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var src []*Record
+	for _, r := range s.byRecipient[userID] {
+		if _, done := r.Acked[userID]; unconsumedOnly && done {
+			continue
+		}
+		src = append(src, r)
+	}
+	return views(src, userID, limit)
+}
+
+// Thread returns convID's messages for a member, newest last.
+func (s *Store) Thread(userID, convID string, limit int) ([]contracts.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.member(convID, userID); !ok {
+		return nil, ErrNotFound
+	}
+	return views(s.byConv[convID], userID, limit), nil
+}
+
+// Ack marks ids consumed for userID and returns how many were newly marked.
+// Every id must be a message userID received; otherwise nothing is marked.
+func (s *Store) Ack(userID string, ids []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids = dedupe(ids, "")
+	n := 0
+	for _, id := range ids {
+		r := s.byID[id]
+		if r == nil || !r.isRecipient(userID) {
+			return 0, ErrNotFound
+		}
+		if _, done := r.Acked[userID]; !done {
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	now := time.Now().UTC()
+	if err := s.commit(entry{Op: opAck, UserID: userID, IDs: ids, At: &now}); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// snapshot is the full state as log entries, for compaction. Callers hold s.mu.
+func (s *Store) snapshot() []entry {
+	out := make([]entry, 0, len(s.convs)+len(s.byID))
+	ids := make([]string, 0, len(s.convs))
+	for id := range s.convs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		out = append(out, entry{Op: opCreate, Conv: s.convs[id]})
+		for _, r := range s.byConv[id] {
+			out = append(out, entry{Op: opAdd, Msg: r})
+		}
+	}
+	return out
+}
+
+func views(src []*Record, userID string, limit int) []contracts.Message {
+	if limit > 0 && len(src) > limit {
+		src = src[len(src)-limit:]
+	}
+	out := make([]contracts.Message, len(src))
+	for i, r := range src {
+		out[i] = r.view(userID)
+	}
+	return out
+}
+
+// dedupe drops empties, duplicates and skip, keeping order.
+func dedupe(in []string, skip string) []string {
+	seen := make(map[string]bool, len(in))
+	var out []string
+	for _, v := range in {
+		if v == "" || v == skip || seen[v] {
+			continue
+		}
+		seen[v] = true
+		out = append(out, v)
+	}
+	return out
+}
+
+func newMessageID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "msg_" + hex.EncodeToString(b[:])
+}
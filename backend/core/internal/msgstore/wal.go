@@ -0,0 +1,200 @@
+package msgstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// Log entry operations.
+const (
+	opCreate = "create" // a conversation is created
+	opJoin   = "join"   // users join a conversation (pre-record logs only)
+	opAdd    = "add"    // a message is stored
+	opAck    = "ack"    // a recipient consumes messages
+)
+
+// entry is one line of the write-ahead log (and of the snapshot).
+type entry struct {
+	Op             string        `json:"op"`
+	ConversationID string        `json:"conversationId,omitempty"`
+	Users          []string      `json:"users,omitempty"`
+	Conv           *Conversation `json:"conv,omitempty"`
+	Msg            *Record       `json:"msg,omitempty"`
+	UserID         string        `json:"userId,omitempty"`
+	IDs            []string      `json:"ids,omitempty"`
+	At             *time.Time    `json:"at,omitempty"`
+}
+
+// compactEvery is how many log entries trigger a snapshot.
+const compactEvery = 1000
+
+// wal is an append-only JSONL log, fsynced per entry, plus a snapshot that
+// replaces the log's prefix on compaction:
+//
+//	messages.snap  full state as entries (written to .tmp, fsynced, renamed)
+//	messages.wal   entries since the snapshot
+//
+// A crash between renaming the snapshot and truncating the log replays some
+// entries twice; Store.apply is idempotent for that reason.
+type wal struct {
+	dir     string
+	f       *os.File
+	entries int                 // in the log since the last snapshot
+	onSync  func(time.Duration) // observes each append's fsync; may be nil
+	failed  error               // set when a failed append could not be rolled back
+}
+
+func openWAL(dir string, apply func(entry)) (*wal, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("dir required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	w := &wal{dir: dir}
+	if _, err := replay(w.snapPath(), apply, false); err != nil {
+		return nil, err
+	}
+	n, err := replay(w.logPath(), apply, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(w.logPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	w.f, w.entries = f, n
+	return w, nil
+}
+
+func (w *wal) logPath() string  { return filepath.Join(w.dir, "messages.wal") }
+func (w *wal) snapPath() string { return filepath.Join(w.dir, "messages.snap") }
+
+// replay applies every entry in path. With allowTorn, an unparseable final
+// line (a write cut short by a crash) is cut off instead of failing.
+func replay(path string, apply func(entry), allowTorn bool) (int, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for off := 0; off < len(b); {
+		end := bytes.IndexByte(b[off:], '\n')
+		last := end < 0 || off+end+1 == len(b)
+		line := b[off:]
+		if end >= 0 {
+			line = b[off : off+end]
+		}
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil || end < 0 {
+			if allowTorn && last {
+				logging.Warn(context.Background(), "dropping torn entry",
+					logging.Public("file", logging.Label(filepath.Base(path))), logging.Public("offset", off))
+				return n, os.Truncate(path, int64(off))
+			}
+			return n, fmt.Errorf("%s: corrupt entry at offset %d", filepath.Base(path), off)
+		}
+		apply(e)
+		n++
+		off += end + 1
+	}
+	return n, nil
+}
+
+// append writes e and fsyncs before returning; the caller applies e after.
+// A failed write or fsync is rolled back by truncating to the prior size, so
+// a later append never lands after a partial line. If that fails too the log
+// is marked failed and every later append returns ErrLogFailed.
+func (w *wal) append(e entry) error {
+	if w.failed != nil {
+		return fmt.Errorf("%w: %v", ErrLogFailed, w.failed)
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	fi, err := w.f.Stat()
+	if err != nil {
+		return err
+	}
+	off := fi.Size()
+	if _, err := w.f.Write(append(b, '\n')); err != nil {
+		return w.rollback(off, err)
+	}
+	start := time.Now()
+	if err := w.f.Sync(); err != nil {
+		return w.rollback(off, err)
+	}
+	if w.onSync != nil {
+		w.onSync(time.Since(start))
+	}
+	w.entries++
+	return nil
+}
+
+// rollback cuts the log back to off after a failed append and returns err.
+func (w *wal) rollback(off int64, err error) error {
+	if terr := w.f.Truncate(off); terr != nil {
+		w.failed = terr
+		logging.Error(context.Background(), "log rollback failed, refusing further writes", logging.Err(terr))
+		return fmt.Errorf("%w: %v", ErrLogFailed, err)
+	}
+	_ = w.f.Sync()
+	return err
+}
+
+func (w *wal) due() bool { return w.entries >= compactEvery }
+
+// compact writes state as the new snapshot and empties the log.
+func (w *wal) compact(state []entry) error {
+	tmp := w.snapPath() + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, e := range state {
+		if err := enc.Encode(e); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, w.snapPath()); err != nil {
+		return err
+	}
+	if d, err := os.Open(w.dir); err == nil {
+		_ = d.Sync()
+		d.Close()
+	}
+	if err := w.f.Truncate(0); err != nil {
+		return err
+	}
+	w.entries = 0
+	return w.f.Sync()
+}
+
+func (w *wal) close() error {
+	if err := w.f.Sync(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
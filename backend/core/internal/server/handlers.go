@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+	"github.com/Bulldog-Master/privxx/backend/core/internal/msgstore"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// Handlers receive an intent whose envelope rpc has already checked
+// (v, userId) and return a result without its envelope, or an error from fail.
+
+// logCtx carries the intent's request ID to the logger.
+func logCtx(in *contracts.Intent) context.Context {
+	return logging.WithRequestID(context.Background(), in.RequestID)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, contracts.Health{
+		Status:  "ok",
+		Version: Version,
+		Stub:    s.cfg.Stub,
+		Capabilities: map[string]bool{
+			"messaging": true,
+			"tunnel":    false,
+			"decrypt":   true,
+		},
+	})
+}
+
+// ---------------- Identity ----------------
+
+func (s *Server) unlock(in *contracts.UnlockIntent) (*contracts.UnlockResult, error) {
+	if strings.TrimSpace(in.Password) == "" {
+		return nil, fail(contracts.ErrInvalidPassword, "password required")
+	}
+	sess := s.identity.unlock(in.UserID)
+	return &contracts.UnlockResult{
+		ExpiresAt:  sess.ExpiresAt.UTC().Format(time.RFC3339),
+		TTLSeconds: int(time.Until(sess.ExpiresAt).Seconds()),
+	}, nil
+}
+
+func (s *Server) lock(in *contracts.LockIntent) (*contracts.LockResult, error) {
+	s.identity.lock(in.UserID)
+	return &contracts.LockResult{}, nil
+}
+
+func (s *Server) identityStatus(in *contracts.IdentityStatusIntent) (*contracts.IdentityStatusResult, error) {
+	out := &contracts.IdentityStatusResult{}
+	if sess, ok := s.identity.get(in.UserID); ok {
+		out.Unlocked = true
+		out.UnlockedAt = sess.UnlockedAt.UTC().Format(time.RFC3339)
+		out.ExpiresAt = sess.ExpiresAt.UTC().Format(time.RFC3339)
+		out.TTLSeconds = max(int(time.Until(sess.ExpiresAt).Seconds()), 0)
+	}
+	return out, nil
+}
+
+// ---------------- Connect ----------------
+
+func (s *Server) connect(in *contracts.ConnectIntent) (*contracts.ConnectResult, error) {
+	if _, ok := s.identity.get(in.UserID); !ok {
+		return nil, fail(contracts.ErrSessionLocked, "identity is locked; unlock first")
+	}
+	if in.TargetURL == "" && in.Domain == "" {
+		return nil, fail(contracts.ErrInvalidInput, "domain or targetUrl required")
+	}
+	if in.TargetURL != "" && !strings.HasPrefix(in.TargetURL, "http://") && !strings.HasPrefix(in.TargetURL, "https://") {
+		return nil, fail(contracts.ErrInvalidURL, "targetUrl must start with http:// or https://")
+	}
+	logging.Info(logCtx(&in.Intent), "connect intent", logging.Pseudonym("user", in.UserID))
+	return &contracts.ConnectResult{Status: "connected"}, nil
+}
+
+func (s *Server) disconnect(in *contracts.DisconnectIntent) (*contracts.DisconnectResult, error) {
+	return &contracts.DisconnectResult{Status: "disconnected"}, nil
+}
+
+func (s *Server) status(in *contracts.StatusIntent) (*contracts.StatusResult, error) {
+	out := &contracts.StatusResult{BackendReady: true}
+	if sess, ok := s.identity.get(in.UserID); ok {
+		out.Unlocked = true
+		out.ExpiresAt = sess.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return out, nil
+}
+
+// ---------------- Phase-5 Messaging ----------------
+
+// storeError maps msgstore errors onto contract codes.
+func storeError(err error) error {
+	switch {
+	case errors.Is(err, msgstore.ErrNotFound):
+		return fail(contracts.ErrNotFound, "conversation or message not found")
+	case errors.Is(err, msgstore.ErrNoRecipients):
+		return fail(contracts.ErrInvalidInput, "no recipients")
+	case errors.Is(err, msgstore.ErrInvalid):
+		return fail(contracts.ErrInvalidInput, err.Error())
+	default:
+		return err
+	}
+}
+
+func (s *Server) createConversation(in *contracts.CreateConversationIntent) (*contracts.CreateConversationResult, error) {
+	conv, err := s.msgStore.CreateConversation(in.UserID, in.Kind, in.Participants)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	logging.Info(logCtx(&in.Intent), "conversation created",
+		logging.Pseudonym("user", in.UserID),
+		logging.Pseudonym("conversation", conv.ConversationID),
+		logging.Public("kind", logging.Label(conv.Kind)))
+	return &contracts.CreateConversationResult{Conversation: &conv}, nil
+}
+
+// openConversation returns a conversation the caller participates in, or
+// finds or creates their direct conversation with participantHint.
+func (s *Server) openConversation(in *contracts.OpenConversationIntent) (*contracts.OpenConversationResult, error) {
+	var (
+		conv contracts.Conversation
+		err  error
+	)
+	switch {
+	case in.ConversationID != "":
+		conv, err = s.msgStore.Conversation(in.UserID, in.ConversationID)
+	case in.ParticipantHint != "":
+		conv, err = s.msgStore.CreateConversation(in.UserID, contracts.KindDirect, []string{in.ParticipantHint})
+	default:
+		return nil, fail(contracts.ErrInvalidInput, "conversationId or participantHint required")
+	}
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &contracts.OpenConversationResult{ConversationID: conv.ConversationID, Conversation: &conv}, nil
+}
+
+func (s *Server) listConversations(in *contracts.ListConversationsIntent) (*contracts.ListConversationsResult, error) {
+	switch in.State {
+	case "", contracts.StateActive, contracts.StateArchived:
+	default:
+		return nil, fail(contracts.ErrInvalidInput, "state must be active or archived")
+	}
+	return &contracts.ListConversationsResult{
+		Conversations: s.msgStore.Conversations(in.UserID, in.State, clampLimit(in.Limit)),
+	}, nil
+}
+
+func (s *Server) sendMessage(in *contracts.SendMessageIntent) (*contracts.SendMessageResult, error) {
+	if in.ConversationID == "" || in.Payload == "" {
+		return nil, fail(contracts.ErrInvalidInput, "conversationId and payload required")
+	}
+	msg, err := s.msgStore.Add(in.UserID, in.ConversationID, in.Recipients, in.Payload)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &contracts.SendMessageResult{Accepted: true, MessageID: msg.ID}, nil
+}
+
+func (s *Server) inbox(in *contracts.InboxIntent) (*contracts.InboxResult, error) {
+	return &contracts.InboxResult{
+		Messages: s.msgStore.Inbox(in.UserID, clampLimit(in.Limit), in.UnconsumedOnly),
+	}, nil
+}
+
+func (s *Server) thread(in *contracts.ThreadIntent) (*contracts.ThreadResult, error) {
+	if in.ConversationID == "" {
+		return nil, fail(contracts.ErrInvalidInput, "conversationId required")
+	}
+	msgs, err := s.msgStore.Thread(in.UserID, in.ConversationID, clampLimit(in.Limit))
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &contracts.ThreadResult{ConversationID: in.ConversationID, Messages: msgs}, nil
+}
+
+func (s *Server) ack(in *contracts.AckIntent) (*contracts.AckResult, error) {
+	if len(in.MessageIDs) == 0 || len(in.MessageIDs) > maxAckIDs {
+		return nil, fail(contracts.ErrInvalidInput, "messageIds: 1 to 500 required")
+	}
+	n, err := s.msgStore.Ack(in.UserID, in.MessageIDs)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return &contracts.AckResult{Acked: n}, nil
+}
+
+// maxAckIDs bounds one ack request.
+const maxAckIDs = 500
+
+// clampLimit keeps list sizes within 1..500 (default 50).
+func clampLimit(n int) int {
+	if n <= 0 {
+		return 50
+	}
+	return min(n, 500)
+}
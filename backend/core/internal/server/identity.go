@@ -0,0 +1,68 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// IdentitySession is one user's unlock.
+type IdentitySession struct {
+	UnlockedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// identityStore holds unlocks in memory; they lapse after ttl.
+type identityStore struct {
+	mu       sync.Mutex
+	sessions map[string]IdentitySession
+	ttl      time.Duration
+}
+
+func newIdentityStore(ttl time.Duration) *identityStore {
+	return &identityStore{sessions: make(map[string]IdentitySession), ttl: ttl}
+}
+
+func (s *identityStore) get(userID string) (IdentitySession, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sess, exists := s.sessions[userID]
+	if !exists {
+		return IdentitySession{}, false
+	}
+	if time.Now().After(sess.ExpiresAt) {
+		delete(s.sessions, userID)
+		return IdentitySession{}, false
+	}
+	return sess, true
+}
+
+func (s *identityStore) unlock(userID string) IdentitySession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	sess := IdentitySession{UnlockedAt: now, ExpiresAt: now.Add(s.ttl)}
+	s.sessions[userID] = sess
+	return sess
+}
+
+// active counts unexpired unlocks.
+func (s *identityStore) active() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	n := 0
+	for _, sess := range s.sessions {
+		if now.Before(sess.ExpiresAt) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *identityStore) lock(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, userID)
+}
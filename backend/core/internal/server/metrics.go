@@ -0,0 +1,52 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/metrics"
+)
+
+// serverMetrics are the core's Prometheus metrics. Labels are routes,
+// methods and statuses only; nothing identifies a user.
+type serverMetrics struct {
+	reg      *metrics.Registry
+	requests *metrics.Counter
+	duration *metrics.Histogram
+	fsync    *metrics.Histogram
+}
+
+func newServerMetrics(s *Server) *serverMetrics {
+	reg := metrics.NewRegistry()
+	m := &serverMetrics{
+		reg: reg,
+		requests: reg.Counter("privxx_core_http_requests_total",
+			"HTTP requests by route, method and status.", "route", "method", "status"),
+		duration: reg.Histogram("privxx_core_http_request_duration_seconds",
+			"HTTP request latency by route and method.", metrics.DefBuckets, "route", "method"),
+		fsync: reg.Histogram("privxx_core_msgstore_fsync_seconds",
+			"Latency of message store write-ahead log fsyncs.", metrics.DefBuckets),
+	}
+	reg.GaugeFunc("privxx_core_identity_sessions", "Unexpired identity unlocks.",
+		func() float64 { return float64(s.identity.active()) })
+	reg.GaugeFunc("privxx_core_msgstore_conversations", "Conversations in the message store.",
+		func() float64 { return float64(s.msgStore.Stats().Conversations) })
+	reg.GaugeFunc("privxx_core_msgstore_messages", "Messages in the message store.",
+		func() float64 { return float64(s.msgStore.Stats().Messages) })
+	reg.GaugeFunc("privxx_core_msgstore_log_entries", "Write-ahead log entries since the last snapshot.",
+		func() float64 { return float64(s.msgStore.Stats().LogEntries) })
+	s.msgStore.ObserveSync(func(d time.Duration) { m.fsync.Observe(d.Seconds()) })
+	return m
+}
+
+// observe records one request. route is a registered path or "unmatched"
+// and method is bucketed, so neither can grow the label set.
+func (m *serverMetrics) observe(route string, r *http.Request, status int, d time.Duration) {
+	method := metrics.MethodLabel(r.Method)
+	m.requests.Inc(route, method, strconv.Itoa(status))
+	m.duration.Observe(d.Seconds(), route, method)
+}
+
+// Metrics is the registry to serve on the metrics listener.
+func (s *Server) Metrics() *metrics.Registry { return s.metrics.reg }
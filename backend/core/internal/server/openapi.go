@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+)
+
+// OpenAPI builds the OpenAPI 3 document from the route table and the
+// contracts types (by reflection over their JSON tags). Keys are maps, so
+// json.Marshal output is stable and can be checked in (openapi.json).
+func (s *Server) OpenAPI() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]any{}
+
+	for _, rt := range s.routes {
+		op := map[string]any{
+			"summary":     rt.summary,
+			"operationId": operationID(rt.path),
+		}
+		ok := map[string]any{"description": "OK"}
+		if rt.out != nil {
+			ok["content"] = jsonContent(schemaFor(rt.out, schemas))
+		}
+		responses := map[string]any{"200": ok}
+		if rt.in != nil {
+			op["parameters"] = []any{map[string]any{"$ref": "#/components/parameters/RequestID"}}
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(schemaFor(rt.in, schemas)),
+			}
+			responses["default"] = map[string]any{
+				"description": "Failure: ok=false, error.code set; the HTTP status follows the code",
+				"content":     jsonContent(schemaFor(rt.out, schemas)),
+			}
+		}
+		op["responses"] = responses
+		paths[rt.path] = map[string]any{strings.ToLower(rt.method): op}
+	}
+
+	// error.code is one of the contracts codes.
+	if er, ok := schemas["ErrorResponse"].(map[string]any); ok {
+		props := er["properties"].(map[string]any)
+		props["code"] = map[string]any{"type": "string", "enum": contracts.ErrorCodes}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Privxx backend core",
+			"version":     Version,
+			"description": "Localhost API used by the bridge. Every /v1 endpoint takes a POST with a contracts intent and answers with a result envelope (v, requestId, ok, error).",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"parameters": map[string]any{
+				"RequestID": map[string]any{
+					"name":        contracts.RequestIDHeader,
+					"in":          "header",
+					"required":    false,
+					"description": "Echoed on the response and in requestId; generated when absent",
+					"schema":      map[string]any{"type": "string"},
+				},
+			},
+		},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.OpenAPI())
+}
+
+// MarshalOpenAPI is the document as written to openapi.json.
+func (s *Server) MarshalOpenAPI() ([]byte, error) {
+	b, err := json.MarshalIndent(s.OpenAPI(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+// operationID turns /v1/identity/unlock into identityUnlock.
+func operationID(path string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "v1" {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '.' || r == '-' || r == '_' }) {
+			if b.Len() == 0 {
+				b.WriteString(word)
+			} else {
+				b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+			}
+		}
+	}
+	return b.String()
+}
+
+func jsonContent(schema any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// schemaFor returns the schema for t, registering named structs in schemas
+// and referring to them by $ref.
+func schemaFor(t reflect.Type, schemas map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		if _, done := schemas[t.Name()]; !done {
+			schemas[t.Name()] = nil // placeholder against recursion
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema describes t's JSON object. Embedded envelopes (Intent,
+// Result) become allOf references so every operation shows the shared part.
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	props := map[string]any{}
+	var required []string
+	var embedded []any
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			embedded = append(embedded, schemaFor(f.Type, schemas))
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = schemaFor(f.Type, schemas)
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	own := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		own["required"] = required
+	}
+	if len(embedded) == 0 {
+		return own
+	}
+	if len(props) > 0 {
+		embedded = append(embedded, own)
+	}
+	return map[string]any{"allOf": embedded}
+}
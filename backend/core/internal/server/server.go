@@ -0,0 +1,284 @@
+// Package server is the backend core HTTP API: one route table, the
+// contracts envelopes on every endpoint, and an OpenAPI document generated
+// from the same table.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Bulldog-Master/privxx/backend/core/contracts"
+	"github.com/Bulldog-Master/privxx/backend/core/internal/msgstore"
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+// Version is reported by /health and the OpenAPI document.
+const Version = "0.5.0"
+
+// maxIntentBytes bounds a request body.
+const maxIntentBytes = 1 << 20
+
+// Config holds the core's startup settings.
+type Config struct {
+	IdentityTTL time.Duration // how long an unlock lasts
+	DataDir     string        // message store directory; "" keeps messages in memory
+	Stub        bool          // reported in /health; set by privxx-backend-stub
+}
+
+// Server owns the core's state and its route table.
+type Server struct {
+	cfg      Config
+	mux      *http.ServeMux
+	routes   []route
+	identity *identityStore
+	msgStore *msgstore.Store
+	metrics  *serverMetrics
+}
+
+// route is one row of the table: the mux registration and the OpenAPI
+// operation both come from it.
+type route struct {
+	method  string
+	path    string
+	summary string
+	in, out reflect.Type // nil in for GET
+	serve   http.HandlerFunc
+}
+
+// New builds the server, loading the message store from cfg.DataDir.
+func New(cfg Config) (*Server, error) {
+	s := &Server{
+		cfg:      cfg,
+		mux:      http.NewServeMux(),
+		identity: newIdentityStore(cfg.IdentityTTL),
+		msgStore: msgstore.New(),
+	}
+	if cfg.DataDir != "" {
+		ms, err := msgstore.Open(cfg.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("message store: %w", err)
+		}
+		s.msgStore = ms
+	}
+	s.metrics = newServerMetrics(s)
+
+	s.routes = []route{
+		get(contracts.PathHealth, "Liveness and capabilities", s.handleHealth, contracts.Health{}),
+		get(contracts.PathOpenAPI, "This document", s.handleOpenAPI, nil),
+
+		rpc(s, contracts.PathUnlock, "Unlock the caller's identity", (*Server).unlock),
+		rpc(s, contracts.PathLock, "Lock the caller's identity", (*Server).lock),
+		rpc(s, contracts.PathIdentityStatus, "Identity unlock state", (*Server).identityStatus),
+
+		rpc(s, contracts.PathConnect, "Connect intent (requires unlock)", (*Server).connect),
+		rpc(s, contracts.PathDisconnect, "Disconnect", (*Server).disconnect),
+		rpc(s, contracts.PathStatus, "Backend and identity status", (*Server).status),
+
+		rpc(s, contracts.PathCreateConversation, "Create a conversation", (*Server).createConversation),
+		rpc(s, contracts.PathOpenConversation, "Open a conversation (participants only)", (*Server).openConversation),
+		rpc(s, contracts.PathListConversations, "The caller's conversations", (*Server).listConversations),
+		rpc(s, contracts.PathSendMessage, "Send a message", (*Server).sendMessage),
+		rpc(s, contracts.PathInbox, "Messages for the caller", (*Server).inbox),
+		rpc(s, contracts.PathThread, "Messages in one conversation (members only)", (*Server).thread),
+		rpc(s, contracts.PathAck, "Mark received messages consumed", (*Server).ack),
+	}
+	for _, rt := range s.routes {
+		s.mux.HandleFunc(rt.path, rt.serve)
+	}
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, fail(contracts.ErrNotFound, "no such endpoint"))
+	})
+	return s, nil
+}
+
+// Close closes the message store.
+func (s *Server) Close() error { return s.msgStore.Close() }
+
+// Handler returns the core's HTTP handler with request IDs, access logs and
+// request metrics.
+func (s *Server) Handler() http.Handler { return s.logMiddleware(s.mux) }
+
+// logMiddleware accepts the caller's X-Request-Id (or assigns one), puts it
+// in the request context, logs one line per request and records it in the
+// metrics. The logged ID is the one echoed on the response, which an
+// intent's requestId may override.
+func (s *Server) logMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := strings.TrimSpace(r.Header.Get(contracts.RequestIDHeader))
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(contracts.RequestIDHeader, id)
+		r = r.WithContext(logging.WithRequestID(r.Context(), id))
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		route := "unmatched"
+		if _, p := s.mux.Handler(r); p != "/" {
+			route = p
+		}
+		s.metrics.observe(route, r, rec.status, time.Since(start))
+		slog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			logging.RequestIDKey, w.Header().Get(contracts.RequestIDHeader))
+	})
+}
+
+// statusRecorder keeps the response status for the access log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// fail builds the error a handler returns; its code decides the HTTP status.
+func fail(code, msg string) error {
+	return &contracts.ErrorResponse{Code: code, Message: contracts.Ptr(msg)}
+}
+
+type intentPtr[I any] interface {
+	*I
+	IntentHead() *contracts.Intent
+}
+
+type resultPtr[O any] interface {
+	*O
+	ResultHead() *contracts.Result
+}
+
+// rpc adapts a typed handler to a POST route. Before h runs, the intent has
+// been decoded, its v checked and its userId resolved; afterwards the result
+// envelope (v, requestId, ok, error) is filled in here, never by h.
+func rpc[I, O any, PI intentPtr[I], PO resultPtr[O]](s *Server, path, summary string, h func(*Server, PI) (PO, error)) route {
+	serve := func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, r, fail(contracts.ErrMethodNotAllowed, "use POST"))
+			return
+		}
+		in := PI(new(I))
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxIntentBytes)).Decode(in); err != nil {
+			writeError(w, r, fail(contracts.ErrInvalidInput, "invalid json"))
+			return
+		}
+		head := in.IntentHead()
+		head.RequestID = requestID(w, r, head.RequestID)
+
+		out := PO(new(O))
+		err := checkIntent(r, head)
+		if err == nil {
+			var res PO
+			if res, err = h(s, in); err == nil && res != nil {
+				out = res
+			}
+		}
+		env := out.ResultHead()
+		env.RequestID = head.RequestID
+		writeResult(w, r, out, env, err)
+	}
+	return route{
+		method:  http.MethodPost,
+		path:    path,
+		summary: summary,
+		in:      reflect.TypeOf((*I)(nil)).Elem(),
+		out:     reflect.TypeOf((*O)(nil)).Elem(),
+		serve:   serve,
+	}
+}
+
+func get(path, summary string, h http.HandlerFunc, out any) route {
+	rt := route{method: http.MethodGet, path: path, summary: summary}
+	if out != nil {
+		rt.out = reflect.TypeOf(out)
+	}
+	rt.serve = func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, r, fail(contracts.ErrMethodNotAllowed, "use GET"))
+			return
+		}
+		h(w, r)
+	}
+	return rt
+}
+
+// checkIntent validates the envelope: the contract version, and the caller.
+// X-User-Id may stand in for userId but may not contradict it.
+func checkIntent(r *http.Request, head *contracts.Intent) error {
+	if head.V != contracts.ContractVersionV1 {
+		return fail(contracts.ErrUnsupportedVersion, "expected v=1")
+	}
+	if hdr := strings.TrimSpace(r.Header.Get("X-User-Id")); hdr != "" {
+		if head.UserID == "" {
+			head.UserID = hdr
+		} else if head.UserID != hdr {
+			return fail(contracts.ErrInvalidInput, "userId does not match X-User-Id")
+		}
+	}
+	if head.UserID == "" {
+		return fail(contracts.ErrInvalidInput, "userId required")
+	}
+	return nil
+}
+
+// writeResult stamps the envelope res (embedded in out) and sends out. On
+// err, out is expected to be an empty result of the route's type.
+func writeResult(w http.ResponseWriter, r *http.Request, out any, res *contracts.Result, err error) {
+	res.V = contracts.ContractVersionV1
+	status := http.StatusOK
+	if err != nil {
+		var e *contracts.ErrorResponse
+		if !errors.As(err, &e) {
+			slog.ErrorContext(r.Context(), "internal error", "err", err)
+			e = &contracts.ErrorResponse{Code: contracts.ErrInternal, Message: contracts.Ptr("internal error")}
+		}
+		res.Ok, res.Error = false, e
+		status = contracts.HTTPStatus(e.Code)
+	} else {
+		res.Ok, res.Error = true, nil
+	}
+	writeJSON(w, status, out)
+}
+
+// writeError answers with a bare Result envelope, for failures before a
+// route's result type is known.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	res := &contracts.Result{RequestID: requestID(w, r, "")}
+	writeResult(w, r, res, res, err)
+}
+
+// requestID prefers a well-formed requestId from the intent, then the one
+// logMiddleware assigned, and echoes the result on the response header.
+func requestID(w http.ResponseWriter, r *http.Request, fromIntent string) string {
+	id := strings.TrimSpace(fromIntent)
+	if !logging.ValidRequestID(id) {
+		id = logging.RequestID(r.Context())
+	}
+	if id == "" {
+		id = logging.NewRequestID()
+	}
+	w.Header().Set(contracts.RequestIDHeader, id)
+	return id
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
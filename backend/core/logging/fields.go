@@ -0,0 +1,106 @@
+package logging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+// Field is one classified log attribute. Only the constructors below make
+// one, so every attribute says how private its value is:
+//
+//	Public     safe as is: routes, statuses, counts, durations, purposes
+//	Pseudonym  links lines about one person or session (subjects, user,
+//	           session and conversation IDs, client IPs): logged as a keyed hash
+//	Secret     never logged (tokens, passwords, proofs): only whether it was set
+type Field struct {
+	attr slog.Attr
+}
+
+// Label is a public string value. A constant converts implicitly; a string
+// variable needs an explicit Label(v), which is visible in review.
+type Label string
+
+// Msg is a log message. Like Label it takes constants without ceremony, so
+// values go in fields rather than being formatted into the message.
+type Msg string
+
+// publicValue lists what Public accepts: numbers, booleans, times and
+// Labels, but not plain strings.
+type publicValue interface {
+	~bool | ~int | ~int64 | ~uint64 | ~float64 | time.Time | Label
+}
+
+// Public logs v unchanged (apart from the filter every record passes).
+func Public[T publicValue](key string, v T) Field {
+	switch x := any(v).(type) {
+	case Label:
+		return Field{slog.String(key, string(x))}
+	case time.Duration:
+		return Field{slog.Duration(key, x)}
+	default:
+		return Field{slog.Any(key, v)}
+	}
+}
+
+// Pseudonym logs a keyed hash of v: equal values give equal hashes within a
+// process (across processes with the same LOG_PSEUDONYM_KEY), and the value
+// cannot be read back. An empty v stays empty.
+func Pseudonym(key, v string) Field {
+	if v == "" {
+		return Field{slog.String(key, "")}
+	}
+	return Field{slog.String(key, pseudonymize(v))}
+}
+
+// Secret logs only whether v was set.
+func Secret(key, v string) Field {
+	if v == "" {
+		return Field{slog.String(key, "")}
+	}
+	return Field{slog.String(key, Redacted)}
+}
+
+// Err logs err's text through the filter (no tokens, fingerprints or URL
+// paths). Errors that embed user data should not be logged with it.
+func Err(err error) Field {
+	if err == nil {
+		return Field{slog.String("err", "")}
+	}
+	return Field{slog.String("err", Scrub(err.Error()))}
+}
+
+// pseudonymKey keys Pseudonym. Setup replaces the per-process random key
+// with LOG_PSEUDONYM_KEY when that is set.
+var pseudonymKey = func() []byte {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return b
+}()
+
+func pseudonymize(v string) string {
+	mac := hmac.New(sha256.New, pseudonymKey)
+	mac.Write([]byte(v))
+	return "p_" + hex.EncodeToString(mac.Sum(nil)[:6])
+}
+
+func Debug(ctx context.Context, msg Msg, fields ...Field) { emit(ctx, slog.LevelDebug, msg, fields) }
+func Info(ctx context.Context, msg Msg, fields ...Field)  { emit(ctx, slog.LevelInfo, msg, fields) }
+func Warn(ctx context.Context, msg Msg, fields ...Field)  { emit(ctx, slog.LevelWarn, msg, fields) }
+func Error(ctx context.Context, msg Msg, fields ...Field) { emit(ctx, slog.LevelError, msg, fields) }
+
+func emit(ctx context.Context, level slog.Level, msg Msg, fields []Field) {
+	l := slog.Default()
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	attrs := make([]slog.Attr, len(fields))
+	for i, f := range fields {
+		attrs[i] = f.attr
+	}
+	l.LogAttrs(ctx, level, string(msg), attrs...)
+}
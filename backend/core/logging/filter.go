@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces a value the filter will not log.
+const Redacted = "[redacted]"
+
+// secretKeyParts mark attributes whose value is never logged, whatever it
+// looks like: credentials, identity fingerprints and email addresses.
+var secretKeyParts = []string{"token", "authorization", "password", "secret", "cookie", "dpop", "proof", "fingerprint", "email"}
+
+var (
+	bearerRe      = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+	jwtRe         = regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	urlRe         = regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^\s"'<>]+`)
+	fingerprintRe = regexp.MustCompile(`\b[0-9a-fA-F]{64}\b`) // SHA-256 hex, as contacts.Fingerprint
+	emailRe       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)+`)
+)
+
+// Scrub removes bearer tokens, JWTs, SHA-256 fingerprints and email
+// addresses from s and cuts URLs down to scheme and host.
+func Scrub(s string) string {
+	s = bearerRe.ReplaceAllString(s, "Bearer "+Redacted)
+	s = jwtRe.ReplaceAllString(s, Redacted)
+	s = urlRe.ReplaceAllStringFunc(s, urlHost)
+	s = fingerprintRe.ReplaceAllString(s, Redacted)
+	return emailRe.ReplaceAllString(s, Redacted)
+}
+
+// urlHost keeps scheme://host of a URL; path, query and user info can carry
+// what the user browsed or a credential.
+func urlHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return Redacted
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+func secretKey(key string) bool {
+	k := strings.ToLower(key)
+	for _, p := range secretKeyParts {
+		if strings.Contains(k, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubAttr applies the privacy rules to one attribute.
+func scrubAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	if secretKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		out := make([]any, len(attrs))
+		for i, g := range attrs {
+			out[i] = scrubAttr(g)
+		}
+		return slog.Group(a.Key, out...)
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		// Errors, structs and maps are logged as their scrubbed text.
+		return slog.String(a.Key, Scrub(fmt.Sprint(a.Value.Any())))
+	default:
+		return a
+	}
+}
+
+// filter is the slog.Handler wrapper every logger from New goes through.
+type filter struct {
+	next slog.Handler
+}
+
+func (f *filter) Enabled(ctx context.Context, l slog.Level) bool {
+	return f.next.Enabled(ctx, l)
+}
+
+func (f *filter) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Scrub(r.Message), r.PC)
+	haveID := false
+	r.Attrs(func(a slog.Attr) bool {
+		haveID = haveID || a.Key == RequestIDKey
+		out.AddAttrs(scrubAttr(a))
+		return true
+	})
+	if id := RequestID(ctx); id != "" && !haveID {
+		out.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	return f.next.Handle(ctx, out)
+}
+
+func (f *filter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		out[i] = scrubAttr(a)
+	}
+	return &filter{next: f.next.WithAttrs(out)}
+}
+
+func (f *filter) WithGroup(name string) slog.Handler {
+	return &filter{next: f.next.WithGroup(name)}
+}
@@ -0,0 +1,117 @@
+// Package logging is the structured (log/slog) logger shared by the backend
+// core and the bridge. New code logs through Debug/Info/Warn/Error with
+// classified fields (Public, Pseudonym, Secret); every record, including
+// output of the standard log package, also passes a privacy filter, and the
+// request ID carried in a context is added to every record logged with it.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// RequestIDKey is the attribute holding the request ID.
+const RequestIDKey = "request_id"
+
+type requestIDKey struct{}
+
+// WithRequestID attaches a request ID; the backend client sends it on as
+// X-Request-Id and records logged with the context carry it.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID set by WithRequestID, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a fresh random request ID (32 hex characters).
+func NewRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// ValidRequestID reports whether a caller-supplied ID may be used as is:
+// 1 to 64 characters from [A-Za-z0-9._:-]. Anything else is replaced, so a
+// client cannot put arbitrary text into the logs.
+func ValidRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == ':', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Options selects the level and output format, and optionally the key for
+// Pseudonym hashes (default: random per process).
+type Options struct {
+	Level        slog.Level
+	JSON         bool
+	PseudonymKey []byte
+}
+
+// OptionsFromEnv reads LOG_LEVEL (debug, info, warn, error; default info),
+// LOG_FORMAT (text or json; default text) and LOG_PSEUDONYM_KEY (hex).
+func OptionsFromEnv() (Options, error) {
+	var o Options
+	switch strings.ToLower(strings.TrimSpace(os.Getenv("LOG_LEVEL"))) {
+	case "debug":
+		o.Level = slog.LevelDebug
+	case "warn", "warning":
+		o.Level = slog.LevelWarn
+	case "error":
+		o.Level = slog.LevelError
+	}
+	o.JSON = strings.EqualFold(strings.TrimSpace(os.Getenv("LOG_FORMAT")), "json")
+	if k := strings.TrimSpace(os.Getenv("LOG_PSEUDONYM_KEY")); k != "" {
+		b, err := hex.DecodeString(k)
+		if err != nil || len(b) < 16 {
+			return o, fmt.Errorf("LOG_PSEUDONYM_KEY: want at least 32 hex characters")
+		}
+		o.PseudonymKey = b
+	}
+	return o, nil
+}
+
+// New returns a logger writing to w through the privacy filter. A
+// PseudonymKey in o applies process-wide.
+func New(w io.Writer, o Options) *slog.Logger {
+	if o.PseudonymKey != nil {
+		pseudonymKey = o.PseudonymKey
+	}
+	ho := &slog.HandlerOptions{Level: o.Level}
+	var h slog.Handler = slog.NewTextHandler(w, ho)
+	if o.JSON {
+		h = slog.NewJSONHandler(w, ho)
+	}
+	return slog.New(&filter{next: h})
+}
+
+// Setup installs the process-wide logger for component (stderr, options
+// from the environment). The standard log package is routed through it too,
+// so existing log.Printf lines are filtered and leveled at info.
+func Setup(component string) (*slog.Logger, error) {
+	o, err := OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	l := New(os.Stderr, o).With("component", component)
+	slog.SetDefault(l)
+	return l, nil
+}
@@ -0,0 +1,39 @@
+#!/usr/bin/env bash
+# Privacy guarantees of the logging package: known secrets logged every way
+# the package allows never reach the output (cmd/logging-check), and the
+# message and Public parameters refuse plain string variables at compile time.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+go run ./cmd/logging-check
+
+echo "== compile-time guards =="
+WORK=$(mktemp -d ./.logging-check.XXXXXX)
+trap 'rm -rf "$WORK"' EXIT
+compiles() {
+  cat >"$WORK/main.go" <<GO
+package main
+
+import (
+	"context"
+
+	"github.com/Bulldog-Master/privxx/backend/core/logging"
+)
+
+func main() {
+	token := "secret"
+	$1
+}
+GO
+  go build -o /dev/null "./$WORK" 2>/dev/null
+}
+expect_reject() {
+  if compiles "$1"; then echo "FAIL compiles: $1"; exit 1; fi
+  echo "ok   rejected: $1"
+}
+# Control: the same program with classified fields builds.
+compiles 'logging.Info(context.Background(), "m", logging.Secret("token", token), logging.Pseudonym("user", token))' \
+  || { echo "FAIL control program does not build"; exit 1; }
+expect_reject 'logging.Info(context.Background(), "m", logging.Public("token", token))'
+expect_reject 'logging.Info(context.Background(), token)'
+echo "logging compile-time guards OK"
@@ -0,0 +1,336 @@
+// Package metrics is a small Prometheus-compatible metrics registry shared
+// by the backend core and the bridge: counters, gauges and histograms with
+// fixed label sets, written in the text exposition format (0.0.4). Label
+// values must come from small fixed sets (routes, statuses, reasons), never
+// from users, so a scrape cannot identify anyone.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are latency buckets in seconds, from 1ms to 10s.
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Registry holds metrics and serves them.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+type metric interface {
+	write(w io.Writer, name string)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register panics on a duplicate name, so a conflict fails at startup.
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.metrics[name]; dup {
+		panic("metrics: duplicate metric " + name)
+	}
+	r.metrics[name] = m
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec: newVec(help, "counter", labels)}
+	r.register(name, c)
+	return c
+}
+
+// Gauge registers a gauge with the given label names.
+func (r *Registry) Gauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec: newVec(help, "gauge", labels)}
+	r.register(name, g)
+	return g
+}
+
+// GaugeFunc registers an unlabeled gauge read from f at scrape time.
+func (r *Registry) GaugeFunc(name, help string, f func() float64) {
+	r.register(name, &gaugeFunc{help: help, f: f})
+}
+
+// Histogram registers a histogram with the given upper bounds (ascending)
+// and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{vec: newVec(help, "histogram", labels), buckets: buckets}
+	r.register(name, h)
+	return h
+}
+
+// Handler serves every metric in the text format, sorted by name.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		r.Write(w)
+	})
+}
+
+// Write writes every metric in the text format, sorted by name.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for n := range r.metrics {
+		names = append(names, n)
+	}
+	ms := r.metrics
+	r.mu.Unlock()
+	sort.Strings(names)
+	for _, n := range names {
+		ms[n].write(w, n)
+	}
+}
+
+// ---------------- Series ----------------
+
+// vec holds one metric's series keyed by label values.
+type vec struct {
+	help, typ string
+	labels    []string
+	mu        sync.Mutex
+	series    map[string]*series
+}
+
+type series struct {
+	values []string
+	v      float64  // counter and gauge
+	counts []uint64 // histogram, per bucket (not cumulative)
+	sum    float64  // histogram
+	count  uint64   // histogram
+}
+
+func newVec(help, typ string, labels []string) vec {
+	return vec{help: help, typ: typ, labels: labels, series: make(map[string]*series)}
+}
+
+// get returns the series for values, creating it. Callers hold v.mu.
+func (v *vec) get(values []string) *series {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: got %d label values, want %d", len(values), len(v.labels)))
+	}
+	key := strings.Join(values, "\xff")
+	s := v.series[key]
+	if s == nil {
+		s = &series{values: append([]string(nil), values...)}
+		v.series[key] = s
+	}
+	return s
+}
+
+// sorted returns the series in label order. Callers hold v.mu.
+func (v *vec) sorted() []*series {
+	keys := make([]string, 0, len(v.series))
+	for k := range v.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]*series, len(keys))
+	for i, k := range keys {
+		out[i] = v.series[k]
+	}
+	return out
+}
+
+func (v *vec) header(w io.Writer, name string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(v.help), name, v.typ)
+}
+
+// labelString renders {a="x",b="y"} plus extra (already rendered) pairs.
+func (v *vec) labelString(values []string, extra string) string {
+	var b strings.Builder
+	for i, l := range v.labels {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	if extra != "" {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extra)
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "{" + b.String() + "}"
+}
+
+// ---------------- Counter / Gauge ----------------
+
+type Counter struct{ vec }
+
+// Inc adds one to the series for label values.
+func (c *Counter) Inc(values ...string) { c.Add(1, values...) }
+
+// Add adds d (>= 0) to the series for label values.
+func (c *Counter) Add(d float64, values ...string) {
+	if d < 0 {
+		panic("metrics: counter decreased")
+	}
+	c.mu.Lock()
+	c.get(values).v += d
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w io.Writer, name string) { c.writeValues(w, name) }
+
+type Gauge struct{ vec }
+
+// Set sets the series for label values.
+func (g *Gauge) Set(x float64, values ...string) {
+	g.mu.Lock()
+	g.get(values).v = x
+	g.mu.Unlock()
+}
+
+// Add adds d (may be negative) to the series for label values.
+func (g *Gauge) Add(d float64, values ...string) {
+	g.mu.Lock()
+	g.get(values).v += d
+	g.mu.Unlock()
+}
+
+func (g *Gauge) write(w io.Writer, name string) { g.writeValues(w, name) }
+
+func (v *vec) writeValues(w io.Writer, name string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.header(w, name)
+	if len(v.labels) == 0 && len(v.series) == 0 {
+		fmt.Fprintf(w, "%s 0\n", name) // unlabeled metrics exist from the start
+		return
+	}
+	for _, s := range v.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", name, v.labelString(s.values, ""), formatFloat(s.v))
+	}
+}
+
+type gaugeFunc struct {
+	help string
+	f    func() float64
+}
+
+func (g *gaugeFunc) write(w io.Writer, name string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, escapeHelp(g.help), name, name, formatFloat(g.f()))
+}
+
+// ---------------- Histogram ----------------
+
+type Histogram struct {
+	vec
+	buckets []float64
+}
+
+// Observe records x in the series for label values.
+func (h *Histogram) Observe(x float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, ub := range h.buckets {
+		if x <= ub {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += x
+	s.count++
+}
+
+func (h *Histogram) write(w io.Writer, name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, name)
+	for _, s := range h.sorted() {
+		var cum uint64
+		for i, ub := range h.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelString(s.values, `le="`+formatFloat(ub)+`"`), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, h.labelString(s.values, `le="+Inf"`), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", name, h.labelString(s.values, ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", name, h.labelString(s.values, ""), s.count)
+	}
+}
+
+// MethodLabel maps an HTTP method to a fixed label value, so arbitrary
+// client-chosen methods cannot grow the label set.
+func MethodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodPost, http.MethodOptions, http.MethodHead:
+		return method
+	}
+	return "other"
+}
+
+// ---------------- Listener ----------------
+
+// CheckLocal rejects listen addresses that are not loopback: metrics are
+// for a local scraper (or an SSH tunnel), never the public interface.
+func CheckLocal(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("metrics address %q: %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("metrics address %q: must be a loopback address", addr)
+	}
+	return nil
+}
+
+// NewServer returns an http.Server for reg on addr, which must be loopback.
+// It serves /metrics only.
+func NewServer(addr string, reg *Registry) (*http.Server, error) {
+	if err := CheckLocal(addr); err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg.Handler())
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}, nil
+}
+
+// ---------------- Formatting ----------------
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
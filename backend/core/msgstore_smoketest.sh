@@ -0,0 +1,55 @@
+#!/usr/bin/env bash
+# Message store persistence: conversations, messages and acks survive a
+# restart, and a torn
+# final log entry (a crash mid-write) is dropped instead of failing startup.
+# Builds the core unless CORE_BIN points at a binary.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+PORT="${PORT:-18894}"
+BASE="http://127.0.0.1:$PORT"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" 2>/dev/null && wait "${PID:-}" 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${CORE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/core"
+  go build -o "$BIN" .
+fi
+
+start() {
+  kill "${PID:-}" 2>/dev/null && wait "$PID" 2>/dev/null || true
+  "$BIN" -addr "127.0.0.1:$PORT" -data-dir "$WORK/data" >>"$WORK/core.log" 2>&1 &
+  PID=$!
+  for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && return; sleep 0.1; done
+  echo "core did not start"; cat "$WORK/core.log"; exit 1
+}
+
+call() { curl -sS -X POST "$BASE$1" -d "$2"; }
+
+start
+call /v1/message/send '{"v":1,"userId":"alice","conversationId":"conv_unknown","recipients":["bob"],"payload":"aGk="}' | jq -e '.error.code == "NOT_FOUND"' >/dev/null
+C1=$(call /v1/conversations/create '{"v":1,"userId":"alice","participants":["bob"]}' | jq -er '.conversation.conversationId')
+call /v1/conversations/open '{"v":1,"userId":"bob","participantHint":"alice"}' | jq -e --arg c "$C1" '.conversationId == $c' >/dev/null
+MID=$(call /v1/message/send '{"v":1,"userId":"alice","conversationId":"'"$C1"'","recipients":["bob"],"payload":"aGk="}' | jq -er '.messageId')
+call /v1/message/send '{"v":1,"userId":"bob","conversationId":"'"$C1"'","payload":"eW8="}' | jq -e '.ok' >/dev/null
+call /v1/message/ack "{\"v\":1,\"userId\":\"bob\",\"messageIds\":[\"$MID\"]}" | jq -e '.acked == 1' >/dev/null
+
+echo "== restart: conversations, messages and acks survive =="
+start
+call /v1/conversations/list '{"v":1,"userId":"bob"}' | jq -e --arg c "$C1" '(.conversations | length) == 1 and .conversations[0].conversationId == $c and (.conversations[0].participants | length) == 2'
+call /v1/message/thread '{"v":1,"userId":"alice","conversationId":"'"$C1"'"}' | jq -e '(.messages | length) == 2 and .messages[0].consumed'
+call /v1/message/inbox '{"v":1,"userId":"bob","unconsumedOnly":true}' | jq -e '(.messages | length) == 0'
+call /v1/message/thread '{"v":1,"userId":"carol","conversationId":"'"$C1"'"}' | jq -e '.error.code == "NOT_FOUND"'
+
+echo "== torn tail: partial last entry is dropped =="
+kill "$PID"; wait "$PID" 2>/dev/null || true
+printf '{"op":"add","msg":{"id":"msg_torn' >>"$WORK/data/messages.wal"
+start
+grep -q "dropping torn entry" "$WORK/core.log"
+call /v1/message/thread '{"v":1,"userId":"bob","conversationId":"'"$C1"'"}' | jq -e '(.messages | length) == 2'
+call /v1/message/send '{"v":1,"userId":"alice","conversationId":"'"$C1"'","payload":"YWdhaW4="}' | jq -e '.ok'
+start
+call /v1/message/thread '{"v":1,"userId":"bob","conversationId":"'"$C1"'"}' | jq -e '(.messages | length) == 3'
+
+echo "msgstore smoke OK"
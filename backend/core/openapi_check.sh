@@ -0,0 +1,12 @@
+#!/usr/bin/env bash
+# OpenAPI check: openapi.json must match what the route table and contracts
+# types generate. Regenerate with `go generate .` in the same change that
+# touches a route or a contracts type.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+if ! diff -u openapi.json <(go run . -openapi); then
+  echo "openapi.json is stale; run: go generate ." >&2
+  exit 1
+fi
+echo "openapi.json OK"
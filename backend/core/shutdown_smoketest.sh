@@ -0,0 +1,69 @@
+#!/usr/bin/env bash
+# Graceful shutdown under load: several clients send messages while the core
+# gets SIGTERM. The core must drain, close the store and exit 0, and after a
+# restart every messageId it returned must be in the thread.
+# Builds the core unless CORE_BIN points at a binary.
+set -euo pipefail
+
+cd "$(dirname "$0")"
+PORT="${PORT:-18895}"
+BASE="http://127.0.0.1:$PORT"
+WORKERS="${WORKERS:-8}"
+WORK=$(mktemp -d)
+trap 'kill "${PID:-}" 2>/dev/null && wait "${PID:-}" 2>/dev/null; rm -rf "$WORK"' EXIT
+
+BIN="${CORE_BIN:-}"
+if [ -z "$BIN" ]; then
+  BIN="$WORK/core"
+  go build -o "$BIN" .
+fi
+
+start() {
+  "$BIN" -addr "127.0.0.1:$PORT" -metrics-addr "" -data-dir "$WORK/data" -shutdown-timeout 10s >>"$WORK/core.log" 2>&1 &
+  PID=$!
+  for _ in $(seq 50); do curl -sf "$BASE/health" >/dev/null && return; sleep 0.1; done
+  echo "core did not start"; cat "$WORK/core.log"; exit 1
+}
+
+call() { curl -sS -X POST "$BASE$1" -d "$2"; }
+
+start
+for i in $(seq "$WORKERS"); do
+  call /v1/conversations/create '{"v":1,"userId":"alice","participants":["bob'"$i"'"]}' | jq -er '.conversation.conversationId' >"$WORK/conv.$i"
+done
+
+# Each worker sends into its own conversation until the core stops answering
+# (at most 400, within one thread page) and records every returned messageId.
+worker() {
+  local conv id
+  conv=$(cat "$WORK/conv.$1")
+  for _ in $(seq 400); do
+    id=$(curl -s -X POST "$BASE/v1/message/send" \
+      -d '{"v":1,"userId":"alice","conversationId":"'"$conv"'","payload":"aGk="}' | jq -r '.messageId // empty' 2>/dev/null) || return 0
+    [ -n "$id" ] || return 0
+    echo "$id" >>"$WORK/acked.$1"
+  done
+}
+
+echo "== $WORKERS senders, SIGTERM after 2s =="
+for i in $(seq "$WORKERS"); do worker "$i" & done
+sleep 2
+kill -TERM "$PID"
+STATUS=0
+wait "$PID" || STATUS=$?
+wait
+[ "$STATUS" -eq 0 ] || { echo "core exited $STATUS"; cat "$WORK/core.log"; exit 1; }
+grep -q "\[BACKEND\] stopped" "$WORK/core.log"
+echo "acknowledged sends: $(cat "$WORK"/acked.* 2>/dev/null | wc -l)"
+
+echo "== restart: every acknowledged messageId is stored =="
+start
+for i in $(seq "$WORKERS"); do
+  [ -s "$WORK/acked.$i" ] || continue
+  call /v1/message/thread '{"v":1,"userId":"alice","conversationId":"'"$(cat "$WORK/conv.$i")"'","limit":500}' \
+    | jq -r '.messages[].id' | sort >"$WORK/stored.$i"
+  MISSING=$(sort "$WORK/acked.$i" | comm -23 - "$WORK/stored.$i" | wc -l)
+  [ "$MISSING" -eq 0 ] || { echo "worker $i: $MISSING acknowledged messages lost"; exit 1; }
+done
+
+echo "shutdown checks OK"